@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ofux/pgsac/pkg/exporter"
+	"github.com/ofux/pgsac/pkg/replay"
+
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create the objects of an export in a PostgreSQL database",
+	Long: `Read the objects listed in the manifest.json of an export and execute their files against
+a PostgreSQL database, each after the objects it depends on, inside a single transaction.
+Missing schemas are created first. Nothing is applied if any object fails. This provisions
+a fresh database from committed schema files; objects that already exist make it fail.
+Function bodies are not checked on creation (check_function_bodies is off), as with pg_dump.
+
+With --dry-run, nothing is applied either: the transaction is printed from BEGIN to COMMIT,
+read from the files alone, so it can be reviewed or run with psql.
+
+With --plan, nothing is applied: the steps are listed in execution order, each object with
+the dependencies placing it there, the one created last first. Dependencies missing from the
+export and dependency cycles are reported as errors.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		showPlan, _ := cmd.Flags().GetBool("plan")
+
+		if dryRun && showPlan {
+			return fmt.Errorf("--plan cannot be combined with --dry-run")
+		}
+
+		objects, err := exporter.LoadExport(dir)
+		if err != nil {
+			return err
+		}
+
+		if showPlan {
+			steps, err := replay.Plan(objects)
+			if err != nil {
+				return err
+			}
+			return printPlan(os.Stdout, steps)
+		}
+
+		if dryRun {
+			script, err := replay.Script(objects)
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		}
+
+		config, err := connectionConfig(cmd)
+		if err != nil {
+			return err
+		}
+		db, closer, _, err := connect(cmd, config)
+		if err != nil {
+			return fmt.Errorf("error connecting to database: %w", err)
+		}
+		defer closer.Close()
+
+		if err := replay.Run(cmd.Context(), db, objects, false); err != nil {
+			return fmt.Errorf("error applying %s: %w", dir, err)
+		}
+
+		if !quiet(cmd) {
+			fmt.Printf("Applied %d objects to %s\n", len(objects), config.DBName)
+		}
+		return nil
+	},
+}
+
+// printPlan prints the steps of an apply plan with the dependencies placing each object,
+// and fails when objects depend on objects missing from the export
+func printPlan(w io.Writer, steps []replay.PlanStep) error {
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Step\tType\tObject\tPlaced after")
+	var missing []string
+	for i, s := range steps {
+		if s.Object == nil {
+			fmt.Fprintf(tw, "%d\tschema\t%s\t\n", i+1, s.Schema)
+			continue
+		}
+		name := s.Object.QualifiedName()
+		if s.Object.Args != "" {
+			name += "(" + s.Object.Args + ")"
+		}
+		var after []string
+		for _, dep := range s.After {
+			after = append(after, fmt.Sprintf("%s (step %d)", dep.Name, dep.Step))
+		}
+		for _, dep := range s.Missing {
+			after = append(after, "MISSING "+dep)
+			missing = append(missing, fmt.Sprintf("%s needs %s", name, dep))
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1, s.Object.Type, name, strings.Join(after, ", "))
+	}
+	tw.Flush()
+	// Steps without dependencies leave the padding of the last column behind
+	for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%d dependency(ies) missing from the export:\n  %s", len(missing), strings.Join(missing, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/replay"
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+func TestPrintPlan(t *testing.T) {
+	users := schema.Object{Schema: "app", Name: "users", Type: schema.TableType}
+	f := schema.Object{Schema: "app", Name: "f", Type: schema.FunctionType, Args: "integer"}
+	steps := []replay.PlanStep{
+		{Schema: "app"},
+		{Object: &users},
+		{Object: &f, After: []replay.PlanDependency{{Name: "app.users", Step: 2}}, Missing: []string{"other.t"}},
+	}
+
+	var out strings.Builder
+	err := printPlan(&out, steps)
+	want := `Step  Type      Object          Placed after
+1     schema    app
+2     table     app.users
+3     function  app.f(integer)  app.users (step 2), MISSING other.t
+`
+	if out.String() != want {
+		t.Errorf("printPlan() =\n%s\nwant\n%s", out.String(), want)
+	}
+	if err == nil || !strings.Contains(err.Error(), "app.f(integer) needs other.t") {
+		t.Errorf("printPlan() error = %v, want the missing dependency", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ofux/pgsac/pkg/exporter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that a PostgreSQL database matches the exported files, for CI",
+	Long: `Extract schema information from a PostgreSQL database and compare it with the files of an
+export, without writing anything. Like diff, header comments and whitespace-only changes are
+ignored. Exits with a zero status when the database matches the files; otherwise prints one
+line per drifted object, or the unified diffs with --detail, and exits with status 2.
+Errors, such as a failed connection, exit with status 1.
+The export directory is given with --dir (or --output).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		detail, _ := cmd.Flags().GetBool("detail")
+
+		src, err := openSource(cmd)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		ctx, cancel := src.context(cmd)
+		defer cancel()
+
+		schemas, err := src.extract(ctx)
+		if err != nil {
+			return err
+		}
+
+		exp := exporter.NewExporter(src.options.Output, src.options.Export)
+		diffs, err := exp.Diff(schemas)
+		if err != nil {
+			return fmt.Errorf("error comparing schemas: %w", err)
+		}
+
+		if len(diffs) == 0 {
+			if !quiet(cmd) {
+				fmt.Println("No drift")
+			}
+			return nil
+		}
+
+		for _, d := range diffs {
+			switch {
+			case detail:
+				fmt.Print(d.Unified)
+			case d.Object == "":
+				fmt.Printf("%-8s %s\n", d.Status, d.Path)
+			default:
+				fmt.Printf("%-8s %s %s (%s)\n", d.Status, d.Type, d.Object, d.Path)
+			}
+		}
+		return &driftError{fmt.Sprintf("%d object(s) drifted from %s", len(diffs), src.options.Output)}
+	},
+}
+
+// dirAsOutput lets check take the export directory as --dir, like validate and apply
+func dirAsOutput(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "dir" {
+		name = "output"
+	}
+	return pflag.NormalizedName(name)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// defaultConfigFile is the configuration file picked up from the working directory
+// when --config is not given
+const defaultConfigFile = "pgsac.yaml"
+
+// loadConfig reads the configuration file given by --config, or ./pgsac.yaml when it
+// exists, and uses its values for the flags of the command not set on the command line.
+// Keys are flag names, e.g. host, dbname, schemas or exclude. Values may reference
+// environment variables as $VAR or ${VAR}, so secrets such as passwords stay out of
+// the file; PGPASSWORD and the password file still apply when no password is set.
+func loadConfig(cmd *cobra.Command) error {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFile); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		path = defaultConfigFile
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading config %s: %w", path, err)
+	}
+
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == "config" || !v.IsSet(f.Name) {
+			return
+		}
+		value := v.GetString(f.Name)
+		if strings.HasSuffix(f.Value.Type(), "Slice") {
+			value = strings.Join(v.GetStringSlice(f.Name), ",")
+		}
+		if setErr := cmd.Flags().Set(f.Name, os.ExpandEnv(value)); setErr != nil {
+			err = fmt.Errorf("invalid %s in config %s: %w", f.Name, path, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ofux/pgsac/pkg/exporter"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show differences between a PostgreSQL database and the exported files",
+	Long: `Extract schema information from a PostgreSQL database and compare each object with its
+file in the output directory, without writing anything. A unified diff is printed for every
+object that was added, modified or removed in the database since the last export, with "-"
+lines from the files and "+" lines from the database. Header comments and whitespace-only
+changes are ignored. Exits with status 2 when differences are found, and 1 on errors.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := openSource(cmd)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		ctx, cancel := src.context(cmd)
+		defer cancel()
+
+		schemas, err := src.extract(ctx)
+		if err != nil {
+			return err
+		}
+
+		exp := exporter.NewExporter(src.options.Output, src.options.Export)
+		diffs, err := exp.Diff(schemas)
+		if err != nil {
+			return fmt.Errorf("error comparing schemas: %w", err)
+		}
+
+		if len(diffs) == 0 {
+			if !quiet(cmd) {
+				fmt.Println("No differences")
+			}
+			return nil
+		}
+
+		for _, d := range diffs {
+			fmt.Print(d.Unified)
+		}
+		return &driftError{fmt.Sprintf("%d object(s) differ", len(diffs))}
+	},
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/exporter"
+	"github.com/ofux/pgsac/pkg/schema"
+
+	"github.com/spf13/cobra"
+)
+
+var extractObjectCmd = &cobra.Command{
+	Use:   "extract-object <schema.name>",
+	Short: "Extract a single object from a PostgreSQL database",
+	Long: `Extract the definition of a single object, e.g. public.my_view, finding its type from the
+catalog, without extracting the rest of its schema. A name without a schema is looked up in
+public. The file content, header included, is printed to stdout, or written to its place in
+the tree when --output is given; manifest.json is left untouched.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schemaName, name, found := strings.Cut(args[0], ".")
+		if !found {
+			schemaName, name = "public", args[0]
+		}
+
+		src, err := openSource(cmd)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		ctx, cancel := src.context(cmd)
+		defer cancel()
+
+		obj, err := src.extractor.ExtractObject(ctx, schemaName, name)
+		if src.progress != nil {
+			src.progress.clear()
+		}
+		if err != nil {
+			return fmt.Errorf("error extracting %s: %w", args[0], src.stopReason(ctx, err))
+		}
+		schemas, err := schema.Remap([]schema.Schema{{Name: schemaName, Objects: []schema.Object{obj}}}, src.options.SchemaMap)
+		if err != nil {
+			return err
+		}
+		obj = schema.Redact(schemas, src.options.RedactPatterns)[0].Objects[0]
+
+		exp := exporter.NewExporter(src.options.Output, src.options.Export)
+		if !cmd.Flags().Changed("output") {
+			fmt.Print(exp.Render(obj))
+			return nil
+		}
+		paths, err := exp.ExportObject(obj)
+		if err != nil {
+			return err
+		}
+		if !quiet(cmd) {
+			for _, path := range paths {
+				fmt.Println(path)
+			}
+		}
+		return nil
+	},
+}
@@ -1,21 +1,77 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
-	"github.com/ofux/pgsac/pkg/database"
 	"github.com/ofux/pgsac/pkg/exporter"
+	"github.com/ofux/pgsac/pkg/gitcommit"
+	"github.com/ofux/pgsac/pkg/pgsac"
 	"github.com/ofux/pgsac/pkg/schema"
 
 	"github.com/spf13/cobra"
 )
 
+// Exit codes of pgsac, documented in the help of the root command
+const (
+	exitOK    = 0
+	exitError = 1
+	exitDrift = 2
+)
+
+// driftError is returned by the commands comparing the database with the exported files
+// when they differ, and makes pgsac exit with exitDrift
+type driftError struct {
+	msg string
+}
+
+func (e *driftError) Error() string {
+	return e.msg
+}
+
+// exitCode returns the exit code for the error a command returned
+func exitCode(err error) int {
+	var drift *driftError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &drift):
+		return exitDrift
+	default:
+		return exitError
+	}
+}
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 var rootCmd = &cobra.Command{
-	Use:   "pgsac",
-	Short: "PostgreSQL Schema As Code - A tool to manage database schemas",
+	Use:     "pgsac",
+	Version: version,
+	Short:   "PostgreSQL Schema As Code - A tool to manage database schemas",
 	Long: `PGSAC is a CLI tool that helps you manage PostgreSQL database schemas as code.
-It extracts schema information and generates SQL DDL files organized by schema and object type.`,
+It extracts schema information and generates SQL DDL files organized by schema and object type.
+
+Exit codes:
+  0  success, and no drift found by diff, check or extract --check-drift-json
+  1  error, e.g. a connection, extraction or export failure
+  2  drift: the database differs from the exported files`,
+	// main reports errors, once, without the usage text
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadConfig(cmd)
+	},
 }
 
 var extractCmd = &cobra.Command{
@@ -26,71 +82,378 @@ Each database object (table, view, materialized view, function) will be stored i
 organized by schema and object type.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get flags
-		host, _ := cmd.Flags().GetString("host")
-		port, _ := cmd.Flags().GetInt("port")
-		dbname, _ := cmd.Flags().GetString("dbname")
-		user, _ := cmd.Flags().GetString("user")
-		password, _ := cmd.Flags().GetString("password")
-		sslmode, _ := cmd.Flags().GetString("sslmode")
-		output, _ := cmd.Flags().GetString("output")
-		schemas, _ := cmd.Flags().GetStringSlice("schemas")
-
-		// Create database connection
-		config := database.Config{
-			Host:     host,
-			Port:     port,
-			DBName:   dbname,
-			User:     user,
-			Password: password,
-			SSLMode:  sslmode,
-		}
-
-		db, err := database.Connect(config)
+		failOnSkip, _ := cmd.Flags().GetBool("fail-on-skip")
+		skipAllow, _ := cmd.Flags().GetStringSlice("skip-allow")
+		gitCommit, _ := cmd.Flags().GetBool("git-commit")
+		driftJSON, _ := cmd.Flags().GetString("check-drift-json")
+		maxDefinitionSize, _ := cmd.Flags().GetInt("max-definition-size")
+		strict, _ := cmd.Flags().GetBool("strict")
+		force, _ := cmd.Flags().GetBool("force")
+		manifestStats, _ := cmd.Flags().GetBool("manifest-stats")
+		changedOnly, _ := cmd.Flags().GetBool("changed-only")
+		headerTemplate, _ := cmd.Flags().GetString("header-template")
+		combined, _ := cmd.Flags().GetBool("combined")
+		bundle, _ := cmd.Flags().GetBool("bundle")
+		emitDrops, _ := cmd.Flags().GetBool("emit-drops")
+		dropCascade, _ := cmd.Flags().GetBool("drop-cascade")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		prune, _ := cmd.Flags().GetBool("prune")
+		singleFile, _ := cmd.Flags().GetString("single-file")
+		formatFlag, _ := cmd.Flags().GetString("format")
+		stream, _ := cmd.Flags().GetBool("stream")
+
+		format, err := exporter.ParseFormat(formatFlag)
+		if err != nil {
+			return err
+		}
+		// Both write a single file, which the directory tree options do not apply to
+		for _, option := range []struct {
+			name string
+			set  bool
+		}{
+			{"--single-file", singleFile != ""},
+			{"--format " + formatFlag, format != exporter.FormatSQL},
+		} {
+			if !option.set {
+				continue
+			}
+			for _, name := range []string{"single-file", "prune", "bundle", "emit-drops", "combined", "check-drift-json", "git-commit", "stream"} {
+				if cmd.Flags().Changed(name) && "--"+name != option.name {
+					return fmt.Errorf("%s cannot be combined with --%s", option.name, name)
+				}
+			}
+		}
+
+		if stream {
+			for _, name := range []string{"combined", "check-drift-json", "git-commit", "manifest-stats"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--stream cannot be combined with --%s", name)
+				}
+			}
+		}
+
+		var header *template.Template
+		if headerTemplate != "" {
+			if header, err = exporter.ParseHeaderTemplate(headerTemplate); err != nil {
+				return fmt.Errorf("invalid --header-template: %w", err)
+			}
+		}
+
+		src, err := openSource(cmd)
 		if err != nil {
-			return fmt.Errorf("error connecting to database: %w", err)
+			return err
 		}
-		defer db.Close()
+		defer src.Close()
+
+		// Export options, completed with the extraction stats
+		opts := src.options.Export
+		opts.MaxDefinitionSize = maxDefinitionSize
+		opts.Strict = strict
+		opts.Force = force
+		opts.ChangedOnly = changedOnly
+		opts.HeaderTemplate = header
+		opts.Combined = combined
+		opts.Bundle = bundle
+		opts.Drops = emitDrops
+		opts.DropCascade = dropCascade
+		opts.DryRun = dryRun
+		opts.Prune = prune
+		opts.Version = version
 
 		// Extract schemas
-		extractor := schema.NewExtractor(db, config)
-		extractedSchemas, err := extractor.ExtractSchemas(schemas)
+		ctx, cancel := src.context(cmd)
+		defer cancel()
+
+		if stream {
+			extraction, exp, err := src.streamAll(ctx, opts)
+			if exp != nil {
+				for _, w := range exp.Warnings() {
+					fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if failOnSkip {
+				if err := src.filter.CheckSkips(skipAllow); err != nil {
+					return fmt.Errorf("fail-on-skip: %w", err)
+				}
+			}
+			if !dryRun && !quiet(cmd) {
+				fmt.Printf("Successfully exported %d schemas to %s\n", len(src.options.Schemas), src.options.Output)
+				if changedOnly {
+					fmt.Printf("%d unchanged file(s) left untouched\n", exp.Unchanged())
+				}
+				printStats(os.Stdout, extraction.Stats)
+			}
+			return reportFailures(extraction.Failures)
+		}
+
+		extraction, err := src.extractAll(ctx)
 		if err != nil {
-			return fmt.Errorf("error extracting schemas: %w", err)
+			return err
+		}
+
+		if failOnSkip {
+			if err := src.filter.CheckSkips(skipAllow); err != nil {
+				return fmt.Errorf("fail-on-skip: %w", err)
+			}
 		}
 
 		// Export to files
-		exp := exporter.NewExporter(output)
-		if err := exp.Export(extractedSchemas); err != nil {
-			return fmt.Errorf("error exporting schemas: %w", err)
+		if manifestStats {
+			opts.Stats = extraction.Stats
 		}
+		exp := exporter.NewExporter(src.options.Output, extraction.ExportOptions(opts))
 
-		fmt.Printf("Successfully exported %d schemas to %s\n", len(extractedSchemas), output)
-		return nil
+		if driftJSON != "" {
+			return checkDrift(exp, extraction.Schemas, driftJSON)
+		}
+
+		// A model dump or a single file replaces the directory tree
+		if format != exporter.FormatSQL || singleFile != "" {
+			target := singleFile
+			if format != exporter.FormatSQL {
+				target = filepath.Join(src.options.Output, "schema."+string(format))
+			}
+			if len(extraction.RoleSecurityLabels) > 0 {
+				fmt.Fprintf(os.Stderr, "warning: role security labels are not written to %s\n", target)
+			}
+			if err := pgsac.Export(exp, extraction, format, singleFile); err != nil {
+				return err
+			}
+			for _, w := range exp.Warnings() {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+			}
+			if !dryRun && !quiet(cmd) {
+				fmt.Printf("Successfully exported %d schemas to %s\n", len(extraction.Schemas), target)
+				printStats(os.Stdout, extraction.Stats)
+			}
+			return reportFailures(extraction.Failures)
+		}
+
+		var changes *exporter.Changeset
+		if gitCommit && !dryRun {
+			changes, err = exp.Changes(extraction.Schemas)
+			if err != nil {
+				return fmt.Errorf("error computing changes: %w", err)
+			}
+		}
+
+		if err := pgsac.Export(exp, extraction, format, ""); err != nil {
+			return err
+		}
+		for _, w := range exp.Warnings() {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		}
+		if dryRun {
+			return reportFailures(extraction.Failures)
+		}
+
+		if !quiet(cmd) {
+			fmt.Printf("Successfully exported %d schemas to %s\n", len(extraction.Schemas), src.options.Output)
+			if changedOnly {
+				fmt.Printf("%d unchanged file(s) left untouched\n", exp.Unchanged())
+			}
+			printStats(os.Stdout, extraction.Stats)
+		}
+
+		if gitCommit {
+			committed, err := gitcommit.Commit(src.options.Output, commitMessage(src.config.DBName, changes))
+			if err != nil {
+				return fmt.Errorf("error committing schemas: %w", err)
+			}
+			if quiet(cmd) {
+				return reportFailures(extraction.Failures)
+			}
+			if committed {
+				fmt.Printf("Committed changes (%s)\n", changes.Summary())
+			} else {
+				fmt.Println("No changes to commit")
+			}
+		}
+		return reportFailures(extraction.Failures)
 	},
 }
 
+// parseMapping parses "from=to" pairs into a map
+func parseMapping(pairs []string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range pairs {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("expected from=to, got %q", pair)
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
+// driftReport is the machine-readable result of a drift check
+type driftReport struct {
+	Status  string              `json:"status"` // "clean" or "drift"
+	Summary string              `json:"summary"`
+	Changes *exporter.Changeset `json:"changes"`
+}
+
+// checkDrift compares extracted schemas with the files on disk without writing them.
+// It prints the drifted files, writes a JSON report to jsonPath and returns a driftError when drift is found.
+func checkDrift(exp *exporter.Exporter, schemas []schema.Schema, jsonPath string) error {
+	changes, err := exp.Changes(schemas)
+	if err != nil {
+		return fmt.Errorf("error computing changes: %w", err)
+	}
+
+	report := driftReport{Status: "clean", Summary: changes.Summary(), Changes: changes}
+	if !changes.Empty() {
+		report.Status = "drift"
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding drift report: %w", err)
+	}
+	if err := os.WriteFile(jsonPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing drift report: %w", err)
+	}
+
+	if changes.Empty() {
+		fmt.Println("No drift detected")
+		return nil
+	}
+
+	for _, group := range []struct {
+		marker string
+		paths  []string
+	}{
+		{"+", changes.Added},
+		{"~", changes.Modified},
+		{"-", changes.Removed},
+	} {
+		for _, p := range group.paths {
+			stats := changes.Stats[p]
+			fmt.Printf("%s %s (+%d -%d)\n", group.marker, p, stats.Added, stats.Removed)
+		}
+	}
+	return &driftError{"schema drift detected: " + changes.Summary()}
+}
+
+// reportFailures lists the errors --continue-on-error left objects out for on stderr, and
+// fails when there are any
+func reportFailures(failures []schema.Failure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "%d extraction error(s):\n", len(failures))
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "  %v\n", f.Err)
+	}
+	return fmt.Errorf("%d object(s) or object type(s) could not be extracted", len(failures))
+}
+
+// printStats prints the objects extracted and the time spent for each object type
+func printStats(w io.Writer, stats []schema.StepStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Type\tObjects\tDuration")
+	var objects int
+	var total time.Duration
+	for _, step := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", step.Step, step.Objects, step.Duration.Round(time.Millisecond))
+		objects += step.Objects
+		total += step.Duration
+	}
+	fmt.Fprintf(tw, "total\t%d\t%s\n", objects, total.Round(time.Millisecond))
+	tw.Flush()
+}
+
+// commitMessage summarizes a changeset as a git commit message
+func commitMessage(dbname string, changes *exporter.Changeset) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Update schema of %s\n\n%s\n", dbname, changes.Summary())
+	for _, group := range []struct {
+		label string
+		paths []string
+	}{
+		{"Added", changes.Added},
+		{"Modified", changes.Modified},
+		{"Removed", changes.Removed},
+	} {
+		if len(group.paths) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", group.label)
+		for _, p := range group.paths {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+	}
+	return b.String()
+}
+
 func init() {
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Log each object as it is extracted and each file as it is written, on stderr")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Do not print progress or the final summary")
+	rootCmd.PersistentFlags().String("config", "", "Configuration file supplying defaults for flags (default ./pgsac.yaml when present)")
+
 	// Extract command flags
-	extractCmd.Flags().StringP("host", "H", "localhost", "Database host")
-	extractCmd.Flags().IntP("port", "p", 5432, "Database port")
-	extractCmd.Flags().StringP("dbname", "d", "", "Database name")
-	extractCmd.Flags().StringP("user", "u", "", "Database user")
-	extractCmd.Flags().StringP("password", "P", "", "Database password")
-	extractCmd.Flags().String("sslmode", "disable", "SSL mode (disable, require, verify-ca, verify-full)")
-	extractCmd.Flags().StringP("output", "o", "./schemas", "Output directory for SQL files")
-	extractCmd.Flags().StringSliceP("schemas", "s", []string{"public"}, "Schemas to extract (comma-separated)")
-
-	// Mark required flags
-	extractCmd.MarkFlagRequired("dbname")
-	extractCmd.MarkFlagRequired("user")
+	addSourceFlags(extractCmd)
+	extractCmd.Flags().Bool("prune", false, "Remove pgsac-generated files whose object no longer exists, and type directories left empty")
+	extractCmd.Flags().Bool("dry-run", false, "List the files that would be written, with any file name collisions, without writing them")
+	extractCmd.Flags().Bool("bundle", false, "Also write an install.sql per schema creating its objects in dependency order")
+	extractCmd.Flags().Bool("emit-drops", false, "Also write a drop.sql per schema dropping its objects if they exist, dependents first")
+	extractCmd.Flags().Bool("drop-cascade", false, "Add CASCADE to the statements of drop.sql")
+	extractCmd.Flags().Bool("stream", false, "Write each schema as soon as it is extracted, holding one schema in memory at a time; not with --combined, --check-drift-json, --git-commit or --manifest-stats")
+	extractCmd.Flags().Bool("combined", false, "Also write every object to schema.sql in dependency order; fails on dependency cycles")
+	extractCmd.Flags().String("format", "sql", "Output format: sql (a file per object), json or yaml (the schema model in schema.json or schema.yaml)")
+	extractCmd.Flags().String("single-file", "", "Write every object to this one file in dependency order, with a section per schema and type, instead of the output directory tree")
+	extractCmd.Flags().Int("max-definition-size", 0, "Skip objects whose definition exceeds this many bytes, in their files and in install.sql and schema.sql (0 for no limit)")
+	extractCmd.Flags().Bool("strict", false, "Fail instead of warning, e.g. on oversized definitions")
+	extractCmd.Flags().String("header-template", "", "Go text/template rendering the comment header of object files, with the object's fields, .SchemaName, .Timestamp and .Version; keep its -- Object: and -- Type: lines for --prune")
+	extractCmd.Flags().Bool("manifest-stats", false, "Record the objects extracted and the time spent for each object type in manifest.json")
+	extractCmd.Flags().Bool("changed-only", false, "Only rewrite files whose content hash differs from the file on disk, leaving unchanged files untouched")
+	extractCmd.Flags().Bool("force", false, "Write objects whose file names collide to numbered files with a warning instead of failing")
+	extractCmd.Flags().String("check-drift-json", "", "Compare against the output directory without writing, report drift as JSON to this path and fail on drift")
+	extractCmd.Flags().Bool("git-commit", false, "Commit the exported files into the git repository containing the output directory; fails if changes outside it are already staged")
+	extractCmd.Flags().Bool("continue-on-error", false, "Export every object that could be extracted and report the failures at the end (non-zero exit) instead of stopping at the first error; disables --prune on failure")
+	extractCmd.Flags().Bool("fail-on-skip", false, "Fail if any object is skipped, unless it matches --skip-allow")
+	extractCmd.Flags().StringSlice("skip-allow", nil, "Globs (name or schema.name) of objects allowed to be skipped with --fail-on-skip")
+
+	// Extract-object command flags
+	addSourceFlags(extractObjectCmd)
+
+	// Diff command flags
+	addSourceFlags(diffCmd)
+
+	// Check command flags
+	addSourceFlags(checkCmd)
+	checkCmd.Flags().SetNormalizeFunc(dirAsOutput)
+	checkCmd.Flags().Bool("detail", false, "Print the unified diff of each drifted object instead of its name")
+
+	// Validate command flags
+	addConnectionFlags(validateCmd)
+	validateCmd.Flags().String("dir", "./schemas", "Export directory to validate, holding manifest.json")
+
+	// Apply command flags
+	addConnectionFlags(applyCmd)
+	applyCmd.Flags().String("dir", "./schemas", "Export directory to apply, holding manifest.json")
+	applyCmd.Flags().Bool("dry-run", false, "Print the transaction in execution order, BEGIN to COMMIT, without connecting to the database, e.g. to review it or pipe it to psql")
+	applyCmd.Flags().Bool("plan", false, "List the objects in execution order with the dependencies placing each one, without connecting; fails on missing dependencies and cycles")
 
 	// Add commands to root
 	rootCmd.AddCommand(extractCmd)
+	rootCmd.AddCommand(extractObjectCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(applyCmd)
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the running command instead of killing it mid-write
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
 	}
+	os.Exit(exitCode(err))
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRootLeavesErrorsToMain(t *testing.T) {
+	drift := &cobra.Command{
+		Use: "drift",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return &driftError{msg: "schema drift detected"}
+		},
+	}
+	rootCmd.AddCommand(drift)
+	defer rootCmd.RemoveCommand(drift)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+	rootCmd.SetArgs([]string{"drift"})
+	defer rootCmd.SetArgs(nil)
+
+	err := rootCmd.Execute()
+	if code := exitCode(err); code != exitDrift {
+		t.Errorf("exitCode(%v) = %d, want %d", err, code, exitDrift)
+	}
+	if out.Len() > 0 {
+		t.Errorf("root command printed %q, want main to report the error", out.String())
+	}
+}
+
+func TestExtractStreamRejectsCombined(t *testing.T) {
+	defer func() {
+		for _, name := range []string{"stream", "combined"} {
+			f := extractCmd.Flags().Lookup(name)
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	}()
+	rootCmd.SetArgs([]string{"extract", "--stream", "--combined"})
+	defer rootCmd.SetArgs(nil)
+
+	err := rootCmd.Execute()
+	if err == nil || err.Error() != "--stream cannot be combined with --combined" {
+		t.Errorf("Execute() error = %v, want --stream rejected with --combined", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ofux/pgsac/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+// progressLine rewrites a single terminal line with the extraction progress
+type progressLine struct {
+	out io.Writer
+}
+
+// newProgressLine returns a progress line on stdout when it is a terminal, and nil when
+// stdout is piped or redirected, or when --quiet, --verbose or --explain-skip would clash
+// with it
+func newProgressLine(cmd *cobra.Command) *progressLine {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	explainSkip, _ := cmd.Flags().GetString("explain-skip")
+	if verbose || explainSkip != "" || quiet(cmd) {
+		return nil
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressLine{out: os.Stdout}
+}
+
+// update prints the progress over the previous one
+func (p *progressLine) update(progress schema.Progress) {
+	fmt.Fprintf(p.out, "\r\033[Kextracting %s %d/%d", progress.Step, progress.Done, progress.Total)
+}
+
+// clear erases the progress line so the following output starts on a clean line
+func (p *progressLine) clear() {
+	fmt.Fprint(p.out, "\r\033[K")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestMergeRenames(t *testing.T) {
+	tests := []struct {
+		name      string
+		schemas   []string
+		schemaMap map[string]string
+		renames   map[string]string
+		want      map[string]string
+		wantErr   string
+	}{
+		{
+			name:      "renames and maps",
+			schemas:   []string{"a", "c", "d"},
+			schemaMap: map[string]string{"c": "shared", "d": "shared"},
+			renames:   map[string]string{"a": "b"},
+			want:      map[string]string{"a": "b", "c": "shared", "d": "shared"},
+		},
+		{
+			name:      "swap",
+			schemas:   []string{"a", "b"},
+			schemaMap: map[string]string{},
+			renames:   map[string]string{"a": "b", "b": "a"},
+			want:      map[string]string{"a": "b", "b": "a"},
+		},
+		{
+			name:      "same rename in both",
+			schemas:   []string{"a"},
+			schemaMap: map[string]string{"a": "b"},
+			renames:   map[string]string{"a": "b"},
+			want:      map[string]string{"a": "b"},
+		},
+		{
+			name:      "two renames",
+			schemas:   []string{"a", "c"},
+			schemaMap: map[string]string{},
+			renames:   map[string]string{"a": "b", "c": "b"},
+			wantErr:   "renames both a and c to b",
+		},
+		{
+			name:      "rename onto a schema-map destination",
+			schemas:   []string{"a", "c"},
+			schemaMap: map[string]string{"c": "b"},
+			renames:   map[string]string{"a": "b"},
+			wantErr:   "--schema-map moves c",
+		},
+		{
+			name:      "rename onto a kept schema",
+			schemas:   []string{"a", "b"},
+			schemaMap: map[string]string{},
+			renames:   map[string]string{"a": "b"},
+			wantErr:   "which is also extracted",
+		},
+		{
+			name:      "schema map of a schema not extracted",
+			schemas:   []string{"a"},
+			schemaMap: map[string]string{"c": "b"},
+			renames:   map[string]string{"a": "b"},
+			want:      map[string]string{"a": "b", "c": "b"},
+		},
+		{
+			name:      "renamed and mapped elsewhere",
+			schemas:   []string{"a"},
+			schemaMap: map[string]string{"a": "c"},
+			renames:   map[string]string{"a": "b"},
+			wantErr:   "mapped to c by --schema-map",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mergeRenames(tt.schemas, tt.schemaMap, tt.renames)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergeRenames() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeRenames() error = %v", err)
+			}
+			if !maps.Equal(tt.schemaMap, tt.want) {
+				t.Errorf("schema map = %v, want %v", tt.schemaMap, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionConfigFromEnv(t *testing.T) {
+	for _, name := range []string{"PGHOST", "PGPORT", "PGDATABASE", "PGUSER", "PGPASSWORD", "PGSSLMODE",
+		"PGSSLCERT", "PGSSLKEY", "PGSSLROOTCERT", "PGCONNECT_TIMEOUT", "PGAPPNAME", "PGOPTIONS", "PGSAC_PSQL"} {
+		t.Setenv(name, "")
+	}
+	// Keep a real ~/.pgpass out of the test
+	t.Setenv("PGPASSFILE", filepath.Join(t.TempDir(), "pgpass"))
+
+	env := map[string]string{"PGHOST": "env-host", "PGPORT": "6432", "PGDATABASE": "env_db",
+		"PGUSER": "env_user", "PGPASSWORD": "env secret"}
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{
+			name: "environment",
+			env:  env,
+			want: "host='env-host' port=6432 dbname='env_db' user='env_user' password='env secret' sslmode='disable' search_path=''",
+		},
+		{
+			name: "flags win over the environment",
+			env:  env,
+			args: []string{"--host", "flag-host", "--port", "5433", "--user", "flag_user"},
+			want: "host='flag-host' port=5433 dbname='env_db' user='flag_user' password='env secret' sslmode='disable' search_path=''",
+		},
+		{
+			name: "URL wins over the environment",
+			env:  env,
+			args: []string{"--url", "postgres://url_user@url-host/url_db"},
+			want: "host='url-host' port=6432 dbname='url_db' user='url_user' password='env secret' sslmode='disable' search_path=''",
+		},
+		{
+			name: "flag defaults without environment",
+			args: []string{"--dbname", "app", "--user", "alice"},
+			want: "host='localhost' port=5432 dbname='app' user='alice' password='' sslmode='disable' search_path=''",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cmd := &cobra.Command{}
+			addConnectionFlags(cmd)
+			if err := cmd.Flags().Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			config, err := connectionConfig(cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := config.ConnString(); got != tt.want {
+				t.Errorf("ConnString() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
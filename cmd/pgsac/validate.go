@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ofux/pgsac/pkg/exporter"
+	"github.com/ofux/pgsac/pkg/replay"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check that exported files replay cleanly on a database",
+	Long: `Read the objects listed in the manifest.json of an export and execute their files against
+a PostgreSQL database, each after the objects it depends on, inside a transaction that is
+always rolled back. Missing schemas are created in the same transaction. The target should
+not already hold the exported objects, e.g. a scratch database made with createdb.
+The first object failing to create is reported with the PostgreSQL error.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+
+		objects, err := exporter.LoadExport(dir)
+		if err != nil {
+			return err
+		}
+
+		config, err := connectionConfig(cmd)
+		if err != nil {
+			return err
+		}
+		db, closer, _, err := connect(cmd, config)
+		if err != nil {
+			return fmt.Errorf("error connecting to database: %w", err)
+		}
+		defer closer.Close()
+
+		if err := replay.Run(cmd.Context(), db, objects, true); err != nil {
+			var objErr *replay.ObjectError
+			if errors.As(err, &objErr) {
+				return fmt.Errorf("validation failed on %w", objErr)
+			}
+			return err
+		}
+
+		if !quiet(cmd) {
+			fmt.Printf("All %d objects replayed successfully\n", len(objects))
+		}
+		return nil
+	},
+}
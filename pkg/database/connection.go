@@ -2,9 +2,18 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+)
+
+// Pool defaults applied by Connect to the Config fields left zero
+const (
+	DefaultMaxOpenConns    = 10
+	DefaultConnMaxLifetime = 30 * time.Minute
 )
 
 // Config holds the database connection configuration
@@ -15,28 +24,88 @@ type Config struct {
 	User     string
 	Password string
 	SSLMode  string
+
+	// Client certificate, its key and the root certificates verifying the server, as
+	// file paths. Empty uses the libpq defaults.
+	SSLCert     string
+	SSLKey      string
+	SSLRootCert string
+
+	// Other libpq parameters, passed through as given: connect_timeout in seconds, the
+	// application_name shown in pg_stat_activity and the server options, e.g.
+	// "-c lock_timeout=5s". Empty leaves them unset.
+	ConnectTimeout  string
+	ApplicationName string
+	Options         string
+
+	// PsqlPath is the psql client run for definitions fetched with psql, e.g. to pick the
+	// client matching the server among several installed versions. Empty runs psql from PATH.
+	PsqlPath string
+
+	// Connection pool of the *sql.DB returned by Connect. MaxOpenConns caps the connections
+	// opened at once, so concurrent extraction does not exhaust max_connections on a shared
+	// server; zero uses DefaultMaxOpenConns. Zero MaxIdleConns keeps MaxOpenConns idle
+	// connections for reuse, and zero ConnMaxLifetime uses DefaultConnMaxLifetime.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// StatementTimeout, when set, is the statement_timeout of every session, including
+	// psql's, so no single query runs away, e.g. on a replica. Zero keeps the server's.
+	StatementTimeout time.Duration
 }
 
-// Connect establishes a connection to the PostgreSQL database
-func Connect(config Config) (*sql.DB, error) {
-	if config.SSLMode == "" {
-		config.SSLMode = "disable"
+// ConnString returns the libpq keyword/value connection string for the configuration.
+// Values are quoted, so passwords may contain spaces and quotes. Sessions run with an empty
+// search_path, as pg_dump's do, so the definitions read from the catalog qualify every
+// object whatever the search_path of the role.
+func (c Config) ConnString() string {
+	sslmode := c.SSLMode
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+
+	params := []string{
+		"host=" + connValue(c.Host),
+		fmt.Sprintf("port=%d", c.Port),
+		"dbname=" + connValue(c.DBName),
+		"user=" + connValue(c.User),
+		"password=" + connValue(c.Password),
+		"sslmode=" + connValue(sslmode),
+	}
+	// lib/pq sends unknown keywords to the server as session settings, applied after the
+	// options, so these win over those given there
+	params = append(params, "search_path=''")
+	if c.StatementTimeout > 0 {
+		params = append(params, fmt.Sprintf("statement_timeout=%d", c.StatementTimeout.Milliseconds()))
+	}
+	for _, p := range []struct{ name, value string }{
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+		{"sslrootcert", c.SSLRootCert},
+		{"connect_timeout", c.ConnectTimeout},
+		{"application_name", c.ApplicationName},
+		{"options", c.Options},
+	} {
+		if p.value != "" {
+			params = append(params, p.name+"="+connValue(p.value))
+		}
 	}
+	return strings.Join(params, " ")
+}
 
-	connStr := fmt.Sprintf(
-		"host=%s port=%d dbname=%s user=%s password=%s sslmode=%s",
-		config.Host,
-		config.Port,
-		config.DBName,
-		config.User,
-		config.Password,
-		config.SSLMode,
-	)
+// connValue quotes a connection string value, escaping quotes and backslashes
+func connValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
 
-	db, err := sql.Open("postgres", connStr)
+// Connect establishes a connection to the PostgreSQL database
+func Connect(config Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", config.ConnString())
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
+	config.applyPool(db)
 
 	if err := db.Ping(); err != nil {
 		db.Close()
@@ -45,3 +114,36 @@ func Connect(config Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// applyPool applies the pool settings of the configuration, or their defaults, to db
+func (c Config) applyPool(db *sql.DB) {
+	maxOpen := c.MaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenConns
+	}
+	maxIdle := c.MaxIdleConns
+	if maxIdle <= 0 || maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	lifetime := c.ConnMaxLifetime
+	if lifetime <= 0 {
+		lifetime = DefaultConnMaxLifetime
+	}
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(lifetime)
+}
+
+// statementTimeoutMessage is the server message of statements canceled by statement_timeout,
+// also found in psql's error output
+const statementTimeoutMessage = "canceling statement due to statement timeout"
+
+// IsStatementTimeout reports whether err, from a query or from psql, comes from a statement
+// canceled by Config.StatementTimeout
+func IsStatementTimeout(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "57014" && strings.Contains(pqErr.Message, "statement timeout")
+	}
+	return err != nil && strings.Contains(err.Error(), statementTimeoutMessage)
+}
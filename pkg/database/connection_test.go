@@ -0,0 +1,46 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnString(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{
+			name:   "defaults",
+			config: Config{Host: "localhost", Port: 5432, DBName: "app", User: "alice"},
+			want:   "host='localhost' port=5432 dbname='app' user='alice' password='' sslmode='disable' search_path=''",
+		},
+		{
+			name:   "quoted password",
+			config: Config{Host: "localhost", Port: 5432, DBName: "app", User: "alice", Password: `it's a \secret`},
+			want:   `host='localhost' port=5432 dbname='app' user='alice' password='it\'s a \\secret' sslmode='disable' search_path=''`,
+		},
+		{
+			name: "client certificate",
+			config: Config{Host: "db", Port: 5432, DBName: "app", User: "alice", SSLMode: "verify-full",
+				SSLCert: "/certs/client.crt", SSLKey: "/certs/client key.pem", SSLRootCert: "/certs/root.crt"},
+			want: "host='db' port=5432 dbname='app' user='alice' password='' sslmode='verify-full' search_path=''" +
+				" sslcert='/certs/client.crt' sslkey='/certs/client key.pem' sslrootcert='/certs/root.crt'",
+		},
+		{
+			name: "statement timeout and passed through parameters",
+			config: Config{Host: "db", Port: 5432, DBName: "app", User: "alice", StatementTimeout: 30 * time.Second,
+				ConnectTimeout: "5", ApplicationName: "pgsac", Options: "-c search_path=app"},
+			want: "host='db' port=5432 dbname='app' user='alice' password='' sslmode='disable' search_path='' statement_timeout=30000" +
+				" connect_timeout='5' application_name='pgsac' options='-c search_path=app'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.ConnString(); got != tt.want {
+				t.Errorf("ConnString() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
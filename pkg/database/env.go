@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ApplyEnv fills the fields left empty from the standard libpq environment variables
+// PGHOST, PGPORT, PGDATABASE, PGUSER, PGPASSWORD, PGSSLMODE, PGSSLCERT, PGSSLKEY,
+// PGSSLROOTCERT, PGCONNECT_TIMEOUT, PGAPPNAME and PGOPTIONS, and PsqlPath from PGSAC_PSQL.
+// Fields already set win, matching libpq where connection parameters take precedence over
+// the environment.
+func (c *Config) ApplyEnv() error {
+	for _, v := range []struct {
+		name  string
+		field *string
+	}{
+		{"PGHOST", &c.Host},
+		{"PGDATABASE", &c.DBName},
+		{"PGUSER", &c.User},
+		{"PGPASSWORD", &c.Password},
+		{"PGSSLMODE", &c.SSLMode},
+		{"PGSSLCERT", &c.SSLCert},
+		{"PGSSLKEY", &c.SSLKey},
+		{"PGSSLROOTCERT", &c.SSLRootCert},
+		{"PGCONNECT_TIMEOUT", &c.ConnectTimeout},
+		{"PGAPPNAME", &c.ApplicationName},
+		{"PGOPTIONS", &c.Options},
+		{"PGSAC_PSQL", &c.PsqlPath},
+	} {
+		if *v.field == "" {
+			*v.field = os.Getenv(v.name)
+		}
+	}
+
+	if port := os.Getenv("PGPORT"); c.Port == 0 && port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("invalid PGPORT %q", port)
+		}
+		c.Port = p
+	}
+	return nil
+}
+
+// Environ returns the libpq environment variables passing the password, the SSL mode, the
+// SSL files, the other libpq parameters and the statement timeout of the configuration to a
+// client such as psql, whose session runs with an empty search_path like ConnString's. The
+// SSL mode defaults to disable like ConnString, rather than to libpq's prefer; empty values
+// are left out.
+func (c Config) Environ() []string {
+	sslmode := c.SSLMode
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+	env := []string{"PGPASSWORD=" + c.Password, "PGSSLMODE=" + sslmode}
+	for _, v := range []struct{ name, value string }{
+		{"PGSSLCERT", c.SSLCert},
+		{"PGSSLKEY", c.SSLKey},
+		{"PGSSLROOTCERT", c.SSLRootCert},
+		{"PGCONNECT_TIMEOUT", c.ConnectTimeout},
+		{"PGAPPNAME", c.ApplicationName},
+	} {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value)
+		}
+	}
+	// The search_path and the statement timeout are added to the options, by default those
+	// already given to the client, which the result overrides. Later settings win.
+	options := c.Options
+	if options == "" {
+		options = os.Getenv("PGOPTIONS")
+	}
+	options += " -c search_path="
+	if c.StatementTimeout > 0 {
+		options += fmt.Sprintf(" -c statement_timeout=%d", c.StatementTimeout.Milliseconds())
+	}
+	return append(env, "PGOPTIONS="+strings.TrimSpace(options))
+}
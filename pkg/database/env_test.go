@@ -0,0 +1,116 @@
+package database
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets the libpq variables ApplyEnv and Environ read for the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"PGHOST", "PGPORT", "PGDATABASE", "PGUSER", "PGPASSWORD", "PGSSLMODE", "PGSSLCERT",
+		"PGSSLKEY", "PGSSLROOTCERT", "PGCONNECT_TIMEOUT", "PGAPPNAME", "PGOPTIONS", "PGSAC_PSQL",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		config Config
+		want   Config
+		err    bool
+	}{
+		{
+			name: "fills empty fields",
+			env: map[string]string{
+				"PGHOST": "db", "PGPORT": "6432", "PGDATABASE": "app", "PGUSER": "alice",
+				"PGPASSWORD": "secret", "PGSSLMODE": "verify-full", "PGCONNECT_TIMEOUT": "5",
+				"PGAPPNAME": "pgsac", "PGOPTIONS": "-c search_path=app",
+			},
+			want: Config{
+				Host: "db", Port: 6432, DBName: "app", User: "alice", Password: "secret",
+				SSLMode: "verify-full", ConnectTimeout: "5", ApplicationName: "pgsac", Options: "-c search_path=app",
+			},
+		},
+		{
+			name:   "set fields win",
+			env:    map[string]string{"PGHOST": "db", "PGPORT": "6432", "PGSSLMODE": "require"},
+			config: Config{Host: "localhost", Port: 5432, SSLMode: "disable"},
+			want:   Config{Host: "localhost", Port: 5432, SSLMode: "disable"},
+		},
+		{name: "invalid port", env: map[string]string{"PGPORT": "abc"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			config := tt.config
+			err := config.ApplyEnv()
+			if tt.err {
+				if err == nil {
+					t.Fatal("ApplyEnv succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config != tt.want {
+				t.Errorf("ApplyEnv() = %+v, want %+v", config, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	tests := []struct {
+		name      string
+		pgoptions string
+		config    Config
+		want      []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"PGPASSWORD=", "PGSSLMODE=disable", "PGOPTIONS=-c search_path="},
+		},
+		{
+			name:   "passed through parameters",
+			config: Config{Password: "secret", SSLMode: "require", ConnectTimeout: "5", ApplicationName: "pgsac", Options: "-c search_path=app"},
+			want:   []string{"PGPASSWORD=secret", "PGSSLMODE=require", "PGCONNECT_TIMEOUT=5", "PGAPPNAME=pgsac", "PGOPTIONS=-c search_path=app -c search_path="},
+		},
+		{
+			name:   "client certificate",
+			config: Config{SSLMode: "verify-full", SSLCert: "/certs/client.crt", SSLKey: "/certs/client.key", SSLRootCert: "/certs/root.crt"},
+			want: []string{"PGPASSWORD=", "PGSSLMODE=verify-full", "PGSSLCERT=/certs/client.crt",
+				"PGSSLKEY=/certs/client.key", "PGSSLROOTCERT=/certs/root.crt", "PGOPTIONS=-c search_path="},
+		},
+		{
+			name:      "statement timeout added to the client options",
+			pgoptions: "-c work_mem=64MB",
+			config:    Config{StatementTimeout: 2 * time.Second},
+			want:      []string{"PGPASSWORD=", "PGSSLMODE=disable", "PGOPTIONS=-c work_mem=64MB -c search_path= -c statement_timeout=2000"},
+		},
+		{
+			name:      "statement timeout added to the configured options",
+			pgoptions: "-c work_mem=64MB",
+			config:    Config{Options: "-c search_path=app", StatementTimeout: time.Second},
+			want:      []string{"PGPASSWORD=", "PGSSLMODE=disable", "PGOPTIONS=-c search_path=app -c search_path= -c statement_timeout=1000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("PGOPTIONS", tt.pgoptions)
+			if got := tt.config.Environ(); !slices.Equal(got, tt.want) {
+				t.Errorf("Environ() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
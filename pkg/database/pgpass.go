@@ -0,0 +1,94 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// LookupPgpass returns the password of the first entry of the password file matching
+// the configuration, as libpq does. The file is PGPASSFILE, or ~/.pgpass by default.
+// Lines are hostname:port:database:username:password, where any of the first four
+// fields may be * and \ escapes : and \. Like libpq, a file with group or world access
+// is ignored with a warning.
+func LookupPgpass(cfg Config) (string, bool) {
+	path := os.Getenv("PGPASSFILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		path = filepath.Join(home, ".pgpass")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "warning: password file %s has group or world access; permissions should be u=rw (0600) or less\n", path)
+		return "", false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	host := cfg.Host
+	if host == "" || strings.HasPrefix(host, "/") {
+		host = "localhost"
+	}
+	want := []string{host, strconv.Itoa(cfg.Port), cfg.DBName, cfg.User}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := splitPgpassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+
+		matches := true
+		for i, w := range want {
+			if fields[i] != "*" && fields[i] != w {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return fields[4], true
+		}
+	}
+	return "", false
+}
+
+// splitPgpassLine splits a password file line on unescaped colons, unescaping \: and \\
+func splitPgpassLine(line string) []string {
+	var (
+		fields  []string
+		current strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case c == ':' && len(fields) < 4:
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return append(fields, current.String())
+}
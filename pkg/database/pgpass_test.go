@@ -0,0 +1,91 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestSplitPgpassLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "localhost:5432:app:alice:secret", want: []string{"localhost", "5432", "app", "alice", "secret"}},
+		{line: "*:*:*:*:pass:with:colons", want: []string{"*", "*", "*", "*", "pass:with:colons"}},
+		{line: `db\:1:5432:app:alice:back\\slash`, want: []string{"db:1", "5432", "app", "alice", `back\slash`}},
+		{line: `h:p:d:u:trailing\`, want: []string{"h", "p", "d", "u", `trailing\`}},
+		{line: "too:few:fields", want: []string{"too", "few", "fields"}},
+	}
+	for _, tt := range tests {
+		if got := splitPgpassLine(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPgpassLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLookupPgpass(t *testing.T) {
+	content := `# comment
+db.example.com:5432:app:alice:exact
+*:6432:*:alice:any-host
+localhost:5432:*:bob:socket
+*:*:*:*:fallback
+`
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{name: "exact match", config: Config{Host: "db.example.com", Port: 5432, DBName: "app", User: "alice"}, want: "exact"},
+		{name: "wildcard host", config: Config{Host: "other", Port: 6432, DBName: "app", User: "alice"}, want: "any-host"},
+		{name: "socket directory is localhost", config: Config{Host: "/var/run/postgresql", Port: 5432, DBName: "app", User: "bob"}, want: "socket"},
+		{name: "first match wins", config: Config{Host: "other", Port: 5432, DBName: "app", User: "carol"}, want: "fallback"},
+	}
+
+	path := filepath.Join(t.TempDir(), "pgpass")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PGPASSFILE", path)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LookupPgpass(tt.config)
+			if !ok || got != tt.want {
+				t.Errorf("LookupPgpass() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		noFallback := filepath.Join(t.TempDir(), "pgpass")
+		if err := os.WriteFile(noFallback, []byte("db:5432:app:alice:secret\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PGPASSFILE", noFallback)
+		if got, ok := LookupPgpass(Config{Host: "db", Port: 5432, DBName: "app", User: "bob"}); ok {
+			t.Errorf("LookupPgpass() = %q, want no match", got)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("PGPASSFILE", filepath.Join(t.TempDir(), "missing"))
+		if _, ok := LookupPgpass(Config{Host: "db", Port: 5432, DBName: "app", User: "alice"}); ok {
+			t.Error("LookupPgpass() found a password in a missing file")
+		}
+	})
+
+	t.Run("group readable file is ignored", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("file permissions are not checked on Windows")
+		}
+		if err := os.Chmod(path, 0o640); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PGPASSFILE", path)
+		if _, ok := LookupPgpass(Config{Host: "db.example.com", Port: 5432, DBName: "app", User: "alice"}); ok {
+			t.Error("LookupPgpass() used a group readable file")
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHConfig holds the configuration of an SSH server used to reach the database
+type SSHConfig struct {
+	Host           string // host or host:port, port 22 by default
+	User           string
+	KeyFile        string // Private key used to authenticate
+	KnownHostsFile string // Defaults to ~/.ssh/known_hosts
+}
+
+// Tunnel forwards connections from a local port to a remote address through an SSH server
+type Tunnel struct {
+	client   *ssh.Client
+	listener net.Listener
+	remote   string
+	wg       sync.WaitGroup
+}
+
+// OpenTunnel connects to the SSH server and forwards a random local port to remoteAddr,
+// as seen from the SSH server
+func OpenTunnel(config SSHConfig, remoteAddr string) (*Tunnel, error) {
+	clientConfig, err := config.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := config.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	client, err := ssh.Dial("tcp", addr, clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to SSH server %s: %w", addr, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error opening local tunnel port: %w", err)
+	}
+
+	t := &Tunnel{client: client, listener: listener, remote: remoteAddr}
+	t.wg.Add(1)
+	go t.serve()
+	return t, nil
+}
+
+// clientConfig builds the SSH client configuration, authenticating with the key file
+// and verifying the server against the known hosts file
+func (c SSHConfig) clientConfig() (*ssh.ClientConfig, error) {
+	key, err := os.ReadFile(c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SSH key %s: %w", c.KeyFile, err)
+	}
+
+	knownHostsFile := c.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("error locating known hosts file: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading known hosts file: %w", err)
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+// Port returns the local port forwarded through the tunnel
+func (t *Tunnel) Port() int {
+	return t.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops forwarding and disconnects from the SSH server
+func (t *Tunnel) Close() error {
+	err := t.listener.Close()
+	t.wg.Wait()
+	if cerr := t.client.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// serve accepts local connections until the listener is closed
+func (t *Tunnel) serve() {
+	defer t.wg.Done()
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(local)
+	}
+}
+
+// forward copies data between a local connection and the remote address
+func (t *Tunnel) forward(local net.Conn) {
+	defer local.Close()
+
+	remote, err := t.client.Dial("tcp", t.remote)
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// TunneledDB is a database connection made through an SSH tunnel
+type TunneledDB struct {
+	*sql.DB
+	// Config reaches the database through the tunnel's local port, e.g. for the psql client
+	Config Config
+
+	tunnel *Tunnel
+}
+
+// ConnectViaTunnel opens an SSH tunnel to the database host and connects through it.
+// Host and port in config are resolved by the SSH server.
+func ConnectViaTunnel(config Config, sshConfig SSHConfig) (*TunneledDB, error) {
+	tunnel, err := OpenTunnel(sshConfig, net.JoinHostPort(config.Host, strconv.Itoa(config.Port)))
+	if err != nil {
+		return nil, err
+	}
+
+	local := config
+	local.Host = "127.0.0.1"
+	local.Port = tunnel.Port()
+
+	db, err := Connect(local)
+	if err != nil {
+		tunnel.Close()
+		return nil, err
+	}
+
+	return &TunneledDB{DB: db, Config: local, tunnel: tunnel}, nil
+}
+
+// Close closes the database connection and then the tunnel
+func (t *TunneledDB) Close() error {
+	err := t.DB.Close()
+	if terr := t.tunnel.Close(); err == nil {
+		err = terr
+	}
+	return err
+}
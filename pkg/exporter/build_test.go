@@ -0,0 +1,32 @@
+package exporter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/exporter"
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// TestExportThroughModulePath uses the exporter as a library user does, through the module
+// import path, so a broken import in the package fails go test ./...
+func TestExportThroughModulePath(t *testing.T) {
+	dir := t.TempDir()
+	e := exporter.NewExporter(dir, exporter.Options{})
+	err := e.Export([]schema.Schema{{Name: "public", Objects: []schema.Object{
+		{Schema: "public", Name: "users", Type: schema.TableType, Definition: "CREATE TABLE public.users (id integer)"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "public", "table", "users.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "CREATE TABLE public.users (id integer)") {
+		t.Errorf("users.sql =\n%s\nwant the table definition", content)
+	}
+}
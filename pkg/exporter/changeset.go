@@ -0,0 +1,158 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// Changeset describes how an export differs from the files already on disk.
+// Paths are relative to the exporter's base directory.
+type Changeset struct {
+	Added    []string             `json:"added"`    // Files that would be created
+	Modified []string             `json:"modified"` // Files whose content would change
+	Removed  []string             `json:"removed"`  // pgsac-managed files with no matching object
+	Stats    map[string]LineStats `json:"stats"`    // Line counts of the change to each file
+}
+
+// LineStats summarizes the lines changed in a file
+type LineStats struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// Empty reports whether the export would leave the files unchanged
+func (c *Changeset) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// Summary returns a one-line description of the changeset
+func (c *Changeset) Summary() string {
+	return fmt.Sprintf("%d added, %d modified, %d removed", len(c.Added), len(c.Modified), len(c.Removed))
+}
+
+// Changes compares the files an export would write against the files on disk
+func (e *Exporter) Changes(schemas []schema.Schema) (*Changeset, error) {
+	files, err := e.compareFiles(schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &Changeset{Stats: make(map[string]LineStats)}
+	for _, f := range files {
+		switch {
+		case !f.onDisk:
+			cs.Added = append(cs.Added, f.path)
+		case !f.expected:
+			cs.Removed = append(cs.Removed, f.path)
+		case f.current != f.content:
+			cs.Modified = append(cs.Modified, f.path)
+		default:
+			continue
+		}
+		cs.Stats[f.path] = lineStats(diffLines(f.current, f.content))
+	}
+
+	sort.Strings(cs.Added)
+	sort.Strings(cs.Modified)
+	sort.Strings(cs.Removed)
+	return cs, nil
+}
+
+// fileComparison pairs the content an export would write with the file on disk
+type fileComparison struct {
+	path       string // Relative to the base directory
+	object     string // Qualified name of the object mapping to the file, if any
+	objectType string // Type of that object
+	content    string // Content the export would write
+	current    string // Content on disk
+	expected   bool   // Whether an object maps to the file
+	onDisk     bool   // Whether the file exists
+}
+
+// compareFiles reads the file of every object, plus the managed files left in the
+// schema directories without a matching object
+func (e *Exporter) compareFiles(schemas []schema.Schema) ([]fileComparison, error) {
+	var files []fileComparison
+	expected := make(map[string]bool)
+
+	for _, s := range e.fileObjects(schemas) {
+		paths, _ := e.objectPaths(s)
+		for i, obj := range s.Objects {
+			rel := paths[i]
+			expected[rel] = true
+
+			obj, _ := e.evolveEnum(rel, obj)
+			f := fileComparison{path: rel, object: obj.QualifiedName(), objectType: string(obj.Type), content: e.render(obj), expected: true}
+			current, err := os.ReadFile(filepath.Join(e.baseDir, rel))
+			switch {
+			case err == nil:
+				f.current = e.decodeFile(rel, current)
+				f.onDisk = true
+			case !os.IsNotExist(err):
+				return nil, fmt.Errorf("error reading %s: %w", rel, err)
+			}
+			files = append(files, f)
+		}
+
+		// Any managed file left in the schema directory no longer has an object
+		schemaDir := filepath.Join(e.baseDir, s.Name)
+		err := filepath.WalkDir(schemaDir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() || filepath.Ext(path) != ".sql" {
+				return nil
+			}
+			rel, err := filepath.Rel(e.baseDir, path)
+			if err != nil {
+				return err
+			}
+			if !expected[rel] && isManaged(path) {
+				current, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				files = append(files, fileComparison{path: rel, current: e.decodeFile(rel, current), onDisk: true})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error scanning schema directory %s: %w", schemaDir, err)
+		}
+	}
+
+	return files, nil
+}
+
+// isManaged reports whether a file starts with a comment block holding the "-- Object:"
+// and "-- Type:" header lines written by pgsac, possibly among lines of a header template
+func isManaged(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	previous := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(previous, "-- Object: ") && strings.HasPrefix(line, "-- Type: ") {
+			return true
+		}
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+		previous = line
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOpKind is the kind of a line-level edit
+type diffOpKind int
+
+const (
+	opEqual diffOpKind = iota
+	opInsert
+	opDelete
+)
+
+// diffOp is a single line of a line-level diff
+type diffOp struct {
+	kind diffOpKind
+	line string
+}
+
+// diffLines computes a line-level diff turning a into b with Myers' algorithm, in its
+// linear-space variant, so large files diff in memory proportional to their size
+func diffLines(a, b string) []diffOp {
+	return appendDiff(nil, splitLines(a), splitLines(b))
+}
+
+// appendDiff appends the ops turning x into y, recursing on each side of a middle snake
+func appendDiff(ops []diffOp, x, y []string) []diffOp {
+	// Common prefix and suffix
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	for _, line := range x[:prefix] {
+		ops = append(ops, diffOp{opEqual, line})
+	}
+	mx, my := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+
+	switch {
+	case len(mx) == 0:
+		for _, line := range my {
+			ops = append(ops, diffOp{opInsert, line})
+		}
+	case len(my) == 0:
+		for _, line := range mx {
+			ops = append(ops, diffOp{opDelete, line})
+		}
+	default:
+		xs, ys, xe, ye := middleSnake(mx, my)
+		if xs+ys == 0 && xe+ye == 0 || xs == len(mx) && ys == len(my) {
+			// Not a split; never expected, but recursing would not end
+			for _, line := range mx {
+				ops = append(ops, diffOp{opDelete, line})
+			}
+			for _, line := range my {
+				ops = append(ops, diffOp{opInsert, line})
+			}
+			break
+		}
+		ops = appendDiff(ops, mx[:xs], my[:ys])
+		for _, line := range mx[xs:xe] {
+			ops = append(ops, diffOp{opEqual, line})
+		}
+		ops = appendDiff(ops, mx[xe:], my[ye:])
+	}
+
+	for _, line := range x[len(x)-suffix:] {
+		ops = append(ops, diffOp{opEqual, line})
+	}
+	return ops
+}
+
+// middleSnake returns the start and end of the snake in the middle of a shortest edit
+// script turning x into y, searching forward from the start and backward from the end
+// at once. x and y are not empty.
+func middleSnake(x, y []string) (xs, ys, xe, ye int) {
+	n, m := len(x), len(y)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	// Furthest x reached on each diagonal k = x - y, forward from (0, 0) and backward from
+	// (n, m), the backward one counted from the end
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || k != d && forward[offset+k-1] < forward[offset+k+1] {
+				px = forward[offset+k+1]
+			} else {
+				px = forward[offset+k-1] + 1
+			}
+			py := px - k
+			sx, sy := px, py
+			for px < n && py < m && x[px] == y[py] {
+				px++
+				py++
+			}
+			forward[offset+k] = px
+			// Forward diagonal k is backward diagonal delta-k
+			if kb := delta - k; odd && kb >= -(d-1) && kb <= d-1 && px+backward[offset+kb] >= n {
+				return sx, sy, px, py
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || k != d && backward[offset+k-1] < backward[offset+k+1] {
+				px = backward[offset+k+1]
+			} else {
+				px = backward[offset+k-1] + 1
+			}
+			py := px - k
+			sx, sy := px, py
+			for px < n && py < m && x[n-1-px] == y[m-1-py] {
+				px++
+				py++
+			}
+			backward[offset+k] = px
+			if kf := delta - k; !odd && kf >= -d && kf <= d && px+forward[offset+kf] >= n {
+				return n - px, m - py, n - sx, m - sy
+			}
+		}
+	}
+	// A path of at most n+m edits always exists, so the searches meet before this; an
+	// empty snake at the start makes appendDiff replace x with y
+	return 0, 0, 0, 0
+}
+
+// lineStats counts the lines added and removed by a diff
+func lineStats(ops []diffOp) LineStats {
+	var stats LineStats
+	for _, op := range ops {
+		switch op.kind {
+		case opInsert:
+			stats.Added++
+		case opDelete:
+			stats.Removed++
+		}
+	}
+	return stats
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// unifiedDiff renders a diff in unified format with the given number of context lines.
+// Hunks whose context would overlap are merged.
+func unifiedDiff(fromName, toName string, ops []diffOp, context int) string {
+	// aPos[k] and bPos[k] count the lines of each side before ops[k]
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aPos[k+1], bPos[k+1] = aPos[k], bPos[k]
+		if op.kind != opInsert {
+			aPos[k+1]++
+		}
+		if op.kind != opDelete {
+			bPos[k+1]++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == opEqual {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Extend the hunk over changes separated by at most 2*context equal lines
+		start := max(0, i-context)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(run, end+context)
+				break
+			}
+			end = run
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(aPos[start], aPos[end]-aPos[start]),
+			hunkRange(bPos[start], bPos[end]-bPos[start]))
+		for _, op := range ops[start:end] {
+			switch op.kind {
+			case opEqual:
+				b.WriteString(" " + op.line + "\n")
+			case opInsert:
+				b.WriteString("+" + op.line + "\n")
+			case opDelete:
+				b.WriteString("-" + op.line + "\n")
+			}
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats the "start,count" of a hunk side; an empty side names the line before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
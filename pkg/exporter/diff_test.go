@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// applyDiff returns the two sides of a diff
+func applyDiff(ops []diffOp) (from, to []string) {
+	for _, op := range ops {
+		if op.kind != opInsert {
+			from = append(from, op.line)
+		}
+		if op.kind != opDelete {
+			to = append(to, op.line)
+		}
+	}
+	return from, to
+}
+
+// lcsLength is the length of the longest common subsequence of x and y
+func lcsLength(x, y []string) int {
+	prev := make([]int, len(y)+1)
+	for i := range x {
+		cur := make([]int, len(y)+1)
+		for j := range y {
+			if x[i] == y[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(y)]
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() string {
+		var lines []string
+		for i := rng.Intn(30); i > 0; i-- {
+			lines = append(lines, string(rune('a'+rng.Intn(4))))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomLines(), randomLines()
+		ops := diffLines(a, b)
+		from, to := applyDiff(ops)
+		if strings.Join(from, "\n") != a || strings.Join(to, "\n") != b {
+			t.Fatalf("diffLines(%q, %q) does not turn one into the other: %v", a, b, ops)
+		}
+		x, y := splitLines(a), splitLines(b)
+		stats := lineStats(ops)
+		if want := len(x) + len(y) - 2*lcsLength(x, y); stats.Added+stats.Removed != want {
+			t.Fatalf("diffLines(%q, %q) has %d edits, want %d", a, b, stats.Added+stats.Removed, want)
+		}
+	}
+}
+
+func TestDiffLinesLargeFiles(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&a, "line %d\n", i)
+		if i%10000 == 5000 {
+			fmt.Fprintf(&b, "changed %d\n", i)
+			continue
+		}
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+
+	stats := lineStats(diffLines(a.String(), b.String()))
+	if stats.Added != 5 || stats.Removed != 5 {
+		t.Errorf("diffLines() stats = %+v, want 5 added and 5 removed", stats)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	want := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if got := unifiedDiff("old", "new", diffLines(a, b), 3); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// postgresEncodings maps PostgreSQL encoding names lacking an IANA alias to their IANA name
+var postgresEncodings = map[string]string{
+	"UTF8":    "UTF-8",
+	"WIN1250": "windows-1250",
+	"WIN1251": "windows-1251",
+	"WIN1252": "windows-1252",
+	"SJIS":    "Shift_JIS",
+	"EUC_JP":  "EUC-JP",
+	"EUC_KR":  "EUC-KR",
+}
+
+// ParseEncoding returns the character encoding with the given IANA name or alias, e.g.
+// "ISO-8859-1", "latin1" or "windows-1252", or PostgreSQL name, e.g. "WIN1252". UTF-8,
+// the default, is returned as nil.
+func ParseEncoding(name string) (encoding.Encoding, error) {
+	if iana, ok := postgresEncodings[strings.ToUpper(name)]; ok {
+		name = iana
+	}
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unknown or unsupported encoding %q", name)
+	}
+	if enc == unicode.UTF8 {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// encodingName returns the name of the encoding of SQL files, empty for UTF-8: its MIME
+// name, e.g. "ISO-8859-1" rather than "ISO_8859-1:1987", or else its IANA name
+func (e *Exporter) encodingName() string {
+	if e.encoding == nil {
+		return ""
+	}
+	if name, err := ianaindex.MIME.Name(e.encoding); err == nil {
+		return name
+	}
+	name, err := ianaindex.IANA.Name(e.encoding)
+	if err != nil {
+		return fmt.Sprint(e.encoding)
+	}
+	return name
+}
+
+// encodeFile returns the bytes of a file. SQL files are encoded with Options.Encoding;
+// other files, such as manifest.json, stay UTF-8.
+func (e *Exporter) encodeFile(rel, content string) ([]byte, error) {
+	if filepath.Ext(rel) != ".sql" {
+		return []byte(content), nil
+	}
+	return e.encode(rel, content)
+}
+
+// encode encodes SQL content with Options.Encoding, failing on characters the encoding
+// cannot represent rather than writing replacement characters
+func (e *Exporter) encode(name, content string) ([]byte, error) {
+	if e.encoding == nil {
+		return []byte(content), nil
+	}
+	encoded, err := e.encoding.NewEncoder().String(content)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s as %s: %w", name, e.encodingName(), err)
+	}
+	return []byte(encoded), nil
+}
+
+// decodeFile returns the content of a file read from disk, decoding SQL files written
+// with Options.Encoding
+func (e *Exporter) decodeFile(rel string, data []byte) string {
+	return decodeWith(e.encoding, rel, data)
+}
+
+// decodeWith decodes a SQL file written with enc, nil for UTF-8. Undecodable content is
+// returned as is.
+func decodeWith(enc encoding.Encoding, rel string, data []byte) string {
+	if enc == nil || filepath.Ext(rel) != ".sql" {
+		return string(data)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/schema"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		want string // IANA name, empty for UTF-8
+		err  bool
+	}{
+		{name: "UTF-8"},
+		{name: "utf8"},
+		{name: "UTF8"},
+		{name: "latin1", want: "ISO-8859-1"},
+		{name: "ISO-8859-15", want: "ISO-8859-15"},
+		{name: "WIN1252", want: "windows-1252"},
+		{name: "windows-1252", want: "windows-1252"},
+		{name: "klingon", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := ParseEncoding(tt.name)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("ParseEncoding(%q) succeeded, want an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := (&Exporter{encoding: enc}).encodingName(); got != tt.want {
+				t.Errorf("ParseEncoding(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportEncodingRoundTrip(t *testing.T) {
+	definition := "CREATE TABLE app.café (id integer);\n\nCOMMENT ON TABLE app.café IS 'Données clés, été'"
+	schemas := []schema.Schema{{Name: "app", Objects: []schema.Object{
+		{Schema: "app", Name: "café", Type: schema.TableType, Definition: definition},
+	}}}
+
+	tests := []struct {
+		name     string
+		encoding string
+		bytes    []byte
+	}{
+		{name: "UTF-8", encoding: "UTF-8", bytes: []byte(definition)},
+		{name: "latin1", encoding: "latin1", bytes: mustEncode(t, charmap.ISO8859_1, definition)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := ParseEncoding(tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			e := NewExporter(dir, Options{Encoding: enc})
+			if err := e.Export(schemas); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "app", "table", "café.sql"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(data, tt.bytes) {
+				t.Errorf("café.sql =\n%q\nwant it to hold\n%q", data, tt.bytes)
+			}
+
+			// Reading the files back finds the definition unchanged
+			diffs, err := e.Diff(schemas)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(diffs) != 0 {
+				t.Errorf("Diff() = %+v, want no difference", diffs)
+			}
+		})
+	}
+}
+
+func TestExportEncodingUnrepresentable(t *testing.T) {
+	enc, err := ParseEncoding("latin1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewExporter(t.TempDir(), Options{Encoding: enc})
+	err = e.Export([]schema.Schema{{Name: "app", Objects: []schema.Object{
+		{Schema: "app", Name: "prices", Type: schema.ViewType, Definition: "SELECT '€' AS currency"},
+	}}})
+	if err == nil || !strings.Contains(err.Error(), "ISO-8859-1") {
+		t.Errorf("Export() error = %v, want an encoding error", err)
+	}
+}
+
+// mustEncode encodes s with enc
+func mustEncode(t *testing.T, enc *charmap.Charmap, s string) []byte {
+	t.Helper()
+	encoded, err := enc.NewEncoder().String(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(encoded)
+}
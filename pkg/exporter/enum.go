@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// EnumStyle decides how the files of enum types are written
+type EnumStyle string
+
+const (
+	// EnumCreate writes an enum type as a single CREATE TYPE listing all its labels
+	EnumCreate EnumStyle = "create"
+	// EnumAlter keeps the statements of an enum type's existing file and appends an
+	// ALTER TYPE ... ADD VALUE for each label added since, so only new labels show up in
+	// diffs. Labels removed or reordered rewrite the file with CREATE TYPE.
+	EnumAlter EnumStyle = "alter"
+)
+
+// ParseEnumStyle returns the enum style with the given name
+func ParseEnumStyle(name string) (EnumStyle, error) {
+	switch s := EnumStyle(name); s {
+	case EnumCreate, EnumAlter:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown enum style %q (expected create or alter)", name)
+}
+
+// evolveEnum applies EnumAlter to a type written to rel: an enum type whose file exists is
+// given the definition of the file followed by the labels added since, see
+// schema.EvolveEnum. Other objects are returned as is.
+func (e *Exporter) evolveEnum(rel string, obj schema.Object) (schema.Object, error) {
+	if e.enumStyle != EnumAlter || obj.Type != schema.TypeType {
+		return obj, nil
+	}
+	data, err := os.ReadFile(filepath.Join(e.baseDir, rel))
+	if err != nil {
+		// No earlier export to build on
+		return obj, nil
+	}
+	definition, err := schema.EvolveEnum(e.decodeFile(rel, data), obj)
+	obj.Definition = definition
+	return obj, err
+}
@@ -1,87 +1,680 @@
 package exporter
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/ofux/pgsac/pkg/schema"
+	"golang.org/x/text/encoding"
 )
 
 // Exporter handles the export of schema objects to files
 type Exporter struct {
-	baseDir string
+	baseDir           string
+	naming            NamingStrategy
+	maxDefinitionSize int
+	strict            bool
+	force             bool
+	combined          bool
+	bundle            bool
+	drops             bool
+	layout            Layout
+	encoding          encoding.Encoding // Of SQL files, nil for UTF-8
+	splitTables       bool
+	enumStyle         EnumStyle
+	changedOnly       bool
+	dropCascade       bool
+	dryRun            bool
+	out               io.Writer
+	logger            *slog.Logger
+	warnings          []string
+	unchanged         int // Files left untouched by ChangedOnly
+
+	prune          bool
+	grants         GrantsMode
+	noOwner        bool
+	version        string
+	stats          []schema.StepStats
+	header         *template.Template
+	started        time.Time // Time of the export, for header templates
+	headerFailures map[string]bool
+	pretty         bool
+	prettyFailures map[string]bool
+	extensions     []string // CREATE EXTENSION statements starting install scripts
+	foreignServers []string // Foreign data wrapper, server and user mapping statements
+	eventTriggers  []schema.Object
+	publications   []string
+	subscriptions  []string
+
+	// Dry-run plan
+	planned    []plannedFile
+	removals   []string
+	collisions []string
+}
+
+// plannedFile is a file a dry run would have written
+type plannedFile struct {
+	kind string // Object type, or the kind of script
+	path string // Relative to the base directory
 }
 
 // NewExporter creates a new exporter
-func NewExporter(baseDir string) *Exporter {
-	return &Exporter{baseDir: baseDir}
+func NewExporter(baseDir string, opts Options) *Exporter {
+	naming := opts.Naming
+	if naming == nil {
+		naming = PreserveCase{}
+	}
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	grants := opts.Grants
+	if grants == "" {
+		grants = GrantsInline
+	}
+	layout := opts.Layout
+	if layout == "" {
+		layout = ByType
+	}
+	enumStyle := opts.EnumStyle
+	if enumStyle == "" {
+		enumStyle = EnumCreate
+	}
+	header := opts.HeaderTemplate
+	if header == nil {
+		header = defaultHeader
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	return &Exporter{
+		baseDir:           baseDir,
+		naming:            naming,
+		maxDefinitionSize: opts.MaxDefinitionSize,
+		strict:            opts.Strict,
+		force:             opts.Force,
+		combined:          opts.Combined,
+		bundle:            opts.Bundle,
+		drops:             opts.Drops,
+		layout:            layout,
+		encoding:          opts.Encoding,
+		splitTables:       opts.SplitTables,
+		enumStyle:         enumStyle,
+		changedOnly:       opts.ChangedOnly,
+		dropCascade:       opts.DropCascade,
+		dryRun:            opts.DryRun,
+		prune:             opts.Prune,
+		grants:            grants,
+		noOwner:           opts.NoOwner,
+		version:           opts.Version,
+		stats:             opts.Stats,
+		header:            header,
+		started:           time.Now().UTC().Truncate(time.Second),
+		headerFailures:    make(map[string]bool),
+		pretty:            opts.Pretty,
+		prettyFailures:    make(map[string]bool),
+		extensions:        opts.Extensions,
+		foreignServers:    opts.ForeignServers,
+		eventTriggers:     opts.EventTriggers,
+		publications:      opts.Publications,
+		subscriptions:     opts.Subscriptions,
+		out:               out,
+		logger:            logger,
+	}
+}
+
+// Warnings returns the warnings recorded during export
+func (e *Exporter) Warnings() []string {
+	return e.warnings
+}
+
+// Unchanged returns the number of files left untouched because of Options.ChangedOnly
+func (e *Exporter) Unchanged() int {
+	return e.unchanged
 }
 
 // Export writes all schema objects to files
 func (e *Exporter) Export(schemas []schema.Schema) error {
-	for _, s := range schemas {
-		if err := e.exportSchema(s); err != nil {
-			return fmt.Errorf("error exporting schema %s: %w", s.Name, err)
+	// Order objects first so a dependency cycle fails before anything is written
+	var ordered []schema.Object
+	if e.combined {
+		var all []schema.Object
+		for _, s := range schemas {
+			all = append(all, s.Objects...)
 		}
+		var err error
+		if ordered, err = schema.SortByDependencies(all); err != nil {
+			return fmt.Errorf("error ordering objects: %w", err)
+		}
+	}
+
+	// Object files may group objects; scripts keep the objects as extracted
+	files := e.fileObjects(schemas)
+	if err := e.checkCollisions(files); err != nil {
+		return err
+	}
+
+	if err := e.exportDatabase(); err != nil {
+		return err
+	}
+	for i, s := range schemas {
+		if err := e.exportSchemaFiles(s, files[i]); err != nil {
+			return err
+		}
+	}
+
+	if e.combined {
+		if err := e.exportCombined(schemas, ordered); err != nil {
+			return err
+		}
+	}
+
+	if e.prune {
+		if err := e.pruneStale(files); err != nil {
+			return err
+		}
+	}
+
+	if err := e.WriteManifest(files); err != nil {
+		return err
+	}
+
+	if e.dryRun {
+		e.printPlan()
 	}
 	return nil
 }
 
-func (e *Exporter) exportSchema(s schema.Schema) error {
-	// Create schema directory if it doesn't exist
-	schemaDir := filepath.Join(e.baseDir, s.Name)
-	if err := os.MkdirAll(schemaDir, 0755); err != nil {
-		return fmt.Errorf("error creating schema directory: %w", err)
+// exportDatabase writes the files of the database-wide sections and event triggers at the
+// root of the output directory
+func (e *Exporter) exportDatabase() error {
+	for _, section := range e.databaseSections() {
+		if err := e.exportDatabaseSection(section); err != nil {
+			return err
+		}
+	}
+	if len(e.eventTriggers) > 0 {
+		content := "-- Event triggers\n" + e.renderEventTriggers()
+		if err := e.writeFile("event_triggers", "event_triggers.sql", content); err != nil {
+			return fmt.Errorf("error writing event_triggers.sql: %w", err)
+		}
 	}
+	return nil
+}
 
-	// Group objects by type
-	objectsByType := make(map[schema.ObjectType][]schema.Object)
-	for _, obj := range s.Objects {
-		objectsByType[obj.Type] = append(objectsByType[obj.Type], obj)
+// exportSchemaFiles writes the directory of a schema, given with its objects grouped into
+// files, along with its install and drop scripts when enabled
+func (e *Exporter) exportSchemaFiles(s, files schema.Schema) error {
+	// A schema without objects gets no directory; combined and single-file outputs
+	// still create it
+	if len(s.Objects) == 0 && len(s.DefaultPrivileges) == 0 {
+		return nil
+	}
+	if err := e.exportSchema(files); err != nil {
+		return fmt.Errorf("error exporting schema %s: %w", s.Name, err)
+	}
+	if e.bundle {
+		if err := e.ExportInstallScript(s); err != nil {
+			return err
+		}
+	}
+	if e.drops {
+		if err := e.ExportDropScript(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlan prints the files a dry run would have written and the name collisions met,
+// then starts a new plan
+func (e *Exporter) printPlan() {
+	for _, f := range e.planned {
+		fmt.Fprintf(e.out, "%-20s %s\n", f.kind, f.path)
+	}
+	for _, path := range e.removals {
+		fmt.Fprintf(e.out, "%-20s %s\n", "remove", path)
+	}
+	fmt.Fprintf(e.out, "%d file(s) would be written to %s\n", len(e.planned), e.baseDir)
+	if e.unchanged > 0 {
+		fmt.Fprintf(e.out, "%d unchanged file(s) would be skipped\n", e.unchanged)
+	}
+	if len(e.removals) > 0 {
+		fmt.Fprintf(e.out, "%d stale file(s) would be removed\n", len(e.removals))
+	}
+	for _, c := range e.collisions {
+		fmt.Fprintf(e.out, "collision: %s\n", c)
+	}
+	e.planned, e.removals, e.collisions, e.unchanged = nil, nil, nil, 0
+}
+
+// pruneStale removes the pgsac-managed files of the exported schemas that no longer match
+// an object, then the type and table directories left empty. Files without the pgsac header are kept.
+func (e *Exporter) pruneStale(schemas []schema.Schema) error {
+	files, err := e.compareFiles(schemas)
+	if err != nil {
+		return err
 	}
 
-	// Export each type of object
-	for objType, objects := range objectsByType {
-		typeDir := filepath.Join(schemaDir, string(objType))
-		if err := os.MkdirAll(typeDir, 0755); err != nil {
-			return fmt.Errorf("error creating type directory: %w", err)
+	for _, f := range files {
+		if f.expected {
+			continue
+		}
+		if e.dryRun {
+			e.removals = append(e.removals, f.path)
+			continue
 		}
 
-		for _, obj := range objects {
-			if err := e.exportObject(typeDir, obj); err != nil {
-				return fmt.Errorf("error exporting object %s: %w", obj.Name, err)
+		e.logger.Debug("removing stale file", "path", f.path)
+		path := filepath.Join(e.baseDir, f.path)
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("error removing stale file %s: %w", f.path, err)
+		}
+		// Remove the directories left empty, up to the schema directory
+		for dir := filepath.Dir(f.path); strings.Contains(dir, string(filepath.Separator)); dir = filepath.Dir(dir) {
+			if entries, err := os.ReadDir(filepath.Join(e.baseDir, dir)); err != nil || len(entries) > 0 {
+				break
+			}
+			if err := os.Remove(filepath.Join(e.baseDir, dir)); err != nil {
+				return fmt.Errorf("error removing empty directory: %w", err)
 			}
 		}
 	}
+	return nil
+}
 
+// writeFile atomically writes content to a path relative to the base directory, creating
+// missing directories. A dry run only adds the file to the plan. With ChangedOnly, files
+// whose content hash already matches are left untouched and counted as unchanged.
+func (e *Exporter) writeFile(kind, rel, content string) error {
+	data, err := e.encodeFile(rel, content)
+	if err != nil {
+		return err
+	}
+	if e.changedOnly && e.sameContent(rel, data) {
+		e.logger.Debug("skipping unchanged file", "kind", kind, "path", rel)
+		e.unchanged++
+		return nil
+	}
+	if e.dryRun {
+		e.planned = append(e.planned, plannedFile{kind: kind, path: rel})
+		return nil
+	}
+
+	e.logger.Debug("writing file", "kind", kind, "path", rel)
+	path := filepath.Join(e.baseDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+	if err := writeAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
 	return nil
 }
 
-func (e *Exporter) exportObject(typeDir string, obj schema.Object) error {
-	// Create file name with .sql extension
-	fileName := fmt.Sprintf("%s.sql", obj.Name)
-	filePath := filepath.Join(typeDir, fileName)
+// sameContent reports whether the file at a path relative to the base directory has the
+// same SHA-256 hash as data
+func (e *Exporter) sameContent(rel string, data []byte) bool {
+	current, err := os.ReadFile(filepath.Join(e.baseDir, rel))
+	if err != nil {
+		return false
+	}
+	return sha256.Sum256(current) == sha256.Sum256(data)
+}
 
-	// Create file
-	f, err := os.Create(filePath)
+// writeAtomic writes data to a temporary file next to path, then renames it into place,
+// so an interrupted export never leaves a truncated file behind
+func writeAtomic(path string, data []byte) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
 	if err != nil {
-		return fmt.Errorf("error creating file: %w", err)
+		return err
 	}
-	defer f.Close()
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
 
-	// Write header comment
-	header := fmt.Sprintf("-- Object: %s.%s\n-- Type: %s\n\n", obj.Schema, obj.Name, obj.Type)
-	if _, err := f.WriteString(header); err != nil {
-		return fmt.Errorf("error writing header: %w", err)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
 	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
 
-	// Write definition
-	definition := strings.TrimSpace(obj.Definition) + ";\n"
-	if _, err := f.WriteString(definition); err != nil {
-		return fmt.Errorf("error writing definition: %w", err)
+// exportCombined writes already ordered objects to schema.sql, followed by the event
+// triggers and closing database-wide sections, so it can be replayed in one go
+func (e *Exporter) exportCombined(schemas []schema.Schema, objects []schema.Object) error {
+	content := e.renderScript("Schema objects in dependency order", schemas, objects)
+	if len(e.eventTriggers) > 0 {
+		content += "\n-- Event triggers\n" + e.renderEventTriggers()
+	}
+	for _, section := range e.databaseSections() {
+		if section.closing {
+			content += "\n-- " + section.title + "\n" + strings.Join(section.statements, "\n") + "\n"
+		}
+	}
+	if err := e.writeFile("combined", "schema.sql", content); err != nil {
+		return fmt.Errorf("error writing schema.sql: %w", err)
+	}
+	return nil
+}
+
+// ExportInstallScript writes install.sql in the schema directory, creating every object of
+// the schema after the objects it depends on so it can bootstrap an empty database.
+// Dependencies on other schemas are expected to be installed beforehand.
+func (e *Exporter) ExportInstallScript(s schema.Schema) error {
+	ordered, err := schema.SortByDependencies(s.Objects)
+	if err != nil {
+		return fmt.Errorf("error ordering objects of schema %s: %w", s.Name, err)
 	}
 
+	content := e.renderScript(fmt.Sprintf("Install script for schema %s", s.Name), []schema.Schema{s}, ordered)
+	if err := e.writeFile("install", filepath.Join(s.Name, "install.sql"), content); err != nil {
+		return fmt.Errorf("error writing install script of schema %s: %w", s.Name, err)
+	}
 	return nil
 }
+
+// ExportDropScript writes drop.sql in the schema directory, dropping every object of the
+// schema if it exists, dependents first, so the schema can be torn down
+func (e *Exporter) ExportDropScript(s schema.Schema) error {
+	ordered, err := schema.SortByDependencies(s.Objects)
+	if err != nil {
+		return fmt.Errorf("error ordering objects of schema %s: %w", s.Name, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Drop script for schema %s\n\n", s.Name)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		obj := ordered[i]
+		if stmt := obj.DropStatement(e.dropCascade); stmt != "" {
+			b.WriteString(stmt + "\n")
+		} else {
+			fmt.Fprintf(&b, "-- No DROP statement for %s %s.%s\n", obj.Type, obj.Schema, obj.Name)
+		}
+	}
+
+	if err := e.writeFile("drop", filepath.Join(s.Name, "drop.sql"), b.String()); err != nil {
+		return fmt.Errorf("error writing drop script of schema %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// renderScript concatenates the files of already ordered objects under a title comment,
+// after the statements creating their schemas, which extensions may be installed in, and
+// the database-wide objects they may rely on. Oversized definitions are left out, as their
+// files are; the warning is recorded when skipping the file.
+func (e *Exporter) renderScript(title string, schemas []schema.Schema, objects []schema.Object) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %s\n", title)
+	b.WriteString(e.renderSchemas(schemas))
+	for _, section := range e.databaseSections() {
+		if !section.closing {
+			b.WriteString("\n-- " + section.title + "\n" + strings.Join(section.statements, "\n") + "\n")
+		}
+	}
+	for _, obj := range objects {
+		if e.oversized(obj) {
+			continue
+		}
+		b.WriteString("\n" + e.render(obj))
+	}
+	return b.String()
+}
+
+// renderSchemas renders the statements creating schemas, as a section of a script
+func (e *Exporter) renderSchemas(schemas []schema.Schema) string {
+	statements := e.schemaStatements(schemas)
+	if len(statements) == 0 {
+		return ""
+	}
+	return "\n-- Schemas\n" + strings.Join(statements, "\n") + "\n"
+}
+
+// schemaStatements returns the statements creating schemas, with their owner unless NoOwner
+func (e *Exporter) schemaStatements(schemas []schema.Schema) []string {
+	var statements []string
+	for _, s := range schemas {
+		statements = append(statements, s.CreateStatements(!e.noOwner)...)
+	}
+	return statements
+}
+
+// databaseSection is a group of statements creating database-wide objects, written to a
+// file at the root of the output directory and at the start of install scripts, or at the
+// end of schema.sql for closing sections
+type databaseSection struct {
+	title      string
+	file       string
+	statements []string
+	closing    bool // Refers to schema objects, so comes after them
+}
+
+// databaseSections returns the non-empty database-wide sections, in creation order
+func (e *Exporter) databaseSections() []databaseSection {
+	var sections []databaseSection
+	for _, section := range []databaseSection{
+		{"Extensions", "extensions.sql", e.extensions, false},
+		{"Foreign data wrappers, servers and user mappings", "foreign_servers.sql", e.foreignServers, false},
+		{"Publications", "publications.sql", e.publications, true},
+		{"Subscriptions", "subscriptions.sql", e.subscriptions, true},
+	} {
+		if len(section.statements) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// exportDatabaseSection writes the statements of a database-wide section to its file at
+// the root of the output directory
+func (e *Exporter) exportDatabaseSection(section databaseSection) error {
+	content := "-- " + section.title + "\n\n" + strings.Join(section.statements, "\n") + "\n"
+	kind := strings.TrimSuffix(section.file, ".sql")
+	if err := e.writeFile(kind, section.file, content); err != nil {
+		return fmt.Errorf("error writing %s: %w", section.file, err)
+	}
+	return nil
+}
+
+// renderEventTriggers renders the event triggers of the database with their owners. They
+// execute functions of the exported schemas, so scripts create them last.
+func (e *Exporter) renderEventTriggers() string {
+	var b strings.Builder
+	for _, trigger := range e.eventTriggers {
+		b.WriteString("\n" + strings.TrimSpace(trigger.Definition) + ";\n")
+		if trigger.Security != nil && trigger.Security.Owner != "" && !e.noOwner {
+			b.WriteString("\n" + trigger.Security.OwnerStatement() + "\n")
+		}
+	}
+	return b.String()
+}
+
+// ExportRoleSecurityLabels writes SECURITY LABEL statements on roles to security_labels.sql
+// at the root of the output directory
+func (e *Exporter) ExportRoleSecurityLabels(statements []string) error {
+	content := "-- Role security labels\n\n" + strings.Join(statements, "\n") + "\n"
+	if err := e.writeFile("security_labels", "security_labels.sql", content); err != nil {
+		return fmt.Errorf("error writing role security labels: %w", err)
+	}
+	return nil
+}
+
+// exportSchema writes the files of a schema: schema.sql creating the schema itself, then a
+// file per object
+func (e *Exporter) exportSchema(s schema.Schema) error {
+	content := fmt.Sprintf("-- Schema %s\n\n", s.Name) + strings.Join(e.schemaStatements([]schema.Schema{s}), "\n") + "\n"
+	if err := e.writeFile("schema", filepath.Join(s.Name, "schema.sql"), content); err != nil {
+		return fmt.Errorf("error writing schema.sql: %w", err)
+	}
+
+	// Export each object into its type directory
+	paths, collisions := e.objectPaths(s)
+	e.collisions = append(e.collisions, collisions...)
+	for i, obj := range s.Objects {
+		if err := e.exportObject(paths[i], obj); err != nil {
+			return fmt.Errorf("error exporting object %s: %w", obj.Name, err)
+		}
+	}
+
+	return e.exportGrants(s)
+}
+
+// checkCollisions fails when objects of the schemas would share a file, naming them, unless
+// forced, in which case the colliding objects get numbered files and a warning. Dry runs
+// list collisions with the plan instead.
+func (e *Exporter) checkCollisions(schemas []schema.Schema) error {
+	if e.dryRun {
+		return nil
+	}
+	var collisions []string
+	for _, s := range schemas {
+		_, c := e.objectPaths(s)
+		collisions = append(collisions, c...)
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	if !e.force {
+		return fmt.Errorf("%d file name collision(s), use --force to write numbered files:\n  %s",
+			len(collisions), strings.Join(collisions, "\n  "))
+	}
+	for _, c := range collisions {
+		e.warnings = append(e.warnings, "file name collision: "+c)
+	}
+	return nil
+}
+
+// objectPaths returns the file of each object of a schema, relative to the base directory,
+// following the layout. With SplitTables, the parts of a table go next to its table.sql.
+// Names colliding within a directory, ignoring case so case-insensitive filesystems are
+// safe, get a numeric suffix in object order; each renaming is described in collisions.
+func (e *Exporter) objectPaths(s schema.Schema) (paths []string, collisions []string) {
+	used := make(map[string]string)       // Lowercased path to the object using it
+	tableFiles := make(map[string]string) // Table name to its file name, for split tables
+	paths = make([]string, len(s.Objects))
+	for i, obj := range s.Objects {
+		if fileName, ok := tableFiles[obj.Name]; ok && tablePartFiles[obj.Type] != "" {
+			paths[i] = e.objectPath(s.Name, obj.Type, fileName)
+			continue
+		}
+		fileName := e.naming.FileName(obj)
+		ext := filepath.Ext(fileName)
+		base := strings.TrimSuffix(fileName, ext)
+		wanted := e.objectPath(s.Name, obj.Type, fileName)
+		path := wanted
+		for n := 2; used[strings.ToLower(path)] != ""; n++ {
+			fileName = fmt.Sprintf("%s_%d%s", base, n, ext)
+			path = e.objectPath(s.Name, obj.Type, fileName)
+		}
+		if path != wanted {
+			collisions = append(collisions, fmt.Sprintf("%s %s.%s and %s map to %s; using %s",
+				obj.Type, obj.Schema, obj.Name, used[strings.ToLower(wanted)], wanted, path))
+		}
+		used[strings.ToLower(path)] = obj.Schema + "." + obj.Name
+		paths[i] = path
+		if obj.Type == schema.TableType {
+			tableFiles[obj.Name] = fileName
+		}
+	}
+	return paths, collisions
+}
+
+// oversized reports whether an object's definition is over the size limit
+func (e *Exporter) oversized(obj schema.Object) bool {
+	return e.maxDefinitionSize > 0 && len(obj.Definition) > e.maxDefinitionSize
+}
+
+// skipOversized reports whether an object is skipped for being over the size limit,
+// recording a warning, or fails in strict mode
+func (e *Exporter) skipOversized(obj schema.Object) (bool, error) {
+	if !e.oversized(obj) {
+		return false, nil
+	}
+	msg := fmt.Sprintf("definition of %s %s.%s is %d bytes, over the %d bytes limit",
+		obj.Type, obj.Schema, obj.Name, len(obj.Definition), e.maxDefinitionSize)
+	if e.strict {
+		return true, fmt.Errorf("%s", msg)
+	}
+	e.warnings = append(e.warnings, msg+"; skipped")
+	return true, nil
+}
+
+// ExportObject writes the file of a single object to its place in the tree, e.g. to refresh
+// it without a full export, returning the paths written. manifest.json is left as is.
+func (e *Exporter) ExportObject(obj schema.Object) ([]string, error) {
+	files := e.fileObjects([]schema.Schema{{Name: obj.Schema, Objects: []schema.Object{obj}}})
+	paths, _ := e.objectPaths(files[0])
+	for i, o := range files[0].Objects {
+		if err := e.exportObject(paths[i], o); err != nil {
+			return nil, fmt.Errorf("error exporting object %s: %w", o.Name, err)
+		}
+	}
+	if e.dryRun {
+		e.printPlan()
+	}
+	return paths, nil
+}
+
+// Render returns the content of an object's file, header included
+func (e *Exporter) Render(obj schema.Object) string {
+	return e.render(obj)
+}
+
+func (e *Exporter) exportObject(rel string, obj schema.Object) error {
+	if skip, err := e.skipOversized(obj); skip {
+		return err
+	}
+	obj, err := e.evolveEnum(rel, obj)
+	if err != nil {
+		e.warnings = append(e.warnings, err.Error()+"; written with CREATE TYPE")
+	}
+
+	return e.writeFile(string(obj.Type), rel, e.render(obj))
+}
+
+// render builds the content of an object's file
+func (e *Exporter) render(obj schema.Object) string {
+	var b strings.Builder
+
+	// Write header comment
+	b.WriteString(e.renderHeader(obj))
+
+	// Write definition
+	b.WriteString(strings.TrimSpace(e.definition(obj)) + ";\n")
+
+	// Write ownership, privileges and security labels
+	if obj.Security != nil {
+		if obj.Security.Owner != "" && !e.noOwner {
+			b.WriteString("\n" + obj.Security.OwnerStatement() + "\n")
+		}
+		if e.grants == GrantsInline && len(obj.Security.Grants) > 0 {
+			b.WriteString("\n" + strings.Join(obj.Security.Grants, "\n") + "\n")
+		}
+		for _, label := range obj.Security.LabelStatements() {
+			b.WriteString("\n" + label + "\n")
+		}
+	}
+
+	return b.String()
+}
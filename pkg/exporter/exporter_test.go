@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+func TestMaxDefinitionSize(t *testing.T) {
+	schemas := []schema.Schema{{Name: "app", Objects: []schema.Object{
+		{Schema: "app", Name: "small", Type: schema.ViewType, Definition: "SELECT 1"},
+		{Schema: "app", Name: "huge", Type: schema.FunctionType, Definition: strings.Repeat("x", 100)},
+	}}}
+	tests := []struct {
+		name     string
+		limit    int
+		strict   bool
+		written  []string
+		skipped  []string
+		warnings int
+		err      string
+	}{
+		{name: "no limit", written: []string{"view/small.sql", "function/huge.sql"}},
+		{name: "under the limit", limit: 100, written: []string{"view/small.sql", "function/huge.sql"}},
+		{name: "over the limit", limit: 99, written: []string{"view/small.sql"}, skipped: []string{"function/huge.sql"}, warnings: 1},
+		{name: "strict", limit: 99, strict: true, err: "definition of function app.huge is 100 bytes, over the 99 bytes limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			e := NewExporter(dir, Options{MaxDefinitionSize: tt.limit, Strict: tt.strict, Bundle: true, Combined: true})
+			err := e.Export(schemas)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Export() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, rel := range tt.written {
+				if _, err := os.Stat(filepath.Join(dir, "app", rel)); err != nil {
+					t.Errorf("%s not written: %v", rel, err)
+				}
+			}
+			for _, rel := range tt.skipped {
+				if _, err := os.Stat(filepath.Join(dir, "app", rel)); !os.IsNotExist(err) {
+					t.Errorf("%s written, want it skipped", rel)
+				}
+			}
+			// Scripts leave out the objects whose files are skipped
+			for _, script := range []string{filepath.Join("app", "install.sql"), "schema.sql"} {
+				data, err := os.ReadFile(filepath.Join(dir, script))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := strings.Contains(string(data), "xxx"), len(tt.skipped) == 0; got != want {
+					t.Errorf("%s holds the huge definition: %v, want %v", script, got, want)
+				}
+				if !strings.Contains(string(data), "SELECT 1") {
+					t.Errorf("%s lacks the small definition", script)
+				}
+			}
+			warnings := e.Warnings()
+			if len(warnings) != tt.warnings {
+				t.Fatalf("Warnings() = %q, want %d warning(s)", warnings, tt.warnings)
+			}
+			for _, w := range warnings {
+				if !strings.Contains(w, "app.huge is 100 bytes") {
+					t.Errorf("warning %q does not name the object and its size", w)
+				}
+			}
+		})
+	}
+}
+
+func TestNewExporterDefaults(t *testing.T) {
+	e := NewExporter("out", Options{})
+
+	if e.naming != (PreserveCase{}) {
+		t.Errorf("naming = %T, want PreserveCase", e.naming)
+	}
+	if e.grants != GrantsInline {
+		t.Errorf("grants = %q, want %q", e.grants, GrantsInline)
+	}
+	if e.layout != ByType {
+		t.Errorf("layout = %q, want %q", e.layout, ByType)
+	}
+	if e.header != defaultHeader {
+		t.Error("header is not the default header")
+	}
+	if e.out != io.Writer(os.Stdout) {
+		t.Errorf("out = %v, want os.Stdout", e.out)
+	}
+	if e.logger == nil {
+		t.Error("logger is nil, want a discarding logger")
+	}
+	if e.encoding != nil || e.maxDefinitionSize != 0 || e.strict || e.force || e.dryRun || e.prune || e.noOwner {
+		t.Error("zero Options set an encoding, a size limit, strict, force, dry-run, prune or no-owner")
+	}
+}
+
+func TestExportSkipsEmptySchemas(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(dir, Options{})
+	err := e.Export([]schema.Schema{
+		{Name: "empty"},
+		{Name: "app", Objects: []schema.Object{{Schema: "app", Name: "v", Type: schema.ViewType, Definition: "SELECT 1"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "empty")); !os.IsNotExist(err) {
+		t.Errorf("directory of the empty schema was created")
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != "view" {
+			t.Errorf("empty type directory %s was created", entry.Name())
+		}
+	}
+}
+
+func TestEnumStyleAlter(t *testing.T) {
+	dir := t.TempDir()
+	export := func(labels string) (string, []string) {
+		t.Helper()
+		e := NewExporter(dir, Options{EnumStyle: EnumAlter})
+		schemas := []schema.Schema{{Name: "app", Objects: []schema.Object{
+			{Schema: "app", Name: "mood", Type: schema.TypeType, Definition: "CREATE TYPE app.mood AS ENUM (\n" + labels + "\n)"},
+		}}}
+		if err := e.Export(schemas); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "app", "type", "mood.sql"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data), e.Warnings()
+	}
+
+	created, _ := export("    'sad',\n    'ok'")
+	if !strings.Contains(created, "CREATE TYPE app.mood AS ENUM (\n    'sad',\n    'ok'\n);\n") {
+		t.Fatalf("first export =\n%s\nwant CREATE TYPE", created)
+	}
+
+	appended, _ := export("    'sad',\n    'ok',\n    'happy'")
+	if want := created[:len(created)-1] + "\n\nALTER TYPE app.mood ADD VALUE 'happy' AFTER 'ok';\n"; appended != want {
+		t.Errorf("export with an appended label =\n%s\nwant\n%s", appended, want)
+	}
+	if again, _ := export("    'sad',\n    'ok',\n    'happy'"); again != appended {
+		t.Errorf("unchanged export =\n%s\nwant the file kept\n%s", again, appended)
+	}
+
+	reordered, warnings := export("    'ok',\n    'sad',\n    'happy'")
+	if !strings.Contains(reordered, "CREATE TYPE app.mood AS ENUM (\n    'ok',\n    'sad',\n    'happy'\n);") || strings.Contains(reordered, "ALTER TYPE") {
+		t.Errorf("export with reordered labels =\n%s\nwant a new CREATE TYPE", reordered)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "removed or reordered") {
+		t.Errorf("Warnings() = %q, want the reordering reported", warnings)
+	}
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// GrantsMode decides where the privileges of objects are written
+type GrantsMode string
+
+const (
+	// GrantsInline writes the privileges of an object after its definition
+	GrantsInline GrantsMode = "inline"
+	// GrantsFile collects the privileges of a schema's objects into its grants.sql
+	GrantsFile GrantsMode = "file"
+	// GrantsNone leaves privileges out, including default privileges
+	GrantsNone GrantsMode = "none"
+)
+
+// ParseGrantsMode returns the grants mode with the given name
+func ParseGrantsMode(name string) (GrantsMode, error) {
+	switch m := GrantsMode(name); m {
+	case GrantsInline, GrantsFile, GrantsNone:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown grants mode %q (expected inline, file or none)", name)
+}
+
+// exportGrants writes grants.sql in the schema directory with the ALTER DEFAULT PRIVILEGES
+// statements of the schema and, in GrantsFile mode, the privileges of its objects
+func (e *Exporter) exportGrants(s schema.Schema) error {
+	grants := e.renderGrants(s)
+	if grants == "" {
+		return nil
+	}
+
+	content := fmt.Sprintf("-- Privileges of schema %s\n", s.Name) + grants
+	if err := e.writeFile("grants", filepath.Join(s.Name, "grants.sql"), content); err != nil {
+		return fmt.Errorf("error writing grants of schema %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// renderGrants renders the privileges not written with the objects of a schema: its default
+// privileges and, in GrantsFile mode, the privileges of its objects. It is empty when there
+// are none.
+func (e *Exporter) renderGrants(s schema.Schema) string {
+	if e.grants == GrantsNone {
+		return ""
+	}
+
+	var b strings.Builder
+	if e.grants == GrantsFile {
+		for _, obj := range s.Objects {
+			if obj.Security == nil || len(obj.Security.Grants) == 0 || e.oversized(obj) {
+				continue
+			}
+			fmt.Fprintf(&b, "\n-- %s %s.%s\n%s\n", obj.Type, obj.Schema, obj.Name, strings.Join(obj.Security.Grants, "\n"))
+		}
+	}
+	if len(s.DefaultPrivileges) > 0 {
+		fmt.Fprintf(&b, "\n-- Default privileges\n%s\n", strings.Join(s.DefaultPrivileges, "\n"))
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// defaultHeader is the header of object files when no template is given. Its "-- Object:"
+// and "-- Type:" lines mark files written by pgsac, e.g. for --prune. A "-- Redacted:" line
+// flags definitions with secrets replaced by schema.Redact.
+var defaultHeader = template.Must(template.New("header").Parse("-- Object: {{.Schema}}.{{.Name}}\n-- Type: {{.Type}}\n" +
+	"{{if .Redacted}}-- Redacted: parts of the definition were replaced with " + schema.RedactedPlaceholder + "\n{{end}}"))
+
+// HeaderData is what a header template is rendered with: the object's fields, plus the
+// name of its schema, the time of the export and the pgsac version
+type HeaderData struct {
+	schema.Object
+	SchemaName string
+	Timestamp  time.Time
+	Version    string
+}
+
+// ParseHeaderTemplate parses a text/template rendering the comment header of object files
+// from HeaderData, and checks that it renders. Templates should keep the "-- Object:" and
+// "-- Type:" lines of the default header for pgsac to recognize the files it wrote.
+func ParseHeaderTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("header").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	sample := HeaderData{Object: schema.Object{Schema: "public", Name: "example", Type: schema.TableType}, SchemaName: "public"}
+	if err := tmpl.Execute(new(strings.Builder), sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderHeader renders the header of an object's file, ending with a blank line. A header
+// template failing on the object falls back to the default header with a warning.
+func (e *Exporter) renderHeader(obj schema.Object) string {
+	data := HeaderData{Object: obj, SchemaName: obj.Schema, Timestamp: e.started, Version: e.version}
+	var b strings.Builder
+	if err := e.header.Execute(&b, data); err != nil {
+		// Files are rendered more than once, e.g. for the manifest; warn once per object
+		key := fmt.Sprintf("%s %s.%s(%s)", obj.Type, obj.Schema, obj.Name, obj.Args)
+		if !e.headerFailures[key] {
+			e.headerFailures[key] = true
+			e.warnings = append(e.warnings, fmt.Sprintf("header template failed on %s: %v; using the default header", key, err))
+		}
+		b.Reset()
+		defaultHeader.Execute(&b, data)
+	}
+
+	header := b.String()
+	if header != "" && !strings.HasSuffix(header, "\n") {
+		header += "\n"
+	}
+	return header + "\n"
+}
@@ -0,0 +1,49 @@
+package exporter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// Layout decides how object files are arranged in a schema directory
+type Layout string
+
+const (
+	// ByType puts each object in a directory per type: "<schema>/<type>/<name>.sql"
+	ByType Layout = "by-type"
+	// ByObject puts each object in a directory per name: "<schema>/<name>/<type>.sql"
+	ByObject Layout = "by-object"
+)
+
+// ParseLayout returns the layout with the given name
+func ParseLayout(name string) (Layout, error) {
+	switch l := Layout(name); l {
+	case ByType, ByObject:
+		return l, nil
+	}
+	return "", fmt.Errorf("unknown layout %q (expected by-type or by-object)", name)
+}
+
+// objectPath returns the file of an object of the given type and file name, relative to the
+// base directory. With SplitTables, tables and their parts are files of a table directory:
+// "<schema>/table/<name>/<part>.sql" by type, "<schema>/<name>/<part>.sql" by object.
+func (e *Exporter) objectPath(schemaName string, objType schema.ObjectType, fileName string) string {
+	ext := filepath.Ext(fileName)
+	name := strings.TrimSuffix(fileName, ext)
+	part, split := tablePartFiles[objType]
+	split = split && e.splitTables
+
+	if e.layout == ByObject {
+		if !split {
+			part = string(objType) + ext
+		}
+		return filepath.Join(schemaName, name, part)
+	}
+	if split {
+		return filepath.Join(schemaName, string(schema.TableType), name, part)
+	}
+	return filepath.Join(schemaName, string(objType), fileName)
+}
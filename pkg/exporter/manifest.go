@@ -0,0 +1,162 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ofux/pgsac/pkg/schema"
+	"golang.org/x/text/encoding"
+)
+
+// manifestFile is the name of the manifest at the root of the output directory
+const manifestFile = "manifest.json"
+
+// Manifest describes an export for auditing and tooling
+type Manifest struct {
+	Version     string          `json:"pgsac_version"`
+	ExtractedAt time.Time       `json:"extracted_at"`
+	Encoding    string          `json:"encoding,omitempty"` // Of the SQL files, empty for UTF-8
+	Objects     []ManifestEntry `json:"objects"`
+	Extraction  []ManifestStep  `json:"extraction,omitempty"` // Only with Options.Stats
+}
+
+// ManifestStep summarizes the extraction of an object type
+type ManifestStep struct {
+	Step       string `json:"step"`
+	Objects    int    `json:"objects"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ManifestEntry describes an exported object and its file
+type ManifestEntry struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Args   string `json:"args,omitempty"` // Argument types of functions
+	Path   string `json:"path"`           // Relative to the output directory
+	SHA256 string `json:"sha256"`         // Hash of the file content
+
+	Depends []string `json:"depends,omitempty"` // Objects to create first, as schema.name
+}
+
+// WriteManifest writes manifest.json at the root of the output directory, listing every
+// object file of the export with a hash of its content. The timestamp of the manifest on
+// disk is kept when nothing else changed, so an unchanged database leaves it untouched.
+func (e *Exporter) WriteManifest(schemas []schema.Schema) error {
+	entries, err := e.manifestEntries(schemas)
+	if err != nil {
+		return err
+	}
+	return e.writeManifest(entries)
+}
+
+// manifestEntries describes the object files of schemas, in object order
+func (e *Exporter) manifestEntries(schemas []schema.Schema) ([]ManifestEntry, error) {
+	entries := []ManifestEntry{}
+	for _, s := range e.fileObjects(schemas) {
+		paths, _ := e.objectPaths(s)
+		for i, obj := range s.Objects {
+			if e.oversized(obj) {
+				continue
+			}
+			obj, _ := e.evolveEnum(paths[i], obj)
+			data, err := e.encodeFile(paths[i], e.render(obj))
+			if err != nil {
+				return nil, err
+			}
+			sum := sha256.Sum256(data)
+			entries = append(entries, ManifestEntry{
+				Schema: obj.Schema,
+				Name:   obj.Name,
+				Type:   string(obj.Type),
+				Args:   obj.Args,
+				Path:   filepath.ToSlash(paths[i]),
+				SHA256: hex.EncodeToString(sum[:]),
+
+				Depends: obj.Depends,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// writeManifest writes manifest.json listing the given object files, see WriteManifest
+func (e *Exporter) writeManifest(entries []ManifestEntry) error {
+	manifest := Manifest{Version: e.version, ExtractedAt: time.Now().UTC().Truncate(time.Second),
+		Encoding: e.encodingName(), Objects: entries}
+	for _, step := range e.stats {
+		manifest.Extraction = append(manifest.Extraction, ManifestStep{
+			Step:       step.Step,
+			Objects:    step.Objects,
+			DurationMS: step.Duration.Milliseconds(),
+		})
+	}
+
+	if data, err := os.ReadFile(filepath.Join(e.baseDir, manifestFile)); err == nil {
+		var previous Manifest
+		if json.Unmarshal(data, &previous) == nil && previous.Version == manifest.Version &&
+			previous.Encoding == manifest.Encoding &&
+			slices.EqualFunc(previous.Objects, manifest.Objects, sameEntry) {
+			manifest.ExtractedAt = previous.ExtractedAt
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %w", err)
+	}
+	if err := e.writeFile("manifest", manifestFile, string(data)+"\n"); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	return nil
+}
+
+// sameEntry reports whether two manifest entries describe the same file content
+func sameEntry(a, b ManifestEntry) bool {
+	return a.Schema == b.Schema && a.Name == b.Name && a.Type == b.Type && a.Args == b.Args &&
+		a.Path == b.Path && a.SHA256 == b.SHA256 && slices.Equal(a.Depends, b.Depends)
+}
+
+// LoadExport reads the objects of an export from its manifest.json. Each object's
+// Definition is the whole content of its file, as written, and its Depends come from
+// the manifest, so the objects can be ordered and replayed. Files written in another
+// encoding than UTF-8 are decoded.
+func LoadExport(dir string) ([]schema.Object, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %w", err)
+	}
+	var enc encoding.Encoding
+	if manifest.Encoding != "" {
+		if enc, err = ParseEncoding(manifest.Encoding); err != nil {
+			return nil, fmt.Errorf("error decoding manifest: %w", err)
+		}
+	}
+
+	objects := make([]schema.Object, len(manifest.Objects))
+	for i, entry := range manifest.Objects {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", entry.Path, err)
+		}
+		objects[i] = schema.Object{
+			Schema:     entry.Schema,
+			Name:       entry.Name,
+			Type:       schema.ObjectType(entry.Type),
+			Definition: decodeWith(enc, entry.Path, content),
+			Args:       entry.Args,
+			Depends:    entry.Depends,
+		}
+	}
+	return objects, nil
+}
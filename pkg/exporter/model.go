@@ -0,0 +1,105 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ofux/pgsac/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the output format of an export
+type Format string
+
+const (
+	// FormatSQL writes SQL files, one per object
+	FormatSQL Format = "sql"
+	// FormatJSON writes the schema model to schema.json
+	FormatJSON Format = "json"
+	// FormatYAML writes the schema model to schema.yaml
+	FormatYAML Format = "yaml"
+)
+
+// ParseFormat returns the output format with the given name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatSQL, FormatJSON, FormatYAML:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected sql, json or yaml)", name)
+}
+
+// Model is the structured dump of extracted schemas written by ExportModel.
+// Field names are part of the output format and must stay stable.
+type Model struct {
+	Version string        `json:"pgsac_version" yaml:"pgsac_version"`
+	Schemas []ModelSchema `json:"schemas" yaml:"schemas"`
+}
+
+// ModelSchema is a schema of the model
+type ModelSchema struct {
+	Name    string        `json:"name" yaml:"name"`
+	Objects []ModelObject `json:"objects" yaml:"objects"`
+}
+
+// ModelObject is an object of the model
+type ModelObject struct {
+	Schema     string   `json:"schema" yaml:"schema"`
+	Name       string   `json:"name" yaml:"name"`
+	Type       string   `json:"type" yaml:"type"`
+	Args       string   `json:"args,omitempty" yaml:"args,omitempty"`       // Argument types of functions
+	Depends    []string `json:"depends,omitempty" yaml:"depends,omitempty"` // Objects to create first, as schema.name
+	Definition string   `json:"definition" yaml:"definition"`
+}
+
+// ExportModel writes the objects of the schemas, with their dependencies and definitions,
+// to schema.json or schema.yaml at the root of the output directory. Oversized definitions
+// are skipped as in Export.
+func (e *Exporter) ExportModel(format Format, schemas []schema.Schema) error {
+	model := Model{Version: e.version, Schemas: []ModelSchema{}}
+	for _, s := range schemas {
+		ms := ModelSchema{Name: s.Name, Objects: []ModelObject{}}
+		for _, obj := range s.Objects {
+			skip, err := e.skipOversized(obj)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+			ms.Objects = append(ms.Objects, ModelObject{
+				Schema:     obj.Schema,
+				Name:       obj.Name,
+				Type:       string(obj.Type),
+				Args:       obj.Args,
+				Depends:    obj.Depends,
+				Definition: obj.Definition,
+			})
+		}
+		model.Schemas = append(model.Schemas, ms)
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(model, "", "  ")
+		data = append(data, '\n')
+	case FormatYAML:
+		data, err = yaml.Marshal(model)
+	default:
+		return fmt.Errorf("format %q has no model file", format)
+	}
+	if err != nil {
+		return fmt.Errorf("error encoding schema model: %w", err)
+	}
+
+	name := "schema." + string(format)
+	if err := e.writeFile(string(format), name, string(data)); err != nil {
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+	if e.dryRun {
+		e.printPlan()
+	}
+	return nil
+}
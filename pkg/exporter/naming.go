@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// NamingStrategy decides the file name of an exported object, including its extension.
+// The exporter guarantees uniqueness on top of it: names colliding within a directory,
+// ignoring case, fail the export, or get a numeric suffix with Options.Force. The built-in
+// strategies append the argument types of functions, so overloads get distinct files.
+type NamingStrategy interface {
+	FileName(obj schema.Object) string
+}
+
+// PreserveCase names files after the object name as-is. This is the default strategy.
+type PreserveCase struct{}
+
+// FileName returns "<name>.sql", or "<name>__<args>.sql" for functions with arguments
+func (PreserveCase) FileName(obj schema.Object) string {
+	return sanitizeFileName(obj.Name) + argsSuffix(obj.Args) + ".sql"
+}
+
+// SnakeCase names files after the object name converted to snake_case
+type SnakeCase struct{}
+
+// FileName returns "<snake_name>.sql", or "<snake_name>__<args>.sql" for functions with arguments
+func (SnakeCase) FileName(obj schema.Object) string {
+	var b strings.Builder
+	prevLower := false
+	for _, r := range obj.Name {
+		switch {
+		case unicode.IsUpper(r):
+			if prevLower {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			prevLower = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			prevLower = true
+		default:
+			b.WriteRune('_')
+			prevLower = false
+		}
+	}
+	return b.String() + argsSuffix(obj.Args) + ".sql"
+}
+
+// IncludeOID appends the object's catalog OID to its name, making names unique per database
+type IncludeOID struct{}
+
+// FileName returns "<name>.<oid>.sql", or "<name>.sql" when the OID is unknown.
+// Function arguments are appended to the name as with PreserveCase.
+func (IncludeOID) FileName(obj schema.Object) string {
+	if obj.OID == 0 {
+		return PreserveCase{}.FileName(obj)
+	}
+	return fmt.Sprintf("%s%s.%d.sql", sanitizeFileName(obj.Name), argsSuffix(obj.Args), obj.OID)
+}
+
+// namingStrategies lists the built-in strategies by name
+var namingStrategies = map[string]NamingStrategy{
+	"preserve": PreserveCase{},
+	"snake":    SnakeCase{},
+	"oid":      IncludeOID{},
+}
+
+// NamingStrategyByName returns the built-in strategy with the given name
+func NamingStrategyByName(name string) (NamingStrategy, error) {
+	if n, ok := namingStrategies[name]; ok {
+		return n, nil
+	}
+
+	var names []string
+	for n := range namingStrategies {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown naming strategy %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// argsSuffix turns function argument types into a file name suffix, e.g.
+// "integer, text[]" becomes "__integer_text_array". No arguments give no suffix.
+func argsSuffix(args string) string {
+	words := strings.FieldsFunc(strings.ReplaceAll(args, "[]", " array"), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	return "__" + strings.Join(words, "_")
+}
+
+// sanitizeFileName replaces characters that cannot appear in a file name
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', 0:
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		obj      schema.Object
+		preserve string
+		snake    string
+		oid      string
+	}{
+		{
+			name:     "plain table",
+			obj:      schema.Object{Name: "users", Type: schema.TableType, OID: 16384},
+			preserve: "users.sql", snake: "users.sql", oid: "users.16384.sql",
+		},
+		{
+			name:     "mixed case",
+			obj:      schema.Object{Name: "UserAccounts", Type: schema.TableType, OID: 16385},
+			preserve: "UserAccounts.sql", snake: "user_accounts.sql", oid: "UserAccounts.16385.sql",
+		},
+		{
+			name:     "spaces and slashes",
+			obj:      schema.Object{Name: "a b/c", Type: schema.ViewType},
+			preserve: "a b_c.sql", snake: "a_b_c.sql", oid: "a b_c.sql",
+		},
+		{
+			name:     "function overload",
+			obj:      schema.Object{Name: "addItem", Type: schema.FunctionType, Args: "integer, text[]", OID: 16390},
+			preserve: "addItem__integer_text_array.sql", snake: "add_item__integer_text_array.sql", oid: "addItem__integer_text_array.16390.sql",
+		},
+		{
+			name:     "function without arguments",
+			obj:      schema.Object{Name: "now_utc", Type: schema.FunctionType, OID: 16391},
+			preserve: "now_utc.sql", snake: "now_utc.sql", oid: "now_utc.16391.sql",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range []struct {
+				strategy NamingStrategy
+				want     string
+			}{
+				{PreserveCase{}, tt.preserve},
+				{SnakeCase{}, tt.snake},
+				{IncludeOID{}, tt.oid},
+			} {
+				if got := s.strategy.FileName(tt.obj); got != s.want {
+					t.Errorf("%T.FileName() = %q, want %q", s.strategy, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNamingStrategyByName(t *testing.T) {
+	for name, want := range map[string]NamingStrategy{"preserve": PreserveCase{}, "snake": SnakeCase{}, "oid": IncludeOID{}} {
+		got, err := NamingStrategyByName(name)
+		if err != nil || got != want {
+			t.Errorf("NamingStrategyByName(%q) = %T, %v, want %T", name, got, err, want)
+		}
+	}
+	if _, err := NamingStrategyByName("kebab"); err == nil {
+		t.Error("NamingStrategyByName(kebab) succeeded, want an error")
+	}
+}
+
+func TestObjectPathsAreUnique(t *testing.T) {
+	s := schema.Schema{Name: "app", Objects: []schema.Object{
+		{Schema: "app", Name: "Users", Type: schema.TableType},
+		{Schema: "app", Name: "users", Type: schema.TableType},
+		{Schema: "app", Name: "user_accounts", Type: schema.TableType},
+		{Schema: "app", Name: "UserAccounts", Type: schema.TableType},
+		{Schema: "app", Name: "f", Type: schema.FunctionType, Args: "integer"},
+		{Schema: "app", Name: "f", Type: schema.FunctionType, Args: "text"},
+	}}
+	tests := []struct {
+		naming     NamingStrategy
+		want       []string
+		collisions int
+	}{
+		{
+			naming: PreserveCase{},
+			want: []string{"table/Users.sql", "table/users_2.sql", "table/user_accounts.sql",
+				"table/UserAccounts.sql", "function/f__integer.sql", "function/f__text.sql"},
+			collisions: 1,
+		},
+		{
+			naming: SnakeCase{},
+			want: []string{"table/users.sql", "table/users_2.sql", "table/user_accounts.sql",
+				"table/user_accounts_2.sql", "function/f__integer.sql", "function/f__text.sql"},
+			collisions: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(reflect.TypeOf(tt.naming).Name(), func(t *testing.T) {
+			e := NewExporter(t.TempDir(), Options{Naming: tt.naming})
+			paths, collisions := e.objectPaths(s)
+			for i, want := range tt.want {
+				tt.want[i] = filepath.Join("app", want)
+			}
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("objectPaths() = %q, want %q", paths, tt.want)
+			}
+			if len(collisions) != tt.collisions {
+				t.Errorf("objectPaths() reported %d collisions, want %d: %q", len(collisions), tt.collisions, collisions)
+			}
+		})
+	}
+}
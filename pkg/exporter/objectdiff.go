@@ -0,0 +1,85 @@
+package exporter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// ObjectDiff describes an object file that differs from the database
+type ObjectDiff struct {
+	Path    string // Relative to the exporter's base directory
+	Object  string // Qualified name of the object, empty for removed files
+	Type    string // Object type, empty for removed files
+	Status  string // "added" (only in the database), "modified" or "removed" (only on disk)
+	Unified string // Unified diff from the file to the database, on normalized definitions
+}
+
+// Diff compares the object files on disk with the extracted schemas. Definitions are
+// normalized first, so header comments and whitespace-only changes are not reported.
+func (e *Exporter) Diff(schemas []schema.Schema) ([]ObjectDiff, error) {
+	files, err := e.compareFiles(schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []ObjectDiff
+	for _, f := range files {
+		from, to := "a/"+f.path, "b/"+f.path
+		status := "modified"
+		switch {
+		case !f.onDisk:
+			from, status = "/dev/null", "added"
+		case !f.expected:
+			to, status = "/dev/null", "removed"
+		}
+
+		current, content := normalizeDefinition(f.current), normalizeDefinition(f.content)
+		if f.onDisk && f.expected && sameStatements(current, content) {
+			continue
+		}
+		diffs = append(diffs, ObjectDiff{
+			Path:    f.path,
+			Object:  f.object,
+			Type:    f.objectType,
+			Status:  status,
+			Unified: unifiedDiff(from, to, diffLines(current, content), 3),
+		})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, nil
+}
+
+// punctuationSpace matches whitespace around punctuation, which never changes a statement
+var punctuationSpace = regexp.MustCompile(`\s*([(),;])\s*`)
+
+// normalizeDefinition drops the leading comment block and blank lines, collapses runs of
+// whitespace within each line and removes whitespace around punctuation
+func normalizeDefinition(content string) string {
+	var lines []string
+	inHeader := true
+	for _, line := range splitLines(content) {
+		line = punctuationSpace.ReplaceAllString(strings.Join(strings.Fields(line), " "), "$1")
+		if line == "" {
+			continue
+		}
+		if inHeader && strings.HasPrefix(line, "--") {
+			continue
+		}
+		inHeader = false
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// sameStatements reports whether normalized definitions only differ in line breaks
+func sameStatements(a, b string) bool {
+	return punctuationSpace.ReplaceAllString(strings.Join(strings.Fields(a), " "), "$1") ==
+		punctuationSpace.ReplaceAllString(strings.Join(strings.Fields(b), " "), "$1")
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"io"
+	"log/slog"
+	"text/template"
+
+	"github.com/ofux/pgsac/pkg/schema"
+	"golang.org/x/text/encoding"
+)
+
+// Options configures an Exporter. The zero value writes every object to
+// "<schema>/<type>/<name>.sql", with its privileges, without any size limit.
+type Options struct {
+	// Naming decides object file names. Nil uses PreserveCase.
+	Naming NamingStrategy
+	// MaxDefinitionSize is the size in bytes above which a definition is not written.
+	// Zero disables the limit.
+	MaxDefinitionSize int
+	// Strict turns warnings, such as oversized definitions, into errors.
+	Strict bool
+	// Force writes objects whose file names collide, e.g. names differing only by case,
+	// to numbered files with a warning. Without it, Export fails before writing anything.
+	Force bool
+	// Layout decides how object files are arranged in schema directories. Empty uses ByType.
+	Layout Layout
+	// SplitTables writes each table to a directory of its own, "<schema>/table/<name>/"
+	// ("<schema>/<name>/" with ByObject), holding table.sql and, when not empty, indexes.sql, constraints.sql, foreign_keys.sql
+	// and comments.sql. Foreign keys get their own file as they may reference other tables.
+	SplitTables bool
+	// EnumStyle decides how enum types are written to their files. Empty uses EnumCreate.
+	EnumStyle EnumStyle
+	// Encoding is the character encoding of the SQL files written, and of those read back
+	// by Diff. Nil writes UTF-8. Characters the encoding cannot represent fail the export.
+	Encoding encoding.Encoding
+	// ChangedOnly leaves files whose SHA-256 hash matches the content to write untouched,
+	// keeping their modification time, and only rewrites the others.
+	ChangedOnly bool
+	// Combined also writes every object to schema.sql at the root of the output
+	// directory, ordered so that each object comes after its dependencies.
+	Combined bool
+	// Bundle also writes install.sql in each schema directory, creating the
+	// schema's objects in dependency order.
+	Bundle bool
+	// Drops also writes drop.sql in each schema directory, dropping the schema's objects
+	// if they exist in reverse dependency order.
+	Drops bool
+	// DropCascade adds CASCADE to the statements of drop.sql.
+	DropCascade bool
+	// Prune removes the files of exported schemas left from objects that no longer exist.
+	// Only files carrying the pgsac header are removed, then empty type directories.
+	Prune bool
+	// DryRun lists the files an export would write, with the total count and any
+	// file name collisions, instead of touching the filesystem.
+	DryRun bool
+	// Out receives the dry-run listing. Defaults to os.Stdout.
+	Out io.Writer
+	// Grants decides where object privileges go. Empty uses GrantsInline. Default
+	// privileges of a schema are written to its grants.sql unless GrantsNone.
+	Grants GrantsMode
+	// NoOwner leaves out the ALTER ... OWNER TO statements of objects.
+	NoOwner bool
+	// Extensions holds the CREATE EXTENSION statements of the database, written to
+	// extensions.sql and at the start of install scripts, schema.sql and single-file exports.
+	Extensions []string
+	// ForeignServers holds the statements creating the foreign data wrappers, servers and
+	// user mappings of the database, written to foreign_servers.sql and after Extensions.
+	ForeignServers []string
+	// Publications and Subscriptions hold the CREATE PUBLICATION and CREATE SUBSCRIPTION
+	// statements of the database, written to publications.sql and subscriptions.sql and at
+	// the end of schema.sql and single-file exports, after the tables they refer to.
+	Publications  []string
+	Subscriptions []string
+	// EventTriggers holds the event triggers of the database, as returned by
+	// schema.Extractor.ExtractEventTriggers, written to event_triggers.sql and at the end of
+	// schema.sql and single-file exports, after the functions they execute.
+	EventTriggers []schema.Object
+	// Pretty upper-cases keywords and re-indents definitions by their parentheses and query
+	// clauses, keeping their line breaks, see prettySQL. Definitions the formatter cannot
+	// read are written as extracted, with a warning.
+	Pretty bool
+	// HeaderTemplate renders the comment header of object files from HeaderData, see
+	// ParseHeaderTemplate. Nil uses the "-- Object:" and "-- Type:" header.
+	HeaderTemplate *template.Template
+	// Version is the pgsac version recorded in manifest.json
+	Version string
+	// Stats, if set, is recorded in manifest.json as the extraction summary. Durations
+	// change on every run, so the manifest is then rewritten each time.
+	Stats []schema.StepStats
+	// Logger receives a debug record for each file written or removed. Nil discards them.
+	Logger *slog.Logger
+}
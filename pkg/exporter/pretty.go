@@ -0,0 +1,161 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/schema"
+	"github.com/ofux/pgsac/pkg/sqltoken"
+)
+
+// prettyKeywords are upper-cased by Options.Pretty. Unquoted identifiers fold to lower
+// case, so upper-casing a word never changes what a statement means.
+var prettyKeywords = wordSet(`ADD ALL ALTER AND ANY AS ASC BEGIN BETWEEN BY CASCADE CASE CAST CHECK
+	COLLATE COLUMN COMMENT CONSTRAINT CREATE CROSS DEFAULT DEFERRABLE DEFINER DELETE DESC DISTINCT
+	DO DOMAIN ELSE END ENUM EXCEPT EXISTS EXTENSION FALSE FILTER FIRST FOR FOREIGN FROM FULL FUNCTION
+	GRANT GROUP HAVING ILIKE IMMUTABLE IN INDEX INHERITS INNER INSERT INTERSECT INTO INVOKER IS JOIN
+	KEY LANGUAGE LAST LATERAL LEFT LIKE LIMIT MATERIALIZED NOT NULL NULLS OF OFFSET ON OR ORDER OUTER
+	OVER OWNER PARALLEL PARTITION POLICY PRIMARY PROCEDURE RECURSIVE REFERENCES REPLACE RETURNING
+	RETURNS REVOKE RIGHT ROWS RULE SECURITY SELECT SEQUENCE SET STABLE STRICT TABLE THEN TO TRIGGER
+	TRUE TYPE UNION UNIQUE UPDATE USING VALUES VIEW VOLATILE WHEN WHERE WINDOW WITH`)
+
+// prettyClauses are the keywords starting a clause of a query; lines they start are not
+// indented further than the query itself
+var prettyClauses = wordSet(`SELECT FROM WHERE GROUP HAVING ORDER LIMIT OFFSET UNION INTERSECT EXCEPT
+	WINDOW JOIN LEFT RIGHT FULL INNER CROSS WITH VALUES RETURNING`)
+
+// prettyStatements are the keywords a definition formatted by Options.Pretty starts with.
+// Other definitions, such as psql \d+ descriptions, are left as they are.
+var prettyStatements = wordSet(`CREATE ALTER COMMENT GRANT REVOKE SELECT WITH`)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// definition returns the definition written for an object, reformatted with Options.Pretty.
+// A definition the formatter cannot read is written as is, with a warning.
+func (e *Exporter) definition(obj schema.Object) string {
+	if !e.pretty {
+		return obj.Definition
+	}
+	formatted, err := prettySQL(obj.Definition)
+	if err != nil {
+		// Files are rendered more than once, e.g. for the manifest; warn once per object
+		key := fmt.Sprintf("%s %s.%s(%s)", obj.Type, obj.Schema, obj.Name, obj.Args)
+		if !e.prettyFailures[key] {
+			e.prettyFailures[key] = true
+			e.warnings = append(e.warnings, fmt.Sprintf("cannot format %s: %v; writing it as extracted", key, err))
+		}
+		return obj.Definition
+	}
+	return formatted
+}
+
+// prettyLevel is a level of parentheses being formatted
+type prettyLevel struct {
+	indent int  // Of the lines at this level
+	query  bool // A query clause was seen at this level
+}
+
+// prettySQL upper-cases keywords, strips trailing whitespace, collapses runs of spaces and
+// blank lines, and re-indents lines: four spaces deeper than the line opening their
+// parentheses, and continuation lines of a query four spaces deeper than its clauses. Line
+// breaks are kept where they are, and literals, function bodies and comments are left
+// untouched.
+func prettySQL(definition string) (string, error) {
+	tokens, err := sqltoken.Tokenize(definition)
+	if err != nil {
+		return "", err
+	}
+	first := firstWord(tokens)
+	if !prettyStatements[strings.ToUpper(first)] {
+		return definition, nil
+	}
+
+	var b strings.Builder
+	levels := []prettyLevel{{}}
+	lineIndent := 0
+	lineStart := true
+	for i, tok := range tokens {
+		switch tok.Kind {
+		case sqltoken.Space:
+			// Leading and trailing spaces are replaced by the indentation
+			if !lineStart && i+1 < len(tokens) && tokens[i+1].Kind != sqltoken.Newline {
+				b.WriteString(" ")
+			}
+			continue
+		case sqltoken.Newline:
+			if b.Len() == 0 {
+				continue
+			}
+			if strings.Count(tok.Text, "\n") > 1 {
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+			lineStart = true
+			continue
+		}
+
+		text := tok.Text
+		upper := strings.ToUpper(text)
+		if tok.Kind == sqltoken.Word && prettyKeywords[upper] {
+			text = upper
+		}
+		level := &levels[len(levels)-1]
+		clause := tok.Kind == sqltoken.Word && prettyClauses[upper]
+		if lineStart {
+			switch {
+			case text == ")":
+				lineIndent = max(level.indent-4, 0)
+			case clause:
+				lineIndent = level.indent
+			case level.query:
+				lineIndent = level.indent + 4
+			default:
+				lineIndent = level.indent
+			}
+			b.WriteString(strings.Repeat(" ", lineIndent))
+			lineStart = false
+		}
+		if clause {
+			level.query = true
+		}
+
+		switch text {
+		case "(":
+			levels = append(levels, prettyLevel{indent: lineIndent + 4})
+		case ")":
+			if len(levels) == 1 {
+				return "", fmt.Errorf("unbalanced parentheses")
+			}
+			levels = levels[:len(levels)-1]
+		case ";":
+			if len(levels) == 1 {
+				levels[0].query = false
+			}
+		}
+		b.WriteString(text)
+	}
+	if len(levels) != 1 {
+		return "", fmt.Errorf("unbalanced parentheses")
+	}
+	return strings.TrimRight(b.String(), " \n"), nil
+}
+
+// firstWord returns the first word of a definition, skipping whitespace and comments
+func firstWord(tokens []sqltoken.Token) string {
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case sqltoken.Space, sqltoken.Newline, sqltoken.Comment:
+			continue
+		case sqltoken.Word:
+			return tok.Text
+		}
+		return ""
+	}
+	return ""
+}
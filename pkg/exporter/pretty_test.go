@@ -0,0 +1,85 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+func TestPrettySQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		want       string
+		err        bool
+	}{
+		{
+			name:       "query clauses and continuation lines",
+			definition: "create view app.v as\nselect a,\n  b\nfrom t\nwhere x = 'select from'",
+			want:       "CREATE VIEW app.v AS\nSELECT a,\n    b\nFROM t\nWHERE x = 'select from'",
+		},
+		{
+			name:       "parentheses",
+			definition: "create table t (\nid integer,\n      name text\n  )",
+			want:       "CREATE TABLE t (\n    id integer,\n    name text\n)",
+		},
+		{
+			name:       "function body untouched",
+			definition: "create function f() returns int language sql as $$select  1$$",
+			want:       "CREATE FUNCTION f() RETURNS int LANGUAGE sql AS $$select  1$$",
+		},
+		{
+			name:       "blank lines and trailing spaces",
+			definition: "select 1;   \n\n\n\nselect   2   \n",
+			want:       "SELECT 1;\n\nSELECT 2",
+		},
+		{
+			name:       "leading comment",
+			definition: "-- keep  me\nselect 1",
+			want:       "-- keep  me\nSELECT 1",
+		},
+		{
+			name:       "quoted identifier",
+			definition: `select "select" from "Order"`,
+			want:       `SELECT "select" FROM "Order"`,
+		},
+		{
+			name:       "psql description left alone",
+			definition: "Table \"app.t\"\n Column | Type\n--------+------",
+			want:       "Table \"app.t\"\n Column | Type\n--------+------",
+		},
+		{name: "unclosed parenthesis", definition: "select (1", err: true},
+		{name: "extra parenthesis", definition: "select 1)", err: true},
+		{name: "unterminated literal", definition: "select 'oops", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := prettySQL(tt.definition)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("prettySQL() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("prettySQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrettyFallsBackToTheDefinition(t *testing.T) {
+	e := NewExporter(t.TempDir(), Options{Pretty: true})
+	obj := schema.Object{Schema: "app", Name: "v", Type: schema.ViewType, Definition: "select (1"}
+	for i := 0; i < 2; i++ {
+		if got := e.definition(obj); got != obj.Definition {
+			t.Errorf("definition() = %q, want the definition as extracted", got)
+		}
+	}
+	if warnings := e.Warnings(); len(warnings) != 1 {
+		t.Errorf("Warnings() = %q, want a single warning", warnings)
+	}
+}
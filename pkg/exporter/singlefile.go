@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// ExportSingleFile writes every object of the schemas to one file at path instead of a
+// directory tree, ordered so that each object comes after its dependencies. A section
+// header starts each run of objects of the same schema and type, after the database-wide
+// objects. The event triggers, publications and subscriptions follow, and the privileges
+// not written with the objects close the file. Oversized definitions are skipped as in Export.
+func (e *Exporter) ExportSingleFile(path string, schemas []schema.Schema) error {
+	var all []schema.Object
+	for _, s := range schemas {
+		for _, obj := range s.Objects {
+			skip, err := e.skipOversized(obj)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+			all = append(all, obj)
+		}
+	}
+	ordered, err := schema.SortByDependencies(all)
+	if err != nil {
+		return fmt.Errorf("error ordering objects: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("-- Schema objects in dependency order\n")
+	if statements := e.schemaStatements(schemas); len(statements) > 0 {
+		fmt.Fprintf(&b, "\n-- ==========\n-- Schemas\n-- ==========\n\n%s\n", strings.Join(statements, "\n"))
+	}
+	sections := e.databaseSections()
+	for _, section := range sections {
+		if !section.closing {
+			fmt.Fprintf(&b, "\n-- ==========\n-- %s\n-- ==========\n\n%s\n", section.title, strings.Join(section.statements, "\n"))
+		}
+	}
+	var section string
+	for _, obj := range ordered {
+		if current := obj.Schema + "/" + string(obj.Type); current != section {
+			section = current
+			fmt.Fprintf(&b, "\n-- ==========\n-- Schema: %s, type: %s\n-- ==========\n", obj.Schema, obj.Type)
+		}
+		b.WriteString("\n" + e.render(obj))
+	}
+	if len(e.eventTriggers) > 0 {
+		fmt.Fprintf(&b, "\n-- ==========\n-- Event triggers\n-- ==========\n%s", e.renderEventTriggers())
+	}
+	for _, section := range sections {
+		if section.closing {
+			fmt.Fprintf(&b, "\n-- ==========\n-- %s\n-- ==========\n\n%s\n", section.title, strings.Join(section.statements, "\n"))
+		}
+	}
+	for _, s := range schemas {
+		if grants := e.renderGrants(s); grants != "" {
+			fmt.Fprintf(&b, "\n-- ==========\n-- Privileges of schema %s\n-- ==========\n%s", s.Name, grants)
+		}
+	}
+
+	if e.dryRun {
+		fmt.Fprintf(e.out, "%-20s %s\n", "single-file", path)
+		fmt.Fprintf(e.out, "%d object(s) would be written to %s\n", len(ordered), path)
+		return nil
+	}
+	data, err := e.encode(path, b.String())
+	if err != nil {
+		return err
+	}
+	e.logger.Debug("writing file", "kind", "single-file", "path", path)
+	if err := writeAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package exporter
+
+import (
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// Object types of the parts of a table written to its own directory with Options.SplitTables
+const (
+	tableIndexesType     schema.ObjectType = "table_indexes"
+	tableConstraintsType schema.ObjectType = "table_constraints"
+	tableForeignKeysType schema.ObjectType = "table_foreign_keys"
+	tableCommentsType    schema.ObjectType = "table_comments"
+)
+
+// tableParts maps the object types grouped per table to the group type, in file order
+var tableParts = []struct {
+	member schema.ObjectType
+	group  schema.ObjectType
+}{
+	{schema.IndexType, tableIndexesType},
+	{schema.ConstraintType, tableConstraintsType},
+	{schema.ForeignKeyType, tableForeignKeysType},
+}
+
+// tablePartFiles maps the types written to a table directory to their file
+var tablePartFiles = map[schema.ObjectType]string{
+	schema.TableType:     "table.sql",
+	tableIndexesType:     "indexes.sql",
+	tableConstraintsType: "constraints.sql",
+	tableForeignKeysType: "foreign_keys.sql",
+	tableCommentsType:    "comments.sql",
+}
+
+// fileObjects returns the objects to write to files. With SplitTables, the comments of each
+// table, then its indexes, constraints and foreign keys, are each merged into one object
+// named after the table and placed right after it. Dependencies on merged objects point to
+// the table instead. Without SplitTables, or applied again, the schemas are returned as is.
+func (e *Exporter) fileObjects(schemas []schema.Schema) []schema.Schema {
+	if !e.splitTables {
+		return schemas
+	}
+
+	// Tables being exported, by qualified name
+	tables := make(map[string]bool)
+	for _, s := range schemas {
+		for _, obj := range s.Objects {
+			if obj.Type == schema.TableType {
+				tables[obj.QualifiedName()] = true
+			}
+		}
+	}
+
+	// Members of each table part, and the table they were merged into
+	members := make(map[string]map[schema.ObjectType][]schema.Object)
+	mergedInto := make(map[string]string)
+	for _, s := range schemas {
+		for _, obj := range s.Objects {
+			group := partOf(obj.Type)
+			if group == "" || len(obj.Depends) == 0 || !tables[obj.Depends[0]] {
+				continue
+			}
+			table := obj.Depends[0]
+			if members[table] == nil {
+				members[table] = make(map[schema.ObjectType][]schema.Object)
+			}
+			members[table][group] = append(members[table][group], obj)
+			mergedInto[obj.QualifiedName()] = table
+		}
+	}
+
+	laidOut := make([]schema.Schema, len(schemas))
+	for i, s := range schemas {
+		var objects []schema.Object
+		for _, obj := range s.Objects {
+			if partOf(obj.Type) != "" && mergedInto[obj.QualifiedName()] != "" {
+				continue
+			}
+			if obj.Type != schema.TableType {
+				objects = append(objects, remapDepends(obj, mergedInto))
+				continue
+			}
+
+			table, comments := splitComments(obj)
+			objects = append(objects, remapDepends(table, mergedInto))
+			if len(comments) > 0 {
+				objects = append(objects, tablePart(obj, tableCommentsType, comments, []string{obj.QualifiedName()}))
+			}
+			for _, part := range tableParts {
+				var statements, depends []string
+				for _, member := range members[obj.QualifiedName()][part.group] {
+					statements = append(statements, strings.TrimSuffix(strings.TrimSpace(member.Definition), ";"))
+					depends = append(depends, remapDepends(member, mergedInto).Depends...)
+				}
+				if len(statements) > 0 {
+					objects = append(objects, tablePart(obj, part.group, statements, depends))
+				}
+			}
+		}
+		s.Objects = objects
+		laidOut[i] = s
+	}
+	return laidOut
+}
+
+// partOf returns the group type an object type is merged into, or "" if it is not merged
+func partOf(t schema.ObjectType) schema.ObjectType {
+	for _, part := range tableParts {
+		if part.member == t {
+			return part.group
+		}
+	}
+	return ""
+}
+
+// tablePart returns an object of the given group type holding statements of a table
+func tablePart(table schema.Object, group schema.ObjectType, statements, depends []string) schema.Object {
+	// Members depend on their own table, which is also the name of the part
+	seen := map[string]bool{table.QualifiedName(): true}
+	unique := []string{table.QualifiedName()}
+	for _, dep := range depends {
+		if !seen[dep] {
+			seen[dep] = true
+			unique = append(unique, dep)
+		}
+	}
+	return schema.Object{
+		Schema:     table.Schema,
+		Name:       table.Name,
+		Type:       group,
+		Definition: strings.Join(statements, ";\n\n"),
+		Depends:    unique,
+		OID:        table.OID,
+	}
+}
+
+// remapDepends points the dependencies of obj on merged objects to their table, leaving
+// out the table itself for its own parts
+func remapDepends(obj schema.Object, mergedInto map[string]string) schema.Object {
+	var depends []string
+	seen := make(map[string]bool)
+	for _, dep := range obj.Depends {
+		if table, ok := mergedInto[dep]; ok {
+			dep = table
+		}
+		if !seen[dep] {
+			seen[dep] = true
+			depends = append(depends, dep)
+		}
+	}
+	obj.Depends = depends
+	return obj
+}
+
+// splitComments moves the COMMENT ON statements out of a table definition
+func splitComments(table schema.Object) (schema.Object, []string) {
+	var kept, comments []string
+	for _, stmt := range splitStatements(table.Definition) {
+		if strings.HasPrefix(stmt, "COMMENT ON ") {
+			comments = append(comments, stmt)
+		} else {
+			kept = append(kept, stmt)
+		}
+	}
+	if len(comments) == 0 {
+		return table, nil
+	}
+	table.Definition = strings.Join(kept, ";\n\n")
+	return table, comments
+}
+
+// splitStatements splits a definition on the ";\n\n" separating its statements, keeping
+// separators found inside quoted literals, such as multi-paragraph comments
+func splitStatements(definition string) []string {
+	var statements []string
+	current := ""
+	for _, piece := range strings.Split(strings.TrimSpace(definition), ";\n\n") {
+		if current != "" {
+			current += ";\n\n"
+		}
+		current += piece
+		// An odd number of quotes leaves a literal open; doubled quotes count twice
+		if strings.Count(current, "'")%2 == 0 {
+			statements = append(statements, current)
+			current = ""
+		}
+	}
+	if current != "" {
+		statements = append(statements, current)
+	}
+	return statements
+}
@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// SchemaStream passes the schemas of an export to export one at a time, e.g. from
+// schema.Extractor.StreamSchemas. complete tells whether every object of the schema could
+// be extracted; the files of incomplete schemas are not pruned.
+type SchemaStream func(export func(s schema.Schema, complete bool) error) error
+
+// ExportStream writes the schemas of a stream like Export does, each as it comes, keeping
+// only the manifest entries of the schemas already written. Combined output orders the
+// objects of every schema, so it cannot be streamed. File name collisions are checked a
+// schema at a time, so the schemas before a collision are already written when it fails.
+func (e *Exporter) ExportStream(stream SchemaStream) error {
+	if e.combined {
+		return fmt.Errorf("combined output needs every schema at once and cannot be streamed")
+	}
+	if err := e.exportDatabase(); err != nil {
+		return err
+	}
+
+	entries := []ManifestEntry{}
+	err := stream(func(s schema.Schema, complete bool) error {
+		files := e.fileObjects([]schema.Schema{s})
+		if err := e.checkCollisions(files); err != nil {
+			return err
+		}
+		if err := e.exportSchemaFiles(s, files[0]); err != nil {
+			return err
+		}
+		if e.prune && complete {
+			if err := e.pruneStale(files); err != nil {
+				return err
+			}
+		}
+		written, err := e.manifestEntries(files)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, written...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := e.writeManifest(entries); err != nil {
+		return err
+	}
+	if e.dryRun {
+		e.printPlan()
+	}
+	return nil
+}
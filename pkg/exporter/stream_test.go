@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+func TestExportStream(t *testing.T) {
+	app := schema.Schema{Name: "app", Objects: []schema.Object{
+		{Schema: "app", Name: "orders", Type: schema.TableType, Definition: "CREATE TABLE app.orders (id int)"},
+		{Schema: "app", Name: "totals", Type: schema.ViewType, Definition: "SELECT 1"},
+	}}
+	audit := schema.Schema{Name: "audit", Objects: []schema.Object{
+		{Schema: "audit", Name: "log", Type: schema.TableType, Definition: "CREATE TABLE audit.log (id int)"},
+		{Schema: "audit", Name: "recent", Type: schema.ViewType, Definition: "SELECT 2"},
+	}}
+	dir := t.TempDir()
+	if err := NewExporter(dir, Options{}).Export([]schema.Schema{app, audit}); err != nil {
+		t.Fatal(err)
+	}
+	want, err := LoadExport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The views are gone, but audit is incomplete so its file is kept
+	app.Objects, audit.Objects = app.Objects[:1], audit.Objects[:1]
+	e := NewExporter(dir, Options{Prune: true})
+	err = e.ExportStream(func(export func(schema.Schema, bool) error) error {
+		if err := export(app, true); err != nil {
+			return err
+		}
+		return export(audit, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "app", "view", "totals.sql")); !os.IsNotExist(err) {
+		t.Errorf("stale view of the complete schema kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "audit", "view", "recent.sql")); err != nil {
+		t.Errorf("view of the incomplete schema pruned: %v", err)
+	}
+	got, err := LoadExport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = slices.DeleteFunc(want, func(obj schema.Object) bool { return obj.Type == schema.ViewType })
+	if !slices.EqualFunc(got, want, func(a, b schema.Object) bool {
+		return a.QualifiedName() == b.QualifiedName() && a.Definition == b.Definition
+	}) {
+		t.Errorf("manifest objects = %v, want %v", got, want)
+	}
+
+	err = NewExporter(dir, Options{Combined: true}).ExportStream(func(func(schema.Schema, bool) error) error { return nil })
+	if err == nil {
+		t.Error("ExportStream() with combined output succeeded, want an error")
+	}
+}
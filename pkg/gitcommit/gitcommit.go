@@ -0,0 +1,115 @@
+// Package gitcommit commits exported schema files into the git repository containing them.
+// It uses go-git, so no git binary is required.
+package gitcommit
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Commit stages every change under dir and commits it with the given message.
+// dir may be anywhere inside a git worktree. It returns false without creating a
+// commit when nothing under dir changed, and fails without staging anything when the
+// index already holds changes outside dir.
+func Commit(dir string, message string) (bool, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, fmt.Errorf("error resolving %s: %w", dir, err)
+	}
+
+	repo, err := git.PlainOpenWithOptions(absDir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return false, fmt.Errorf("error opening git repository for %s: %w", dir, err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("error opening worktree: %w", err)
+	}
+
+	rel, err := filepath.Rel(wt.Filesystem.Root(), absDir)
+	if err != nil {
+		return false, fmt.Errorf("error locating %s in worktree: %w", dir, err)
+	}
+
+	rel = filepath.ToSlash(rel)
+
+	// A commit takes the whole index, so changes staged outside dir would be committed
+	// under the pgsac message
+	status, err := wt.Status()
+	if err != nil {
+		return false, fmt.Errorf("error reading worktree status: %w", err)
+	}
+	var outside []string
+	for path, s := range status {
+		if isStaged(s) && !under(path, rel) {
+			outside = append(outside, path)
+		}
+	}
+	if len(outside) > 0 {
+		sort.Strings(outside)
+		return false, fmt.Errorf("the git index holds changes outside %s (%s); commit or unstage them first", dir, strings.Join(outside, ", "))
+	}
+
+	// Adding a directory also stages deletions of files under it
+	if err := wt.AddWithOptions(&git.AddOptions{Path: rel}); err != nil {
+		return false, fmt.Errorf("error staging %s: %w", rel, err)
+	}
+
+	status, err = wt.Status()
+	if err != nil {
+		return false, fmt.Errorf("error reading worktree status: %w", err)
+	}
+
+	staged := false
+	for path, s := range status {
+		if isStaged(s) && under(path, rel) {
+			staged = true
+			break
+		}
+	}
+	if !staged {
+		return false, nil
+	}
+
+	if _, err := wt.Commit(message, &git.CommitOptions{Author: signature(repo)}); err != nil {
+		return false, fmt.Errorf("error creating commit: %w", err)
+	}
+
+	return true, nil
+}
+
+// isStaged reports whether a file has changes in the index
+func isStaged(s *git.FileStatus) bool {
+	return s.Staging != git.Unmodified && s.Staging != git.Untracked
+}
+
+// under reports whether the slash-separated path is dir or inside it, dir being relative
+// to the worktree root
+func under(path, dir string) bool {
+	return dir == "." || path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// signature builds the commit author from the git configuration, falling back to pgsac
+func signature(repo *git.Repository) *object.Signature {
+	sig := &object.Signature{Name: "pgsac", Email: "pgsac@localhost", When: time.Now()}
+
+	cfg, err := repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return sig
+	}
+	if cfg.User.Name != "" {
+		sig.Name = cfg.User.Name
+	}
+	if cfg.User.Email != "" {
+		sig.Email = cfg.User.Email
+	}
+	return sig
+}
@@ -0,0 +1,100 @@
+package gitcommit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// newRepo creates a git repository with a committed README and returns its root
+func newRepo(t *testing.T) (string, *git.Worktree) {
+	t.Helper()
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "README", "readme\n")
+	if _, err := wt.Add("README"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Commit("initial", &git.CommitOptions{Author: signature(repo)}); err != nil {
+		t.Fatal(err)
+	}
+	return root, wt
+}
+
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	root, wt := newRepo(t)
+	writeFile(t, root, "schemas/public/table/t.sql", "CREATE TABLE t ();\n")
+	// Unstaged changes outside the directory are left alone
+	writeFile(t, root, "README", "changed\n")
+
+	committed, err := Commit(filepath.Join(root, "schemas"), "Update schema")
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if !committed {
+		t.Fatal("Commit() = false, want a commit")
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := status.File("README"); s.Worktree != git.Modified || s.Staging != git.Unmodified {
+		t.Errorf("README status = %c%c, want it modified and unstaged", s.Staging, s.Worktree)
+	}
+	// Clean files are left out of the status
+	if s, ok := status["schemas/public/table/t.sql"]; ok {
+		t.Errorf("schema file status = %c%c, want it committed", s.Staging, s.Worktree)
+	}
+
+	committed, err = Commit(filepath.Join(root, "schemas"), "Update schema")
+	if err != nil || committed {
+		t.Errorf("Commit() without changes = %v, %v, want false, nil", committed, err)
+	}
+}
+
+func TestCommitRefusesChangesStagedOutsideDir(t *testing.T) {
+	root, wt := newRepo(t)
+	writeFile(t, root, "README", "changed\n")
+	if _, err := wt.Add("README"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "schemas/public/table/t.sql", "CREATE TABLE t ();\n")
+
+	committed, err := Commit(filepath.Join(root, "schemas"), "Update schema")
+	if err == nil || !strings.Contains(err.Error(), "README") {
+		t.Fatalf("Commit() error = %v, want an error naming README", err)
+	}
+	if committed {
+		t.Error("Commit() = true, want no commit")
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := status.File("schemas/public/table/t.sql"); s.Staging != git.Untracked {
+		t.Errorf("schema file staging = %c, want it left unstaged", s.Staging)
+	}
+}
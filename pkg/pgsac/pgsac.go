@@ -0,0 +1,357 @@
+// Package pgsac extracts PostgreSQL schemas to files from Go programs. Run performs the
+// connect, extract and export steps of the pgsac command, configured with Options instead
+// of flags; Connect, Extract and Export run the steps one at a time, and Stream exports
+// the schemas as they are extracted.
+package pgsac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"slices"
+
+	"github.com/ofux/pgsac/pkg/database"
+	"github.com/ofux/pgsac/pkg/exporter"
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// Options configures Run
+type Options struct {
+	// Connection locates and authenticates to the database. Unlike the command, Run reads
+	// neither PG* environment variables nor the password file, see database.Config.ApplyEnv
+	// and database.LookupPgpass.
+	Connection database.Config
+	// SSH reaches the database through an SSH tunnel when its Host is set
+	SSH database.SSHConfig
+	// Schemas lists the schemas to extract. Empty extracts "public".
+	Schemas []string
+	// SchemaMap relocates the objects of a schema to another one, see schema.Remap
+	SchemaMap map[string]string
+	// Extract configures the extraction
+	Extract schema.Options
+	// RedactPatterns are replaced in definitions by schema.Redact, e.g. to keep tokens
+	// embedded in function bodies out of the files
+	RedactPatterns []*regexp.Regexp
+
+	// Output is the directory files are written to. Empty, with no SingleFile, only
+	// extracts the schemas.
+	Output string
+	// Export configures the export. The objects of the database as a whole (Extensions,
+	// ForeignServers, EventTriggers, Publications and Subscriptions) are filled in by Run.
+	Export exporter.Options
+	// Format, other than FormatSQL, writes the schema model to Output instead of SQL files.
+	// Empty uses FormatSQL.
+	Format exporter.Format
+	// SingleFile, if set, writes every object to this file instead of Output
+	SingleFile string
+	// Stream exports each schema to Output as soon as it is extracted, so only one schema is
+	// held in memory, see Stream. Run then returns no schemas.
+	Stream bool
+}
+
+// Extraction holds what was extracted from a database
+type Extraction struct {
+	// Schemas holds the objects of the extracted schemas, relocated by Options.SchemaMap
+	Schemas []schema.Schema
+	// RoleSecurityLabels holds SECURITY LABEL statements on roles, only extracted with
+	// schema.Options.IncludeSecurityLabels
+	RoleSecurityLabels []string
+
+	// Objects of the database as a whole, see the fields of exporter.Options
+	Extensions     []string
+	ForeignServers []string
+	EventTriggers  []schema.Object
+	Publications   []string
+	Subscriptions  []string
+
+	// Stats holds the objects extracted and the time spent for each object type
+	Stats []schema.StepStats
+	// Failures holds the errors schema.Options.ContinueOnError left objects out for
+	Failures []schema.Failure
+}
+
+// Run connects to the database, extracts the schemas and exports them, returning the
+// extracted schemas. Export warnings, e.g. for skipped oversized definitions, are logged
+// to opts.Export.Logger. With ContinueOnError, the objects that could be extracted are
+// exported, and the failures are returned as a *FailuresError.
+func Run(ctx context.Context, opts Options) ([]schema.Schema, error) {
+	db, closer, config, err := Connect(opts.Connection, opts.SSH)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+	defer closer.Close()
+
+	extractor := schema.NewExtractor(db, config, opts.Extract)
+	if opts.Stream {
+		return nil, runStream(ctx, extractor, opts)
+	}
+	extraction, err := Extract(ctx, extractor, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Output != "" || opts.SingleFile != "" {
+		if err := export(extraction, opts); err != nil {
+			return nil, err
+		}
+	}
+	if len(extraction.Failures) > 0 {
+		return extraction.Schemas, &FailuresError{Failures: extraction.Failures}
+	}
+	return extraction.Schemas, nil
+}
+
+// runStream extracts the database-wide objects, then streams the schemas to opts.Output,
+// logging the export warnings
+func runStream(ctx context.Context, extractor *schema.Extractor, opts Options) error {
+	if opts.Output == "" || opts.SingleFile != "" || isModel(opts.Format) {
+		return fmt.Errorf("streaming needs an output directory of SQL files")
+	}
+	extraction, err := ExtractDatabase(ctx, extractor, opts)
+	if err != nil {
+		return err
+	}
+	exp := exporter.NewExporter(opts.Output, extraction.ExportOptions(opts.Export))
+	err = Stream(ctx, exp, extractor, extraction, opts)
+	for _, w := range exp.Warnings() {
+		exportLogger(opts).Warn(w)
+	}
+	if err != nil {
+		return err
+	}
+	if len(extraction.Failures) > 0 {
+		return &FailuresError{Failures: extraction.Failures}
+	}
+	return nil
+}
+
+// exportLogger returns the logger of the export warnings, discarding them by default
+func exportLogger(opts Options) *slog.Logger {
+	if opts.Export.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return opts.Export.Logger
+}
+
+// export writes an extraction as configured by opts, logging the export warnings
+func export(extraction *Extraction, opts Options) error {
+	logger := exportLogger(opts)
+	if len(extraction.RoleSecurityLabels) > 0 && (opts.SingleFile != "" || isModel(opts.Format)) {
+		logger.Warn("role security labels are only written to SQL directory exports")
+	}
+
+	exp := exporter.NewExporter(opts.Output, extraction.ExportOptions(opts.Export))
+	if err := Export(exp, extraction, opts.Format, opts.SingleFile); err != nil {
+		return err
+	}
+	for _, w := range exp.Warnings() {
+		logger.Warn(w)
+	}
+	return nil
+}
+
+// FailuresError reports the objects ContinueOnError left out of an export
+type FailuresError struct {
+	Failures []schema.Failure
+}
+
+// Error counts the failures and gives the first one
+func (e *FailuresError) Error() string {
+	return fmt.Sprintf("%d extraction error(s), first: %v", len(e.Failures), e.Failures[0].Err)
+}
+
+// Connect connects to the database, through an SSH tunnel when ssh.Host is set. The
+// returned configuration is the one reaching the database, e.g. for the psql client, and
+// the closer closes the connection along with the tunnel.
+func Connect(config database.Config, ssh database.SSHConfig) (*sql.DB, io.Closer, database.Config, error) {
+	if ssh.Host == "" {
+		db, err := database.Connect(config)
+		return db, db, config, err
+	}
+
+	tunneled, err := database.ConnectViaTunnel(config, ssh)
+	if err != nil {
+		return nil, nil, config, err
+	}
+	return tunneled.DB, tunneled, tunneled.Config, nil
+}
+
+// Extract extracts opts.Schemas with extractor, relocated by opts.SchemaMap and redacted
+// with opts.RedactPatterns, then the objects of the database as a whole
+func Extract(ctx context.Context, extractor *schema.Extractor, opts Options) (*Extraction, error) {
+	schemas, err := extractor.ExtractSchemas(ctx, schemaNames(opts))
+	if err != nil {
+		return nil, fmt.Errorf("error extracting schemas: %w", err)
+	}
+	if schemas, err = schema.Remap(schemas, opts.SchemaMap); err != nil {
+		return nil, err
+	}
+	stats, failures := extractor.Stats(), extractor.Failures()
+
+	extraction, err := ExtractDatabase(ctx, extractor, opts)
+	if err != nil {
+		return nil, err
+	}
+	extraction.Schemas = schema.Redact(schemas, opts.RedactPatterns)
+	extraction.Stats = stats
+	extraction.Failures = append(failures, extraction.Failures...)
+	return extraction, nil
+}
+
+// Stream extracts opts.Schemas with extractor like Extract, exporting each schema with exp
+// before extracting the next, so only one schema is held in memory. x comes from
+// ExtractDatabase, exp from x's ExportOptions; Stream fills the Stats and Failures of x but
+// leaves its Schemas empty. opts.SchemaMap may rename schemas but not merge them, which
+// needs every source at once. Schemas with failures are not pruned.
+func Stream(ctx context.Context, exp *exporter.Exporter, extractor *schema.Extractor, x *Extraction, opts Options) error {
+	names := schemaNames(opts)
+	if err := checkUnmerged(names, opts.SchemaMap); err != nil {
+		return err
+	}
+	if len(x.RoleSecurityLabels) > 0 {
+		if err := exp.ExportRoleSecurityLabels(x.RoleSecurityLabels); err != nil {
+			return fmt.Errorf("error exporting role security labels: %w", err)
+		}
+	}
+
+	err := exp.ExportStream(func(export func(schema.Schema, bool) error) error {
+		return extractor.StreamSchemas(ctx, names, func(s schema.Schema) error {
+			complete := !slices.ContainsFunc(extractor.Failures(), func(f schema.Failure) bool { return f.Schema == s.Name })
+			remapped, err := schema.Remap([]schema.Schema{s}, opts.SchemaMap)
+			if err != nil {
+				return err
+			}
+			return export(schema.Redact(remapped, opts.RedactPatterns)[0], complete)
+		})
+	})
+	x.Stats = extractor.Stats()
+	x.Failures = append(x.Failures, extractor.Failures()...)
+	if err != nil {
+		return fmt.Errorf("error streaming schemas: %w", err)
+	}
+	return nil
+}
+
+// schemaNames returns the schemas to extract, "public" by default
+func schemaNames(opts Options) []string {
+	if len(opts.Schemas) == 0 {
+		return []string{"public"}
+	}
+	return opts.Schemas
+}
+
+// checkUnmerged fails when mapping relocates several of the schemas to the same one
+func checkUnmerged(schemaNames []string, mapping map[string]string) error {
+	sources := make(map[string]string) // Destination to its source
+	for _, name := range schemaNames {
+		dst := name
+		if mapped, ok := mapping[name]; ok {
+			dst = mapped
+		}
+		if src, ok := sources[dst]; ok {
+			return fmt.Errorf("schemas %s and %s both map to %s, which cannot be streamed", src, name, dst)
+		}
+		sources[dst] = name
+	}
+	return nil
+}
+
+// ExtractDatabase extracts the objects of the database as a whole with extractor, leaving
+// the schemas to Extract or Stream. With ContinueOnError, a failing step is recorded in
+// Failures and left empty.
+func ExtractDatabase(ctx context.Context, extractor *schema.Extractor, opts Options) (*Extraction, error) {
+	extraction := &Extraction{}
+
+	// Objects of the database as a whole; with ContinueOnError, a failing step is
+	// recorded and left empty
+	steps := []struct {
+		label   string
+		skip    bool
+		extract func() error
+	}{
+		{"role security labels", !opts.Extract.IncludeSecurityLabels, func() (err error) {
+			extraction.RoleSecurityLabels, err = extractor.ExtractRoleSecurityLabels(ctx)
+			return err
+		}},
+		{"extensions", false, func() (err error) {
+			extraction.Extensions, err = extractor.ExtractExtensions(ctx)
+			return err
+		}},
+		{"foreign servers", false, func() (err error) {
+			extraction.ForeignServers, err = extractor.ExtractForeignServers(ctx)
+			return err
+		}},
+		{"event triggers", false, func() (err error) {
+			extraction.EventTriggers, err = extractor.ExtractEventTriggers(ctx)
+			extraction.EventTriggers = schema.RedactObjects(extraction.EventTriggers, opts.RedactPatterns)
+			return err
+		}},
+		{"publications", false, func() (err error) {
+			extraction.Publications, err = extractor.ExtractPublications(ctx)
+			return err
+		}},
+		{"subscriptions", false, func() (err error) {
+			extraction.Subscriptions, err = extractor.ExtractSubscriptions(ctx)
+			return err
+		}},
+	}
+	for _, step := range steps {
+		if step.skip {
+			continue
+		}
+		if err := step.extract(); err != nil {
+			err = fmt.Errorf("error extracting %s: %w", step.label, err)
+			if !opts.Extract.ContinueOnError || ctx.Err() != nil {
+				return nil, err
+			}
+			extraction.Failures = append(extraction.Failures, schema.Failure{Step: step.label, Err: err})
+		}
+	}
+	return extraction, nil
+}
+
+// ExportOptions returns opts with the objects of the database as a whole filled in. Prune is
+// turned off when objects failed to extract, so their files are kept.
+func (x *Extraction) ExportOptions(opts exporter.Options) exporter.Options {
+	if len(x.Failures) > 0 {
+		opts.Prune = false
+	}
+	opts.Extensions = x.Extensions
+	opts.ForeignServers = x.ForeignServers
+	opts.EventTriggers = x.EventTriggers
+	opts.Publications = x.Publications
+	opts.Subscriptions = x.Subscriptions
+	return opts
+}
+
+// Export writes an extraction with exp, created with the extraction's ExportOptions: the
+// schema model for formats other than FormatSQL, every object to singleFile when set, or
+// else the directory tree, along with the role security labels
+func Export(exp *exporter.Exporter, x *Extraction, format exporter.Format, singleFile string) error {
+	var err error
+	switch {
+	case isModel(format):
+		err = exp.ExportModel(format, x.Schemas)
+	case singleFile != "":
+		err = exp.ExportSingleFile(singleFile, x.Schemas)
+	default:
+		// Role labels go first so a dry run lists them with the rest of the plan
+		if len(x.RoleSecurityLabels) > 0 {
+			if err := exp.ExportRoleSecurityLabels(x.RoleSecurityLabels); err != nil {
+				return fmt.Errorf("error exporting role security labels: %w", err)
+			}
+		}
+		err = exp.Export(x.Schemas)
+	}
+	if err != nil {
+		return fmt.Errorf("error exporting schemas: %w", err)
+	}
+	return nil
+}
+
+// isModel reports whether format writes the schema model rather than SQL
+func isModel(format exporter.Format) bool {
+	return format != "" && format != exporter.FormatSQL
+}
@@ -0,0 +1,171 @@
+// Package replay executes exported object files against a database, in dependency order.
+package replay
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+// ObjectError reports the object whose file failed to execute
+type ObjectError struct {
+	Object schema.Object
+	Err    error
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("%s %s.%s: %v", e.Object.Type, e.Object.Schema, e.Object.Name, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// settings are applied to the transaction before any object is created. Function bodies
+// are checked when functions are called rather than created, since they are not part of
+// the dependencies and may use objects created after them, as pg_dump does.
+var settings = []string{"SET LOCAL check_function_bodies = false;"}
+
+// step is a statement to execute, creating an object or, when obj is nil, a schema
+type step struct {
+	obj    *schema.Object
+	schema string
+	sql    string
+}
+
+// plan returns the statements replaying objects: missing schemas are created first,
+// then each object's file follows the objects it depends on
+func plan(objects []schema.Object) ([]step, error) {
+	ordered, err := schema.SortByDependencies(objects)
+	if err != nil {
+		return nil, fmt.Errorf("error ordering objects: %w", err)
+	}
+
+	var steps []step
+	created := make(map[string]bool)
+	for _, obj := range ordered {
+		if !created[obj.Schema] {
+			created[obj.Schema] = true
+			steps = append(steps, step{schema: obj.Schema, sql: "CREATE SCHEMA IF NOT EXISTS " + pq.QuoteIdentifier(obj.Schema) + ";"})
+		}
+	}
+	for i := range ordered {
+		steps = append(steps, step{obj: &ordered[i], sql: ordered[i].Definition})
+	}
+	return steps, nil
+}
+
+// PlanStep is a step of the plan Run follows, with the dependencies placing it there
+type PlanStep struct {
+	Schema string         // Schema created by the step, when Object is nil
+	Object *schema.Object // Object created by the step
+	// After lists the dependencies created by earlier steps, latest first: the first one
+	// is the dependency that places the object where it is
+	After []PlanDependency
+	// Missing lists the dependencies not found among the objects
+	Missing []string
+}
+
+// PlanDependency is a dependency of an object and the step creating it, counted from 1
+type PlanDependency struct {
+	Name string
+	Step int
+}
+
+// Plan returns the steps Run would execute, in order, with the dependencies of each object.
+// A *schema.CycleError is returned when objects depend on each other in a cycle.
+func Plan(objects []schema.Object) ([]PlanStep, error) {
+	steps, err := plan(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step of each object name, the last one for overloaded functions
+	stepOf := make(map[string]int)
+	for i, s := range steps {
+		if s.obj != nil {
+			stepOf[s.obj.QualifiedName()] = i + 1
+		}
+	}
+
+	planned := make([]PlanStep, len(steps))
+	for i, s := range steps {
+		planned[i] = PlanStep{Schema: s.schema, Object: s.obj}
+		if s.obj == nil {
+			continue
+		}
+		for _, dep := range s.obj.Depends {
+			switch n, ok := stepOf[dep]; {
+			case dep == s.obj.QualifiedName():
+			case ok:
+				planned[i].After = append(planned[i].After, PlanDependency{Name: dep, Step: n})
+			default:
+				planned[i].Missing = append(planned[i].Missing, dep)
+			}
+		}
+		slices.SortStableFunc(planned[i].After, func(a, b PlanDependency) int { return b.Step - a.Step })
+	}
+	return planned, nil
+}
+
+// Script returns the transaction Run would execute, as one SQL script that can be run with
+// psql without pgsac
+func Script(objects []schema.Object) (string, error) {
+	steps, err := plan(objects)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("BEGIN;\n\n")
+	for _, setting := range settings {
+		b.WriteString(setting + "\n\n")
+	}
+	for _, s := range steps {
+		b.WriteString(strings.TrimSpace(s.sql) + "\n\n")
+	}
+	b.WriteString("COMMIT;\n")
+	return b.String(), nil
+}
+
+// Run creates the schemas of the objects if missing, then executes the file of each object
+// after the objects it depends on, all in one transaction. The transaction is rolled back
+// when rollback is set, or when an object fails, which is reported as an *ObjectError.
+func Run(ctx context.Context, db *sql.DB, objects []schema.Object, rollback bool) error {
+	steps, err := plan(objects)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, setting := range settings {
+		if _, err := tx.ExecContext(ctx, setting); err != nil {
+			return fmt.Errorf("error configuring transaction: %w", err)
+		}
+	}
+	for _, s := range steps {
+		if _, err := tx.ExecContext(ctx, s.sql); err != nil {
+			if s.obj == nil {
+				return fmt.Errorf("error creating schema: %w", err)
+			}
+			return &ObjectError{Object: *s.obj, Err: err}
+		}
+	}
+
+	if rollback {
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
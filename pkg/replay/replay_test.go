@@ -0,0 +1,78 @@
+package replay
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/schema"
+)
+
+func TestPlan(t *testing.T) {
+	objects := []schema.Object{
+		{Schema: "app", Name: "v", Type: schema.ViewType, Depends: []string{"app.users", "app.orders"}},
+		{Schema: "app", Name: "orders", Type: schema.TableType, Depends: []string{"app.users"}},
+		{Schema: "app", Name: "users", Type: schema.TableType},
+		{Schema: "app", Name: "f", Type: schema.FunctionType, Args: "integer", Depends: []string{"app.f", "other.t"}},
+	}
+
+	steps, err := Plan(objects)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	var names []string
+	for _, s := range steps {
+		if s.Object == nil {
+			names = append(names, "schema "+s.Schema)
+		} else {
+			names = append(names, s.Object.QualifiedName())
+		}
+	}
+	if want := []string{"schema app", "app.users", "app.orders", "app.v", "app.f"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Plan() order = %v, want %v", names, want)
+	}
+
+	if want := []PlanDependency{{"app.users", 2}}; !reflect.DeepEqual(steps[2].After, want) {
+		t.Errorf("orders After = %v, want %v", steps[2].After, want)
+	}
+	// The dependency created last comes first
+	if want := []PlanDependency{{"app.orders", 3}, {"app.users", 2}}; !reflect.DeepEqual(steps[3].After, want) {
+		t.Errorf("view After = %v, want %v", steps[3].After, want)
+	}
+	if steps[4].After != nil || !reflect.DeepEqual(steps[4].Missing, []string{"other.t"}) {
+		t.Errorf("function After = %v, Missing = %v, want only other.t missing", steps[4].After, steps[4].Missing)
+	}
+}
+
+func TestPlanCycle(t *testing.T) {
+	objects := []schema.Object{
+		{Schema: "app", Name: "a", Type: schema.ViewType, Depends: []string{"app.b"}},
+		{Schema: "app", Name: "b", Type: schema.ViewType, Depends: []string{"app.a"}},
+	}
+	_, err := Plan(objects)
+	var cycle *schema.CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Plan() error = %v, want a *schema.CycleError", err)
+	}
+}
+
+func TestScript(t *testing.T) {
+	objects := []schema.Object{
+		{Schema: "app", Name: "v", Type: schema.ViewType, Definition: "CREATE VIEW app.v AS SELECT * FROM app.t;\n", Depends: []string{"app.t"}},
+		{Schema: "app", Name: "t", Type: schema.TableType, Definition: "CREATE TABLE app.t (id integer);"},
+	}
+	script, err := Script(objects)
+	if err != nil {
+		t.Fatalf("Script() error = %v", err)
+	}
+	want := "BEGIN;\n\n" +
+		"SET LOCAL check_function_bodies = false;\n\n" +
+		"CREATE SCHEMA IF NOT EXISTS \"app\";\n\n" +
+		"CREATE TABLE app.t (id integer);\n\n" +
+		"CREATE VIEW app.v AS SELECT * FROM app.t;\n\n" +
+		"COMMIT;\n"
+	if script != want {
+		t.Errorf("Script() =\n%s\nwant\n%s", script, want)
+	}
+}
@@ -0,0 +1,245 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// aclPrivileges maps the privilege letters of an aclitem to their keywords
+var aclPrivileges = map[byte]string{
+	'r': "SELECT",
+	'w': "UPDATE",
+	'a': "INSERT",
+	'd': "DELETE",
+	'D': "TRUNCATE",
+	'x': "REFERENCES",
+	't': "TRIGGER",
+	'm': "MAINTAIN",
+	'X': "EXECUTE",
+	'U': "USAGE",
+	'C': "CREATE",
+	'c': "CONNECT",
+	'T': "TEMPORARY",
+	's': "SET",
+	'A': "ALTER SYSTEM",
+}
+
+// grantTargets maps object kinds reported by pg_identify_object to the keyword
+// introducing them in GRANT and REVOKE
+var grantTargets = map[string]string{
+	"table":             "TABLE",
+	"view":              "TABLE",
+	"materialized view": "TABLE",
+	"foreign table":     "TABLE",
+	"sequence":          "SEQUENCE",
+	"function":          "FUNCTION",
+	"aggregate":         "FUNCTION",
+	"procedure":         "PROCEDURE",
+	"type":              "TYPE",
+	"domain":            "DOMAIN",
+	"schema":            "SCHEMA",
+}
+
+// aclItem is a parsed aclitem: the privileges a grantee holds, with or without grant option
+type aclItem struct {
+	grantee   string // Quoted role name, or PUBLIC
+	privs     []string
+	grantable []string
+}
+
+// parseACLItem parses an aclitem such as `"My Role"=r*w/owner`. An empty grantee is PUBLIC.
+func parseACLItem(item string) (aclItem, error) {
+	grantee, rest, err := splitACLRole(item)
+	if err != nil {
+		return aclItem{}, err
+	}
+	if !strings.HasPrefix(rest, "=") {
+		return aclItem{}, fmt.Errorf("invalid aclitem %q", item)
+	}
+	privs, _, _ := strings.Cut(rest[1:], "/")
+
+	a := aclItem{grantee: "PUBLIC"}
+	if grantee != "" {
+		a.grantee = quoteIdent(grantee)
+	}
+	for i := 0; i < len(privs); i++ {
+		keyword, ok := aclPrivileges[privs[i]]
+		if !ok {
+			return aclItem{}, fmt.Errorf("unknown privilege %q in aclitem %q", privs[i], item)
+		}
+		if i+1 < len(privs) && privs[i+1] == '*' {
+			a.grantable = append(a.grantable, keyword)
+			i++
+		} else {
+			a.privs = append(a.privs, keyword)
+		}
+	}
+	return a, nil
+}
+
+// splitACLRole splits the role name leading an aclitem, unquoting it, from the rest
+func splitACLRole(item string) (role, rest string, err error) {
+	if !strings.HasPrefix(item, `"`) {
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			return "", "", fmt.Errorf("invalid aclitem %q", item)
+		}
+		return item[:i], item[i:], nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(item); i++ {
+		if item[i] != '"' {
+			b.WriteByte(item[i])
+			continue
+		}
+		if i+1 < len(item) && item[i+1] == '"' {
+			b.WriteByte('"')
+			i++
+			continue
+		}
+		return b.String(), item[i+1:], nil
+	}
+	return "", "", fmt.Errorf("unterminated role name in aclitem %q", item)
+}
+
+// aclByGrantee parses aclitems, merging the items of a same grantee
+func aclByGrantee(items []string) (map[string]aclItem, error) {
+	byGrantee := make(map[string]aclItem)
+	for _, item := range items {
+		a, err := parseACLItem(item)
+		if err != nil {
+			return nil, err
+		}
+		merged := byGrantee[a.grantee]
+		merged.grantee = a.grantee
+		merged.privs = append(merged.privs, a.privs...)
+		merged.grantable = append(merged.grantable, a.grantable...)
+		byGrantee[a.grantee] = merged
+	}
+	return byGrantee, nil
+}
+
+// samePrivileges reports whether two parsed aclitems hold the same privileges
+func samePrivileges(a, b aclItem) bool {
+	sameSet := func(x, y []string) bool {
+		x, y = slices.Clone(x), slices.Clone(y)
+		slices.Sort(x)
+		slices.Sort(y)
+		return slices.Equal(x, y)
+	}
+	return sameSet(a.privs, b.privs) && sameSet(a.grantable, b.grantable)
+}
+
+// grantStatements renders the GRANT statements of an aclitem on target
+func (a aclItem) grantStatements(target string) []string {
+	var statements []string
+	if len(a.privs) > 0 {
+		statements = append(statements, fmt.Sprintf("GRANT %s ON %s TO %s;", strings.Join(a.privs, ", "), target, a.grantee))
+	}
+	if len(a.grantable) > 0 {
+		statements = append(statements, fmt.Sprintf("GRANT %s ON %s TO %s WITH GRANT OPTION;", strings.Join(a.grantable, ", "), target, a.grantee))
+	}
+	return statements
+}
+
+// grantStatements returns the REVOKE and GRANT statements turning the default privileges
+// of a new object into its actual ones. Objects still on default privileges need none.
+func (s Security) grantStatements() ([]string, error) {
+	if s.ACL == nil {
+		return nil, nil
+	}
+	keyword, ok := grantTargets[s.Kind]
+	if !ok {
+		return nil, nil
+	}
+	target := keyword + " " + s.Identity
+
+	actual, err := aclByGrantee(s.ACL)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := aclByGrantee(s.DefaultACL)
+	if err != nil {
+		return nil, err
+	}
+
+	grantees := make([]string, 0, len(actual)+len(defaults))
+	for g := range defaults {
+		grantees = append(grantees, g)
+	}
+	for g := range actual {
+		if _, ok := defaults[g]; !ok {
+			grantees = append(grantees, g)
+		}
+	}
+	sort.Strings(grantees)
+
+	var statements []string
+	for _, g := range grantees {
+		a, granted := actual[g]
+		d, isDefault := defaults[g]
+		if granted && isDefault && samePrivileges(a, d) {
+			continue
+		}
+		if isDefault {
+			statements = append(statements, fmt.Sprintf("REVOKE ALL ON %s FROM %s;", target, g))
+		}
+		if granted {
+			statements = append(statements, a.grantStatements(target)...)
+		}
+	}
+	return statements, nil
+}
+
+// defaultACLObjects maps pg_default_acl.defaclobjtype codes to the objects they apply to
+var defaultACLObjects = map[string]string{
+	"r": "TABLES",
+	"S": "SEQUENCES",
+	"f": "FUNCTIONS",
+	"T": "TYPES",
+}
+
+// extractDefaultPrivileges returns the ALTER DEFAULT PRIVILEGES statements applying to
+// objects created in a schema. These add to any database-wide defaults.
+func (e *Extractor) extractDefaultPrivileges(ctx context.Context, schemaName string) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT pg_get_userbyid(d.defaclrole), d.defaclobjtype::text, d.defaclacl::text[]
+		FROM pg_default_acl d
+		JOIN pg_namespace n ON n.oid = d.defaclnamespace
+		WHERE n.nspname = $1
+		ORDER BY 1, 2`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var (
+			role, objType string
+			acl           []string
+		)
+		if err := rows.Scan(&role, &objType, pq.Array(&acl)); err != nil {
+			return nil, err
+		}
+		objects, ok := defaultACLObjects[objType]
+		if !ok {
+			continue
+		}
+		prefix := fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s ", quoteIdent(role), quoteIdent(schemaName))
+		for _, item := range acl {
+			a, err := parseACLItem(item)
+			if err != nil {
+				return nil, err
+			}
+			for _, grant := range a.grantStatements(objects) {
+				statements = append(statements, prefix+grant)
+			}
+		}
+	}
+	return statements, rows.Err()
+}
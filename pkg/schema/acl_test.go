@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseACLItem(t *testing.T) {
+	tests := []struct {
+		item string
+		want aclItem
+		err  bool
+	}{
+		{item: "alice=arwdDxtm/alice", want: aclItem{grantee: "alice", privs: []string{"INSERT", "SELECT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER", "MAINTAIN"}}},
+		{item: "=U/owner", want: aclItem{grantee: "PUBLIC", privs: []string{"USAGE"}}},
+		{item: "bob=r*w/alice", want: aclItem{grantee: "bob", privs: []string{"UPDATE"}, grantable: []string{"SELECT"}}},
+		{item: `"My Role"=X/alice`, want: aclItem{grantee: `"My Role"`, privs: []string{"EXECUTE"}}},
+		{item: `"say ""hi"""=U/alice`, want: aclItem{grantee: `"say ""hi"""`, privs: []string{"USAGE"}}},
+		{item: "Admin=C/alice", want: aclItem{grantee: `"Admin"`, privs: []string{"CREATE"}}},
+		{item: "alice", err: true},
+		{item: `"unterminated=r/alice`, err: true},
+		{item: "alice=q/alice", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.item, func(t *testing.T) {
+			got, err := parseACLItem(tt.item)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("parseACLItem(%q) = %+v, want an error", tt.item, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseACLItem(%q): %v", tt.item, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseACLItem(%q) = %+v, want %+v", tt.item, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityGrantStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sec  Security
+		want []string
+	}{
+		{
+			name: "default privileges",
+			sec:  Security{Kind: "sequence", Identity: "app.s", DefaultACL: []string{"alice=rwU/alice"}},
+		},
+		{
+			name: "unchanged privileges",
+			sec:  Security{Kind: "sequence", Identity: "app.s", ACL: []string{"alice=rwU/alice"}, DefaultACL: []string{"alice=rwU/alice"}},
+		},
+		{
+			name: "sequence granted to PUBLIC and a role",
+			sec: Security{Kind: "sequence", Identity: "app.s",
+				ACL:        []string{"alice=rwU/alice", "=U/alice", "bob=r*/alice"},
+				DefaultACL: []string{"alice=rwU/alice"}},
+			want: []string{
+				"GRANT USAGE ON SEQUENCE app.s TO PUBLIC;",
+				"GRANT SELECT ON SEQUENCE app.s TO bob WITH GRANT OPTION;",
+			},
+		},
+		{
+			name: "type revoked from PUBLIC",
+			sec: Security{Kind: "type", Identity: "app.mood",
+				ACL:        []string{"alice=U/alice"},
+				DefaultACL: []string{"=U/alice", "alice=U/alice"}},
+			want: []string{"REVOKE ALL ON TYPE app.mood FROM PUBLIC;"},
+		},
+		{
+			name: "owner privileges reduced",
+			sec: Security{Kind: "table", Identity: "app.t",
+				ACL:        []string{"alice=r/alice"},
+				DefaultACL: []string{"alice=arwdDxtm/alice"}},
+			want: []string{"REVOKE ALL ON TABLE app.t FROM alice;", "GRANT SELECT ON TABLE app.t TO alice;"},
+		},
+		{
+			name: "kind without GRANT",
+			sec:  Security{Kind: "operator family", Identity: "app.f USING btree", ACL: []string{"alice=U/alice"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sec.grantStatements()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("grantStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
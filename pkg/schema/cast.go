@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// cast is a row of pg_cast listed for extraction
+type cast struct {
+	oid       uint32
+	name      string // <source>_to_<target>, by type name
+	source    string
+	target    string
+	method    string
+	context   string
+	function  string // Qualified function with its argument types, for method "f"
+	extension string
+}
+
+// castMethods maps pg_cast.castmethod codes to the clause creating the cast
+var castMethods = map[string]string{
+	"i": "WITH INOUT",
+	"b": "WITHOUT FUNCTION",
+}
+
+// castContexts maps pg_cast.castcontext codes to the clause allowing implicit use
+var castContexts = map[string]string{
+	"a": " AS ASSIGNMENT",
+	"i": " AS IMPLICIT",
+}
+
+// extractCasts extracts the casts created by users. Casts belong to no schema, so each is
+// extracted with the schema of its source type, else of its target type, else of its
+// function, the first one not built in.
+func (e *Extractor) extractCasts(ctx context.Context, schemaName string) ([]Object, error) {
+	// Casts from initdb have OIDs below FirstNormalObjectId
+	rows, err := e.db.QueryContext(ctx, `SELECT c.oid, st.typname || '_to_' || tt.typname,
+			format_type(c.castsource, NULL), format_type(c.casttarget, NULL),
+			c.castmethod::text, c.castcontext::text,
+			COALESCE(quote_ident(pn.nspname) || '.' || quote_ident(p.proname) || '(' || oidvectortypes(p.proargtypes) || ')', ''),
+			COALESCE((SELECT x.extname FROM pg_depend d JOIN pg_extension x ON x.oid = d.refobjid
+				WHERE d.classid = 'pg_cast'::regclass AND d.objid = c.oid
+					AND d.refclassid = 'pg_extension'::regclass AND d.deptype = 'e'), '')
+		FROM pg_cast c
+		JOIN pg_type st ON st.oid = c.castsource
+		JOIN pg_namespace sn ON sn.oid = st.typnamespace
+		JOIN pg_type tt ON tt.oid = c.casttarget
+		JOIN pg_namespace tn ON tn.oid = tt.typnamespace
+		LEFT JOIN pg_proc p ON p.oid = c.castfunc
+		LEFT JOIN pg_namespace pn ON pn.oid = p.pronamespace
+		WHERE c.oid >= 16384 AND $1 = CASE
+			WHEN sn.nspname NOT IN ('pg_catalog', 'information_schema') THEN sn.nspname
+			WHEN tn.nspname NOT IN ('pg_catalog', 'information_schema') THEN tn.nspname
+			ELSE pn.nspname END
+		ORDER BY st.typname, tt.typname`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing casts: %w", err)
+	}
+
+	var casts []cast
+	for rows.Next() {
+		var c cast
+		if err := rows.Scan(&c.oid, &c.name, &c.source, &c.target, &c.method, &c.context, &c.function, &c.extension); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading cast: %w", err)
+		}
+		casts = append(casts, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing casts: %w", err)
+	}
+
+	var included []cast
+	for _, c := range casts {
+		if e.decide(Candidate{Schema: schemaName, Name: c.name, Type: CastType, Extension: c.extension}) {
+			included = append(included, c)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, c cast) (Object, error) {
+		method, ok := castMethods[c.method]
+		if !ok {
+			method = "WITH FUNCTION " + c.function
+		}
+
+		// Casts have no owner nor privileges; their OID is known from the listing
+		obj := Object{
+			Schema:     schemaName,
+			Name:       c.name,
+			Type:       CastType,
+			Definition: fmt.Sprintf("CREATE CAST (%s AS %s)\n    %s%s", c.source, c.target, method, castContexts[c.context]),
+			OID:        c.oid,
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// castSignature returns the "(source AS target)" of a cast definition
+func castSignature(definition string) string {
+	line, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(definition), "CREATE CAST "), "\n")
+	return line
+}
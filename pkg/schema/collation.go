@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// collation holds the columns of a pg_collation row. The row is read as JSON since its
+// locale columns vary across server versions.
+type collation struct {
+	OID           uint32  `json:"oid"`
+	Name          string  `json:"collname"`
+	Provider      string  `json:"collprovider"`
+	Deterministic *bool   `json:"collisdeterministic"` // Absent before PostgreSQL 12
+	Collate       *string `json:"collcollate"`
+	Ctype         *string `json:"collctype"`
+	ICULocale     *string `json:"colliculocale"` // PostgreSQL 15 and 16
+	Locale        *string `json:"colllocale"`    // PostgreSQL 17 and later
+	ICURules      *string `json:"collicurules"`  // PostgreSQL 16 and later
+}
+
+// collationProviders maps pg_collation.collprovider codes to provider names
+var collationProviders = map[string]string{
+	"c": "libc",
+	"i": "icu",
+	"b": "builtin",
+}
+
+// extractCollations extracts collations as CREATE COLLATION statements
+func (e *Extractor) extractCollations(ctx context.Context, schemaName string) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT to_jsonb(c)
+		FROM pg_collation c
+		JOIN pg_namespace n ON n.oid = c.collnamespace
+		WHERE n.nspname = $1
+		ORDER BY c.collname`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing collations: %w", err)
+	}
+
+	var collations []collation
+	for rows.Next() {
+		var (
+			row []byte
+			c   collation
+		)
+		if err := rows.Scan(&row); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading collation: %w", err)
+		}
+		if err := json.Unmarshal(row, &c); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading collation: %w", err)
+		}
+		collations = append(collations, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing collations: %w", err)
+	}
+
+	var included []collation
+	for _, c := range collations {
+		if e.decide(Candidate{Schema: schemaName, Name: c.Name, Type: CollationType,
+			Extension: e.extensionOf("pg_collation", schemaName, c.Name)}) {
+			included = append(included, c)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, c collation) (Object, error) {
+		obj := Object{
+			Schema:     schemaName,
+			Name:       c.Name,
+			Type:       CollationType,
+			Definition: c.definition(qualify(schemaName, c.Name)),
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(c.OID)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// definition renders the CREATE COLLATION statement, leaving out options at their default
+func (c collation) definition(qualified string) string {
+	var options []string
+	provider := collationProviders[c.Provider]
+	if provider != "libc" {
+		options = append(options, "provider = "+provider)
+	}
+
+	locale := c.Locale
+	if locale == nil {
+		locale = c.ICULocale
+	}
+	switch {
+	case locale != nil:
+		options = append(options, "locale = "+pq.QuoteLiteral(*locale))
+	case c.Collate != nil && c.Ctype != nil && *c.Collate == *c.Ctype:
+		options = append(options, "locale = "+pq.QuoteLiteral(*c.Collate))
+	default:
+		if c.Collate != nil {
+			options = append(options, "lc_collate = "+pq.QuoteLiteral(*c.Collate))
+		}
+		if c.Ctype != nil {
+			options = append(options, "lc_ctype = "+pq.QuoteLiteral(*c.Ctype))
+		}
+	}
+
+	if c.ICURules != nil && *c.ICURules != "" {
+		options = append(options, "rules = "+pq.QuoteLiteral(*c.ICURules))
+	}
+	if c.Deterministic != nil && !*c.Deterministic {
+		options = append(options, "deterministic = false")
+	}
+	return fmt.Sprintf("CREATE COLLATION %s (%s)", qualified, strings.Join(options, ", "))
+}
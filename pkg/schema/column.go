@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+)
+
+// Column describes a table column as rows loaded into the table see it
+type Column struct {
+	Name      string // Quoted name
+	Generated bool   // Computed from other columns, GENERATED ALWAYS AS; it takes no value
+	Identity  string // ALWAYS or BY DEFAULT for identity columns, empty otherwise
+}
+
+// TableColumns returns the columns of a table, in order, with the generation and identity
+// details read for its CREATE TABLE statement, see tableDefinition
+func (e *Extractor) TableColumns(ctx context.Context, schemaName, table string) ([]Column, error) {
+	var oid uint32
+	err := e.db.QueryRowContext(ctx, `SELECT c.oid
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('r', 'p', 'f')`, schemaName, table).Scan(&oid)
+	if err != nil {
+		return nil, fmt.Errorf("table %s not found: %w", qualify(schemaName, table), err)
+	}
+
+	columns, err := e.tableColumns(ctx, oid)
+	if err != nil {
+		return nil, fmt.Errorf("error reading columns of %s: %w", qualify(schemaName, table), err)
+	}
+	described := make([]Column, len(columns))
+	for i, c := range columns {
+		described[i] = Column{Name: c.name, Generated: generatedKinds[c.generated] != "", Identity: identityKinds[c.identity]}
+	}
+	return described, nil
+}
+
+// InsertColumns returns the columns an INSERT of whole rows lists, and the OVERRIDING
+// clause it needs. Generated columns are left out, as they cannot be inserted into. The
+// values of identity columns are kept only when keepIdentity is set, GENERATED ALWAYS ones
+// then needing OVERRIDING SYSTEM VALUE; otherwise GENERATED ALWAYS identity columns are
+// left out to get new values.
+func InsertColumns(columns []Column, keepIdentity bool) (names []string, overriding string) {
+	for _, c := range columns {
+		switch {
+		case c.Generated:
+			continue
+		case c.Identity == "ALWAYS" && !keepIdentity:
+			continue
+		case c.Identity == "ALWAYS":
+			overriding = "OVERRIDING SYSTEM VALUE"
+		}
+		names = append(names, c.Name)
+	}
+	return names, overriding
+}
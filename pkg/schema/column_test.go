@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestInsertColumns(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Identity: "ALWAYS"},
+		{Name: "ref", Identity: "BY DEFAULT"},
+		{Name: "price"},
+		{Name: "qty"},
+		{Name: "total", Generated: true},
+	}
+	tests := []struct {
+		name           string
+		keepIdentity   bool
+		wantNames      []string
+		wantOverriding string
+	}{
+		{name: "new identity values", wantNames: []string{"ref", "price", "qty"}},
+		{name: "kept identity values", keepIdentity: true, wantNames: []string{"id", "ref", "price", "qty"},
+			wantOverriding: "OVERRIDING SYSTEM VALUE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, overriding := InsertColumns(columns, tt.keepIdentity)
+			if !slices.Equal(names, tt.wantNames) || overriding != tt.wantOverriding {
+				t.Errorf("InsertColumns() = %v, %q, want %v, %q", names, overriding, tt.wantNames, tt.wantOverriding)
+			}
+		})
+	}
+
+	// BY DEFAULT identity columns take values without overriding
+	if _, overriding := InsertColumns(columns[1:], true); overriding != "" {
+		t.Errorf("InsertColumns(BY DEFAULT identity) overriding = %q, want none", overriding)
+	}
+}
+
+func TestTableColumns(t *testing.T) {
+	db, config := testSchema(t, "pgsac_columns",
+		`CREATE TABLE pgsac_columns."Orders" (
+			id integer GENERATED ALWAYS AS IDENTITY,
+			ref bigint GENERATED BY DEFAULT AS IDENTITY,
+			price numeric,
+			qty integer,
+			total numeric GENERATED ALWAYS AS (price * qty) STORED
+		)`)
+
+	columns, err := NewExtractor(db, config, Options{}).TableColumns(context.Background(), "pgsac_columns", "Orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Column{
+		{Name: "id", Identity: "ALWAYS"},
+		{Name: "ref", Identity: "BY DEFAULT"},
+		{Name: "price"},
+		{Name: "qty"},
+		{Name: "total", Generated: true},
+	}
+	if !slices.Equal(columns, want) {
+		t.Errorf("TableColumns() = %+v, want %+v", columns, want)
+	}
+}
@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// tableComments returns the COMMENT ON statements for a table and its columns.
+// Comments are quoted as SQL literals, so quotes and backslashes survive the round-trip.
+func (e *Extractor) tableComments(ctx context.Context, qualified string, oid uint32) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT '', obj_description($1::oid, 'pg_class')
+		WHERE obj_description($1::oid, 'pg_class') IS NOT NULL
+		UNION ALL
+		SELECT * FROM (
+			SELECT quote_ident(a.attname), col_description(a.attrelid, a.attnum)
+			FROM pg_attribute a
+			WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+				AND col_description(a.attrelid, a.attnum) IS NOT NULL
+			ORDER BY a.attnum
+		) c`, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var column, comment string
+		if err := rows.Scan(&column, &comment); err != nil {
+			return nil, err
+		}
+		if column == "" {
+			statements = append(statements, fmt.Sprintf("COMMENT ON TABLE %s IS %s", qualified, pq.QuoteLiteral(comment)))
+		} else {
+			statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", qualified, column, pq.QuoteLiteral(comment)))
+		}
+	}
+	return statements, rows.Err()
+}
+
+// extractComments appends the COMMENT ON statements of an object, and of its columns for
+// views, to its definition. The statements name the object as pg_identify_object does,
+// e.g. "COMMENT ON FUNCTION public.f(integer)", so overloads get their own comments. The
+// object's OID must have been resolved by extractSecurity. Tables and foreign tables keep
+// their comments with tableComments, and object types without a registered catalog are
+// left untouched.
+func (e *Extractor) extractComments(ctx context.Context, obj *Object) error {
+	cat, ok := objectCatalogs[obj.Type]
+	if !ok || obj.OID == 0 || obj.Type == TableType || obj.Type == ForeignTableType {
+		return nil
+	}
+
+	rows, err := e.db.QueryContext(ctx, `SELECT d.objsubid > 0, upper(i.type), i.identity, d.description
+		FROM pg_description d, pg_identify_object(d.classoid, d.objoid, d.objsubid) i
+		WHERE d.classoid = $1::regclass AND d.objoid = $2
+		ORDER BY d.objsubid`, cat.catalog, obj.OID)
+	if err != nil {
+		return fmt.Errorf("error getting comments of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var column bool
+		var kind, identity, comment string
+		if err := rows.Scan(&column, &kind, &identity, &comment); err != nil {
+			return fmt.Errorf("error reading comments of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+		}
+		if column {
+			kind = "COLUMN"
+		}
+		statements = append(statements, fmt.Sprintf("COMMENT ON %s %s IS %s", kind, identity, pq.QuoteLiteral(comment)))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading comments of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+	}
+	for _, stmt := range statements {
+		obj.Definition = strings.TrimSuffix(strings.TrimSpace(obj.Definition), ";") + ";\n\n" + stmt
+	}
+	return nil
+}
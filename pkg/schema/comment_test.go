@@ -0,0 +1,18 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAccentedCommentRoundTrip(t *testing.T) {
+	comment := "Données clés – été 🌞"
+	db, config := testSchema(t, "pgsac_comments",
+		`CREATE TABLE pgsac_comments.café (id integer)`,
+		`COMMENT ON TABLE pgsac_comments.café IS '`+comment+`'`)
+
+	obj := extractTestObject(t, db, config, Options{}, "pgsac_comments", "café")
+	if want := "COMMENT ON TABLE pgsac_comments.\"café\" IS '" + comment + "'"; !strings.Contains(obj.Definition, want) {
+		t.Errorf("Definition =\n%s\nwant it to hold\n%s", obj.Definition, want)
+	}
+}
@@ -0,0 +1,147 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// tableConstraint is a row of pg_constraint on a table
+type tableConstraint struct {
+	oid        uint32
+	name       string
+	table      string
+	definition string
+	inherited  bool // Copied from a parent table rather than declared locally
+	partition  bool // Cloned from the constraint of a partitioned parent
+
+	// Foreign keys only: the referenced table, the unique index enforcing the referenced
+	// key and the constraint creating that index, if any
+	refSchema, refTable, refIndex, refConstraint string
+}
+
+// listConstraints lists the table constraints of a schema having one of the given contypes
+func (e *Extractor) listConstraints(ctx context.Context, schemaName string, contypes ...string) ([]tableConstraint, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT c.oid, c.conname, t.relname, pg_get_constraintdef(c.oid, true),
+			NOT c.conislocal, c.conparentid <> 0,
+			COALESCE(rn.nspname, ''), COALESCE(r.relname, ''), COALESCE(ri.relname, ''), COALESCE(rk.conname, '')
+		FROM pg_constraint c
+		JOIN pg_class t ON t.oid = c.conrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		LEFT JOIN pg_class r ON c.contype = 'f' AND r.oid = c.confrelid
+		LEFT JOIN pg_namespace rn ON rn.oid = r.relnamespace
+		LEFT JOIN pg_class ri ON c.contype = 'f' AND ri.oid = c.conindid
+		LEFT JOIN pg_constraint rk ON c.contype = 'f' AND rk.conrelid = c.confrelid AND rk.conindid = c.conindid
+			AND rk.contype IN ('p', 'u')
+		WHERE n.nspname = $1 AND c.contype::text = ANY($2)
+		ORDER BY t.relname, c.conname`, schemaName, pq.Array(contypes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constraints []tableConstraint
+	for rows.Next() {
+		var c tableConstraint
+		if err := rows.Scan(&c.oid, &c.name, &c.table, &c.definition, &c.inherited, &c.partition,
+			&c.refSchema, &c.refTable, &c.refIndex, &c.refConstraint); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, rows.Err()
+}
+
+// kind returns the filter sub-kind of a constraint created along with another object
+func (c tableConstraint) kind() string {
+	switch {
+	case c.partition:
+		return "partition"
+	case c.inherited:
+		return "inherited"
+	}
+	return ""
+}
+
+// objectName returns the name of the object of a constraint, prefixed with its table since
+// constraint names are only unique per table
+func (c tableConstraint) objectName() string {
+	return c.table + "_" + c.name
+}
+
+// alterStatement renders the ALTER TABLE ... ADD CONSTRAINT statement
+func (c tableConstraint) alterStatement(schemaName string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", qualify(schemaName, c.table), quoteIdent(c.name), c.definition)
+}
+
+// extractConstraints extracts primary key, unique, check and exclusion constraints as
+// ALTER TABLE statements applied once their table exists
+func (e *Extractor) extractConstraints(ctx context.Context, schemaName string) ([]Object, error) {
+	constraints, err := e.listConstraints(ctx, schemaName, "p", "u", "c", "x")
+	if err != nil {
+		return nil, fmt.Errorf("error listing constraints: %w", err)
+	}
+
+	var included []tableConstraint
+	for _, c := range constraints {
+		if e.decide(Candidate{Schema: schemaName, Name: c.objectName(), Type: ConstraintType, Kind: c.kind(),
+			Extension: e.extensionOf("pg_class", schemaName, c.table)}) {
+			included = append(included, c)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, c tableConstraint) (Object, error) {
+		obj := Object{
+			Schema:     schemaName,
+			Name:       c.objectName(),
+			Type:       ConstraintType,
+			Definition: c.alterStatement(schemaName),
+			Depends:    []string{schemaName + "." + c.table},
+			OID:        c.oid,
+		}
+		// Check expressions may call functions
+		if err := e.addDepends(ctx, &obj, "pg_constraint"); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// extractForeignKeys extracts foreign keys as ALTER TABLE statements, applied once both
+// their table and the referenced key exist
+func (e *Extractor) extractForeignKeys(ctx context.Context, schemaName string) ([]Object, error) {
+	constraints, err := e.listConstraints(ctx, schemaName, "f")
+	if err != nil {
+		return nil, fmt.Errorf("error listing foreign keys: %w", err)
+	}
+
+	var objects []Object
+	for _, c := range constraints {
+		if !e.decide(Candidate{Schema: schemaName, Name: c.objectName(), Type: ForeignKeyType, Kind: c.kind(),
+			Extension: e.extensionOf("pg_class", schemaName, c.table)}) {
+			continue
+		}
+
+		deps := newDependencySet()
+		deps.add(schemaName, c.table)
+		deps.add(c.refSchema, c.refTable)
+		// The referenced key is created by its constraint, else by a unique index
+		if c.refConstraint != "" {
+			deps.add(c.refSchema, c.refTable+"_"+c.refConstraint)
+		} else {
+			deps.add(c.refSchema, c.refIndex)
+		}
+
+		objects = append(objects, Object{
+			Schema:     schemaName,
+			Name:       c.objectName(),
+			Type:       ForeignKeyType,
+			Definition: c.alterStatement(schemaName),
+			Depends:    deps.list(),
+			OID:        c.oid,
+		})
+	}
+
+	return objects, nil
+}
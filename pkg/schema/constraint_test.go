@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestConstraintDropStatement(t *testing.T) {
+	obj := Object{
+		Schema:  "app",
+		Name:    "orders_positive",
+		Type:    ConstraintType,
+		Depends: []string{"app.orders"},
+	}
+	want := "ALTER TABLE IF EXISTS app.orders DROP CONSTRAINT IF EXISTS positive;"
+	if got := obj.DropStatement(false); got != want {
+		t.Errorf("DropStatement() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractConstraintsSharingName(t *testing.T) {
+	db, config := testSchema(t, "pgsac_test_constraints",
+		`CREATE TABLE pgsac_test_constraints.customers (id int CONSTRAINT customers_pkey PRIMARY KEY)`,
+		`CREATE TABLE pgsac_test_constraints.orders (total int CONSTRAINT positive CHECK (total > 0),
+			customer int CONSTRAINT customer_fk REFERENCES pgsac_test_constraints.customers)`,
+		`CREATE TABLE pgsac_test_constraints.refunds (total int CONSTRAINT positive CHECK (total > 0),
+			customer int CONSTRAINT customer_fk REFERENCES pgsac_test_constraints.customers)`)
+
+	schemas, err := NewExtractor(db, config, Options{}).ExtractSchemas(context.Background(), []string{"pgsac_test_constraints"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drops := make(map[string]string)
+	for _, obj := range schemas[0].Objects {
+		if obj.Type == ConstraintType || obj.Type == ForeignKeyType {
+			drops[obj.Name] = obj.DropStatement(false)
+		}
+		// Foreign keys depend on the constraint creating the referenced key
+		if obj.Type == ForeignKeyType && !slices.Contains(obj.Depends, "pgsac_test_constraints.customers_customers_pkey") {
+			t.Errorf("foreign key %s depends on %v, want the primary key of customers", obj.Name, obj.Depends)
+		}
+	}
+	want := map[string]string{
+		"customers_customers_pkey": "ALTER TABLE IF EXISTS pgsac_test_constraints.customers DROP CONSTRAINT IF EXISTS customers_pkey;",
+		"orders_positive":          "ALTER TABLE IF EXISTS pgsac_test_constraints.orders DROP CONSTRAINT IF EXISTS positive;",
+		"refunds_positive":         "ALTER TABLE IF EXISTS pgsac_test_constraints.refunds DROP CONSTRAINT IF EXISTS positive;",
+		"orders_customer_fk":       "ALTER TABLE IF EXISTS pgsac_test_constraints.orders DROP CONSTRAINT IF EXISTS customer_fk;",
+		"refunds_customer_fk":      "ALTER TABLE IF EXISTS pgsac_test_constraints.refunds DROP CONSTRAINT IF EXISTS customer_fk;",
+	}
+	if len(drops) != len(want) {
+		t.Fatalf("constraints = %v, want %v", drops, want)
+	}
+	for name, drop := range want {
+		if drops[name] != drop {
+			t.Errorf("constraint %s drop = %q, want %q", name, drops[name], drop)
+		}
+	}
+
+	obj := extractTestObject(t, db, config, Options{}, "pgsac_test_constraints", "refunds_positive")
+	if want := "ALTER TABLE pgsac_test_constraints.refunds ADD CONSTRAINT positive CHECK (total > 0)"; obj.Definition != want {
+		t.Errorf("Definition = %q, want %q", obj.Definition, want)
+	}
+	fk := extractTestObject(t, db, config, Options{}, "pgsac_test_constraints", "refunds_customer_fk")
+	if want := "ALTER TABLE pgsac_test_constraints.refunds ADD CONSTRAINT customer_fk FOREIGN KEY (customer) REFERENCES pgsac_test_constraints.customers(id)"; fk.Definition != want {
+		t.Errorf("Definition = %q, want %q", fk.Definition, want)
+	}
+}
@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/database"
+)
+
+// testDatabase connects to the database at PGSAC_TEST_DATABASE_URL, e.g.
+// postgres://postgres@localhost/pgsac_test, skipping the test when it is not set
+func testDatabase(t *testing.T) (*sql.DB, database.Config) {
+	t.Helper()
+	url := os.Getenv("PGSAC_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("PGSAC_TEST_DATABASE_URL is not set")
+	}
+	config, err := database.ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.ApplyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Port == 0 {
+		config.Port = 5432
+	}
+	db, err := database.Connect(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, config
+}
+
+// testSchema connects to the test database and creates schemaName from scratch, running
+// statements in it; the schema is dropped when the test ends
+func testSchema(t *testing.T, schemaName string, statements ...string) (*sql.DB, database.Config) {
+	t.Helper()
+	db, config := testDatabase(t)
+	drop := fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", quoteIdent(schemaName))
+	for _, stmt := range append([]string{drop, "CREATE SCHEMA " + quoteIdent(schemaName)}, statements...) {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	t.Cleanup(func() { db.Exec(drop) })
+	return db, config
+}
+
+// extractTestObject extracts a single object of the test schema with the given options
+func extractTestObject(t *testing.T, db *sql.DB, config database.Config, opts Options, schemaName, name string) Object {
+	t.Helper()
+	obj, err := NewExtractor(db, config, opts).ExtractObject(context.Background(), schemaName, name)
+	if err != nil {
+		t.Fatalf("ExtractObject(%s.%s) error = %v", schemaName, name, err)
+	}
+	return obj
+}
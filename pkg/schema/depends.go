@@ -0,0 +1,144 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+)
+
+// dependencySet collects the qualified names an object depends on, in first-seen order
+type dependencySet struct {
+	seen  map[string]bool
+	names []string
+}
+
+func newDependencySet() *dependencySet {
+	return &dependencySet{seen: make(map[string]bool)}
+}
+
+// add records a dependency on schema.name. Built-in objects from system schemas are ignored.
+func (d *dependencySet) add(schemaName, name string) {
+	if schemaName == "pg_catalog" || schemaName == "information_schema" {
+		return
+	}
+	d.addQualified(schemaName + "." + name)
+}
+
+// addQualified records a dependency on an already qualified name
+func (d *dependencySet) addQualified(qualified string) {
+	if d.seen[qualified] {
+		return
+	}
+	d.seen[qualified] = true
+	d.names = append(d.names, qualified)
+}
+
+// list returns the recorded dependencies
+func (d *dependencySet) list() []string {
+	return d.names
+}
+
+// dependsQuery lists the relations, functions, types, collations, text search objects and
+// operators referenced by an object, as recorded
+// in pg_depend for the object itself, for the _RETURN rule of a view and for column defaults;
+// other rules are objects of their own.
+// Array types resolve to their element type, and row types share their relation's name.
+const dependsQuery = `WITH refs AS (
+		SELECT d.refclassid, d.refobjid
+		FROM pg_depend d
+		WHERE d.classid = $1::text::regclass AND d.objid = $2 AND d.deptype = 'n'
+		UNION
+		SELECT d.refclassid, d.refobjid
+		FROM pg_rewrite r
+		JOIN pg_depend d ON d.classid = 'pg_rewrite'::regclass AND d.objid = r.oid AND d.deptype = 'n'
+		WHERE $1::text = 'pg_class' AND r.ev_class = $2 AND r.rulename = '_RETURN'
+		UNION
+		SELECT d.refclassid, d.refobjid
+		FROM pg_attrdef ad
+		JOIN pg_depend d ON d.classid = 'pg_attrdef'::regclass AND d.objid = ad.oid AND d.deptype = 'n'
+		WHERE $1::text = 'pg_class' AND ad.adrelid = $2
+	)
+	SELECT n.nspname, c.relname
+	FROM refs
+	JOIN pg_class c ON c.oid = refs.refobjid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	WHERE refs.refclassid = 'pg_class'::regclass
+	UNION
+	SELECT n.nspname, p.proname
+	FROM refs
+	JOIN pg_proc p ON p.oid = refs.refobjid
+	JOIN pg_namespace n ON n.oid = p.pronamespace
+	WHERE refs.refclassid = 'pg_proc'::regclass
+	UNION
+	SELECT n.nspname, t.typname
+	FROM refs
+	JOIN pg_type rt ON rt.oid = refs.refobjid
+	JOIN pg_type t ON t.oid = CASE WHEN rt.typcategory = 'A' THEN rt.typelem ELSE rt.oid END
+	JOIN pg_namespace n ON n.oid = t.typnamespace
+	WHERE refs.refclassid = 'pg_type'::regclass
+	UNION
+	SELECT n.nspname, co.collname
+	FROM refs
+	JOIN pg_collation co ON co.oid = refs.refobjid
+	JOIN pg_namespace n ON n.oid = co.collnamespace
+	WHERE refs.refclassid = 'pg_collation'::regclass
+	UNION
+	SELECT n.nspname, cfg.cfgname
+	FROM refs
+	JOIN pg_ts_config cfg ON cfg.oid = refs.refobjid
+	JOIN pg_namespace n ON n.oid = cfg.cfgnamespace
+	WHERE refs.refclassid = 'pg_ts_config'::regclass
+	UNION
+	SELECT n.nspname, dict.dictname
+	FROM refs
+	JOIN pg_ts_dict dict ON dict.oid = refs.refobjid
+	JOIN pg_namespace n ON n.oid = dict.dictnamespace
+	WHERE refs.refclassid = 'pg_ts_dict'::regclass
+	UNION
+	SELECT n.nspname, o.oprname
+	FROM refs
+	JOIN pg_operator o ON o.oid = refs.refobjid
+	JOIN pg_namespace n ON n.oid = o.oprnamespace
+	WHERE refs.refclassid = 'pg_operator'::regclass
+	ORDER BY 1, 2`
+
+// extractDepends adds the objects referenced by obj to its Depends. Column defaults are
+// resolved through pg_depend too, so a default calling my_schema.gen_id() depends on that
+// function without any string matching. The object's OID must already be resolved.
+func (e *Extractor) extractDepends(ctx context.Context, obj *Object) error {
+	cat, ok := objectCatalogs[obj.Type]
+	if !ok || obj.OID == 0 {
+		return nil
+	}
+	return e.addDepends(ctx, obj, cat.catalog)
+}
+
+// addDepends adds the objects referenced by obj, found in the given catalog, to its Depends
+func (e *Extractor) addDepends(ctx context.Context, obj *Object, catalog string) error {
+	rows, err := e.db.QueryContext(ctx, dependsQuery, catalog, obj.OID)
+	if err != nil {
+		return fmt.Errorf("error getting dependencies of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+	}
+	defer rows.Close()
+
+	deps := newDependencySet()
+	for _, dep := range obj.Depends {
+		deps.addQualified(dep)
+	}
+	for rows.Next() {
+		var schemaName, name string
+		if err := rows.Scan(&schemaName, &name); err != nil {
+			return fmt.Errorf("error reading dependency of %s.%s: %w", obj.Schema, obj.Name, err)
+		}
+		// An object never depends on itself, e.g. a table on its own row type
+		if schemaName == obj.Schema && name == obj.Name {
+			continue
+		}
+		deps.add(schemaName, name)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error getting dependencies of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+	}
+
+	obj.Depends = deps.list()
+	return nil
+}
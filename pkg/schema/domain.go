@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// domain holds the properties of a domain
+type domain struct {
+	oid        uint32
+	name       string
+	baseType   string
+	collation  string
+	defaultVal string
+	notNull    bool
+	baseSchema string
+	baseName   string
+}
+
+func (e *Extractor) extractDomains(ctx context.Context, schemaName string) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT t.oid, t.typname, format_type(t.typbasetype, t.typtypmod),
+			CASE WHEN t.typcollation <> 0 AND t.typcollation <> bt.typcollation
+				THEN quote_ident(cn.nspname) || '.' || quote_ident(co.collname) ELSE '' END,
+			COALESCE(t.typdefault, ''), t.typnotnull, btn.nspname, bt.typname
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_type bt ON bt.oid = t.typbasetype
+		JOIN pg_namespace btn ON btn.oid = bt.typnamespace
+		LEFT JOIN pg_collation co ON co.oid = t.typcollation
+		LEFT JOIN pg_namespace cn ON cn.oid = co.collnamespace
+		WHERE n.nspname = $1 AND t.typtype = 'd'
+		ORDER BY t.typname`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing domains: %w", err)
+	}
+
+	var domains []domain
+	for rows.Next() {
+		var d domain
+		if err := rows.Scan(&d.oid, &d.name, &d.baseType, &d.collation, &d.defaultVal, &d.notNull, &d.baseSchema, &d.baseName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing domains: %w", err)
+	}
+
+	var included []domain
+	for _, d := range domains {
+		if e.decide(Candidate{Schema: schemaName, Name: d.name, Type: DomainType, Extension: e.extensionOf("pg_type", schemaName, d.name)}) {
+			included = append(included, d)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, d domain) (Object, error) {
+		qualified := qualify(schemaName, d.name)
+		definition, err := e.domainDefinition(ctx, qualified, d)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting domain definition for %s: %w", d.name, err)
+		}
+
+		deps := newDependencySet()
+		deps.add(d.baseSchema, d.baseName)
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       d.name,
+			Type:       DomainType,
+			Definition: definition,
+			Depends:    deps.list(),
+		}
+		if err := e.extractSecurity(ctx, &obj, qualified); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// domainDefinition renders CREATE DOMAIN with every named check constraint
+func (e *Extractor) domainDefinition(ctx context.Context, qualified string, d domain) (string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT quote_ident(conname), pg_get_constraintdef(oid)
+		FROM pg_constraint
+		WHERE contypid = $1 AND contype = 'c'
+		ORDER BY conname`, d.oid)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	clauses := []string{fmt.Sprintf("CREATE DOMAIN %s AS %s", qualified, d.baseType)}
+	if d.collation != "" {
+		clauses = append(clauses, "COLLATE "+d.collation)
+	}
+	if d.defaultVal != "" {
+		clauses = append(clauses, "DEFAULT "+d.defaultVal)
+	}
+	if d.notNull {
+		clauses = append(clauses, "NOT NULL")
+	}
+
+	for rows.Next() {
+		var name, def string
+		if err := rows.Scan(&name, &def); err != nil {
+			return "", err
+		}
+		clauses = append(clauses, fmt.Sprintf("CONSTRAINT %s %s", name, def))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(clauses, "\n    "), nil
+}
@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dropKeywords maps object types dropped with a DROP statement to their keyword
+var dropKeywords = map[ObjectType]string{
+	TableType:          "TABLE",
+	ForeignTableType:   "FOREIGN TABLE",
+	ViewType:           "VIEW",
+	MaterializedView:   "MATERIALIZED VIEW",
+	FunctionType:       "FUNCTION",
+	SequenceType:       "SEQUENCE",
+	IndexType:          "INDEX",
+	TypeType:           "TYPE",
+	DomainType:         "DOMAIN",
+	OperatorFamilyType: "OPERATOR FAMILY",
+	OperatorType:       "OPERATOR",
+	CastType:           "CAST",
+	CollationType:      "COLLATION",
+
+	TextSearchConfigType:     "TEXT SEARCH CONFIGURATION",
+	TextSearchDictionaryType: "TEXT SEARCH DICTIONARY",
+}
+
+// DropStatement returns the statement dropping the object if it exists, with CASCADE when
+// cascade is set, or "" for object types that cannot be dropped on their own
+func (o Object) DropStatement(cascade bool) string {
+	var stmt string
+	switch o.Type {
+	case ConstraintType, ForeignKeyType:
+		// Constraints are named after their table
+		table := o.table()
+		stmt = fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP CONSTRAINT IF EXISTS %s", qualify(o.Schema, table), quoteIdent(strings.TrimPrefix(o.Name, table+"_")))
+	case PolicyType, RuleType:
+		// Policies and rules are named after their table
+		keyword := "POLICY"
+		if o.Type == RuleType {
+			keyword = "RULE"
+		}
+		table := o.table()
+		stmt = fmt.Sprintf("DROP %s IF EXISTS %s ON %s", keyword, quoteIdent(strings.TrimPrefix(o.Name, table+"_")), qualify(o.Schema, table))
+	default:
+		keyword, ok := dropKeywords[o.Type]
+		if !ok {
+			return ""
+		}
+		name := qualify(o.Schema, o.Name)
+		switch {
+		case o.Type == CastType:
+			name = castSignature(o.Definition)
+		case o.Security != nil && (o.Type == FunctionType || o.Type == OperatorFamilyType || o.Type == OperatorType):
+			// The identity carries the argument types, or the access method
+			name = o.Security.Identity
+			if o.Security.Kind == "aggregate" {
+				keyword = "AGGREGATE"
+			}
+		case o.Type == FunctionType:
+			name += "(" + o.Args + ")"
+		case o.Type == OperatorType:
+			name = quoteIdent(o.Schema) + "." + o.Name + "(" + o.Args + ")"
+		case o.Type == OperatorFamilyType:
+			return ""
+		}
+		stmt = fmt.Sprintf("DROP %s IF EXISTS %s", keyword, name)
+	}
+
+	if cascade {
+		stmt += " CASCADE"
+	}
+	return stmt + ";"
+}
+
+// table returns the name of the table a constraint, policy or rule is attached to, which is
+// always its first dependency
+func (o Object) table() string {
+	if len(o.Depends) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(o.Depends[0], o.Schema+".")
+}
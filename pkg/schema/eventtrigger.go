@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// eventTriggerStates maps the evtenabled codes of pg_event_trigger, except the default
+// "O", to the statement putting an event trigger in that state
+var eventTriggerStates = map[string]string{
+	"D": "DISABLE",
+	"R": "ENABLE REPLICA",
+	"A": "ENABLE ALWAYS",
+}
+
+// ExtractEventTriggers returns the event triggers of the database not created by an
+// extension. Event triggers are database-wide, so they have no schema; each depends on the
+// function it executes, and carries its owner so it can be written like schema objects.
+func (e *Extractor) ExtractEventTriggers(ctx context.Context) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT t.oid, t.evtname, t.evtevent, t.evtenabled,
+			COALESCE(t.evttags, '{}'), n.nspname, p.proname, pg_get_userbyid(t.evtowner)
+		FROM pg_event_trigger t
+		JOIN pg_proc p ON p.oid = t.evtfoid
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE NOT EXISTS (SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_event_trigger'::regclass AND d.objid = t.oid AND d.deptype = 'e')
+		ORDER BY t.evtname`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing event triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var oid uint32
+		var name, event, enabled, functionSchema, function, owner string
+		var tags []string
+		if err := rows.Scan(&oid, &name, &event, &enabled, pq.Array(&tags), &functionSchema, &function, &owner); err != nil {
+			return nil, fmt.Errorf("error reading event trigger: %w", err)
+		}
+
+		definition := fmt.Sprintf("CREATE EVENT TRIGGER %s ON %s", quoteIdent(name), quoteIdent(event))
+		if len(tags) > 0 {
+			quoted := make([]string, len(tags))
+			for i, tag := range tags {
+				quoted[i] = pq.QuoteLiteral(tag)
+			}
+			definition += fmt.Sprintf("\n    WHEN TAG IN (%s)", strings.Join(quoted, ", "))
+		}
+		definition += fmt.Sprintf("\n    EXECUTE FUNCTION %s.%s()", quoteIdent(functionSchema), quoteIdent(function))
+		if state, ok := eventTriggerStates[enabled]; ok {
+			definition += fmt.Sprintf(";\n\nALTER EVENT TRIGGER %s %s", quoteIdent(name), state)
+		}
+
+		objects = append(objects, Object{
+			Name:       name,
+			Type:       EventTriggerType,
+			Definition: definition,
+			Depends:    []string{functionSchema + "." + function},
+			OID:        oid,
+			Security:   &Security{Kind: "event trigger", Identity: quoteIdent(name), Owner: owner},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing event triggers: %w", err)
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ExtractExtensions returns the CREATE EXTENSION statements of the extensions installed in
+// the database, with the schema and version they were installed with. Extensions are
+// database-wide, so these are not tied to any extracted schema.
+func (e *Extractor) ExtractExtensions(ctx context.Context) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT x.extname, n.nspname, x.extversion
+		FROM pg_extension x
+		JOIN pg_namespace n ON n.oid = x.extnamespace
+		ORDER BY x.extname`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing extensions: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var name, schemaName, version string
+		if err := rows.Scan(&name, &schemaName, &version); err != nil {
+			return nil, fmt.Errorf("error reading extension: %w", err)
+		}
+		statements = append(statements, fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s WITH SCHEMA %s VERSION %s;",
+			quoteIdent(name), quoteIdent(schemaName), pq.QuoteLiteral(version)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing extensions: %w", err)
+	}
+
+	return statements, nil
+}
+
+// listExtensionMembers returns the extension owning each object created by an extension,
+// keyed by memberKey. Only the catalogs of listed objects are covered.
+func (e *Extractor) listExtensionMembers(ctx context.Context) (map[string]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT d.classid::regclass::text, n.nspname,
+			COALESCE(c.relname, p.proname, t.typname, f.opfname, co.collname, cfg.cfgname, dict.dictname, o.oprname), x.extname
+		FROM pg_depend d
+		JOIN pg_extension x ON x.oid = d.refobjid
+		LEFT JOIN pg_class c ON d.classid = 'pg_class'::regclass AND c.oid = d.objid
+		LEFT JOIN pg_proc p ON d.classid = 'pg_proc'::regclass AND p.oid = d.objid
+		LEFT JOIN pg_type t ON d.classid = 'pg_type'::regclass AND t.oid = d.objid
+		LEFT JOIN pg_opfamily f ON d.classid = 'pg_opfamily'::regclass AND f.oid = d.objid
+		LEFT JOIN pg_collation co ON d.classid = 'pg_collation'::regclass AND co.oid = d.objid
+		LEFT JOIN pg_ts_config cfg ON d.classid = 'pg_ts_config'::regclass AND cfg.oid = d.objid
+		LEFT JOIN pg_ts_dict dict ON d.classid = 'pg_ts_dict'::regclass AND dict.oid = d.objid
+		LEFT JOIN pg_operator o ON d.classid = 'pg_operator'::regclass AND o.oid = d.objid
+		JOIN pg_namespace n ON n.oid = COALESCE(c.relnamespace, p.pronamespace, t.typnamespace, f.opfnamespace,
+			co.collnamespace, cfg.cfgnamespace, dict.dictnamespace, o.oprnamespace)
+		WHERE d.refclassid = 'pg_extension'::regclass AND d.deptype = 'e'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[string]string)
+	for rows.Next() {
+		var catalog, schemaName, name, extension string
+		if err := rows.Scan(&catalog, &schemaName, &name, &extension); err != nil {
+			return nil, err
+		}
+		members[memberKey(catalog, schemaName, name)] = extension
+	}
+	return members, rows.Err()
+}
+
+// memberKey identifies an object by catalog and name in the extension members map.
+// Overloaded functions share a key, as extensions own every overload they create.
+func memberKey(catalog, schemaName, name string) string {
+	return catalog + "/" + schemaName + "/" + name
+}
+
+// extensionOf returns the extension owning an object of the given catalog, or "" for
+// objects created by users
+func (e *Extractor) extensionOf(catalog, schemaName, name string) string {
+	return e.extensionMembers[memberKey(catalog, schemaName, name)]
+}
@@ -1,369 +1,299 @@
 package schema
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
-	"os/exec"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/ofux/pgsac/pkg/database"
 )
 
 // Extractor handles the extraction of schema information from the database
 type Extractor struct {
-	db     *sql.DB
+	db     *retryingDB
 	config database.Config
+	filter *Filter
+	logger *slog.Logger
+	types  []ObjectType
+
+	includeSecurityLabels bool
+	usePsql               bool
+	tableFormat           TableFormat
+	concurrency           int
+	normalize             bool
+	retrier               retrier
+	redactPasswords       bool
+	redactConninfo        bool
+	continueOnError       bool
+
+	// Extension owning each object created by an extension, see listExtensionMembers
+	extensionMembers map[string]string
+
+	// Progress of the running extraction
+	progress     func(Progress)
+	progressMu   sync.Mutex
+	progressStep Progress
+
+	// Counts and durations of the last extraction, by step
+	stats []StepStats
+
+	// Errors recorded with continueOnError, and the schema being extracted
+	failures      []Failure
+	failureSchema string
 }
 
 // NewExtractor creates a new schema extractor
-func NewExtractor(db *sql.DB, config database.Config) *Extractor {
-	return &Extractor{
-		db:     db,
-		config: config,
+func NewExtractor(db *sql.DB, config database.Config, opts Options) *Extractor {
+	filter := opts.Filter
+	if filter == nil {
+		filter = &Filter{}
 	}
-}
-
-// execPsql executes a psql command and returns its output
-func (e *Extractor) execPsql(command string) (string, error) {
-	args := []string{
-		"-h", e.config.Host,
-		"-p", fmt.Sprintf("%d", e.config.Port),
-		"-U", e.config.User,
-		"-d", e.config.DBName,
-		"-c", command,
-		"--no-align",    // Unaligned output mode
-		"--tuples-only", // Print rows only
-		"-q",            // Run quietly (no messages, only query output)
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
 	}
-
-	cmd := exec.Command("psql", args...)
-
-	// Set PGPASSWORD environment variable
-	cmd.Env = append(cmd.Environ(), fmt.Sprintf("PGPASSWORD=%s", e.config.Password))
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("psql error: %w\nstderr: %s", err, stderr.String())
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	retrier := retrier{maxRetries: opts.MaxRetries, delay: opts.RetryDelay, logger: logger}
+	tableFormat := opts.TableFormat
+	if tableFormat == "" {
+		tableFormat = TableDDL
 	}
 
-	return stdout.String(), nil
+	return &Extractor{
+		db:                    &retryingDB{DB: db, retrier: retrier},
+		config:                config,
+		filter:                filter,
+		logger:                logger,
+		types:                 opts.Types,
+		includeSecurityLabels: opts.IncludeSecurityLabels,
+		usePsql:               opts.UsePsql,
+		tableFormat:           tableFormat,
+		concurrency:           concurrency,
+		normalize:             opts.Normalize,
+		retrier:               retrier,
+		redactPasswords:       !opts.KeepPasswords,
+		redactConninfo:        !opts.IncludeSubscriptionConninfo,
+		continueOnError:       opts.ContinueOnError,
+		progress:              opts.Progress,
+	}
 }
 
 // ExtractSchemas extracts all objects from the specified schemas
-func (e *Extractor) ExtractSchemas(schemaNames []string) ([]Schema, error) {
+func (e *Extractor) ExtractSchemas(ctx context.Context, schemaNames []string) ([]Schema, error) {
 	var schemas []Schema
-	for _, schemaName := range schemaNames {
-		schema := Schema{Name: schemaName}
-
-		// Extract tables
-		tables, err := e.extractTables(schemaName)
-		if err != nil {
-			return nil, fmt.Errorf("error extracting tables from schema %s: %w", schemaName, err)
-		}
-		schema.Objects = append(schema.Objects, tables...)
-
-		// Extract views
-		views, err := e.extractViews(schemaName)
-		if err != nil {
-			return nil, fmt.Errorf("error extracting views from schema %s: %w", schemaName, err)
-		}
-		schema.Objects = append(schema.Objects, views...)
-
-		// Extract materialized views
-		matViews, err := e.extractMaterializedViews(schemaName)
-		if err != nil {
-			return nil, fmt.Errorf("error extracting materialized views from schema %s: %w", schemaName, err)
-		}
-		schema.Objects = append(schema.Objects, matViews...)
-
-		// Extract functions
-		functions, err := e.extractFunctions(schemaName)
-		if err != nil {
-			return nil, fmt.Errorf("error extracting functions from schema %s: %w", schemaName, err)
-		}
-		schema.Objects = append(schema.Objects, functions...)
-
-		schemas = append(schemas, schema)
+	err := e.StreamSchemas(ctx, schemaNames, func(s Schema) error {
+		schemas = append(schemas, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return schemas, nil
 }
 
-func (e *Extractor) extractTables(schemaName string) ([]Object, error) {
-	// First, get the list of tables, excluding system tables
-	listCmd := fmt.Sprintf(`\dt+ %s.*`, schemaName)
-	tableList, err := e.execPsql(listCmd)
-	if err != nil {
-		return nil, fmt.Errorf("error listing tables: %w", err)
-	}
-
-	var objects []Object
-	for _, line := range strings.Split(strings.TrimSpace(tableList), "\n") {
-		if line == "" {
-			continue
-		}
-
-		// Parse the table name from the output (pipe separated)
-		fields := strings.Split(line, "|")
-		if len(fields) < 6 { // \dt+ output has at least 6 fields
-			continue
-		}
-
-		schema := strings.TrimSpace(fields[0])
-		tableName := strings.TrimSpace(fields[1])
-
-		// Skip if this is a system table
-		if schema == "pg_catalog" || schema == "information_schema" {
-			continue
-		}
-
-		// Get the table definition
-		defCmd := fmt.Sprintf(`\d+ %s.%s`, schemaName, tableName)
-		definition, err := e.execPsql(defCmd)
-		if err != nil {
-			return nil, fmt.Errorf("error getting table definition for %s: %w", tableName, err)
-		}
-
-		obj := Object{
-			Schema:     schemaName,
-			Name:       tableName,
-			Type:       TableType,
-			Definition: definition,
+// StreamSchemas extracts the specified schemas one at a time, passing each to fn before
+// extracting the next, so only one schema's objects are held in memory. An error of fn
+// stops the extraction and is returned as is.
+func (e *Extractor) StreamSchemas(ctx context.Context, schemaNames []string, fn func(Schema) error) error {
+	// Object types in extraction order, restricted by Options.Types
+	var steps []step
+	for _, step := range e.steps() {
+		if len(e.types) == 0 || slices.Contains(e.types, step.objType) {
+			steps = append(steps, step)
 		}
-		objects = append(objects, obj)
 	}
 
-	return objects, nil
-}
-
-func (e *Extractor) extractViews(schemaName string) ([]Object, error) {
-	// List views, excluding system views
-	listCmd := fmt.Sprintf(`\dv+ %s.*`, schemaName)
-	viewList, err := e.execPsql(listCmd)
-	if err != nil {
-		return nil, fmt.Errorf("error listing views: %w", err)
+	if err := e.checkSearchPath(ctx); err != nil {
+		return err
 	}
-
-	var objects []Object
-	for _, line := range strings.Split(strings.TrimSpace(viewList), "\n") {
-		if line == "" {
-			continue
-		}
-
-		// Parse the view name from the output (pipe separated)
-		fields := strings.Split(line, "|")
-		if len(fields) < 6 { // \dv+ output has at least 6 fields
-			continue
-		}
-
-		schema := strings.TrimSpace(fields[0])
-		viewName := strings.TrimSpace(fields[1])
-
-		// Skip if this is a system view
-		if schema == "pg_catalog" || schema == "information_schema" {
-			continue
-		}
-
-		// Get the view definition
-		defCmd := fmt.Sprintf(`\d+ %s.%s`, schemaName, viewName)
-		definition, err := e.execPsql(defCmd)
-		if err != nil {
-			return nil, fmt.Errorf("error getting view definition for %s: %w", viewName, err)
-		}
-
-		obj := Object{
-			Schema:     schemaName,
-			Name:       viewName,
-			Type:       ViewType,
-			Definition: definition,
-		}
-		objects = append(objects, obj)
+	if err := e.checkSchemasExist(ctx, schemaNames); err != nil {
+		return err
 	}
 
-	return objects, nil
-}
-
-func (e *Extractor) extractMaterializedViews(schemaName string) ([]Object, error) {
-	// List materialized views, excluding system ones
-	listCmd := fmt.Sprintf(`\dm+ %s.*`, schemaName)
-	matViewList, err := e.execPsql(listCmd)
+	members, err := e.listExtensionMembers(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error listing materialized views: %w", err)
+		return fmt.Errorf("error listing extension members: %w", err)
 	}
+	e.extensionMembers = members
 
-	var objects []Object
-	for _, line := range strings.Split(strings.TrimSpace(matViewList), "\n") {
-		if line == "" {
-			continue
-		}
-
-		// Parse the materialized view name from the output (pipe separated)
-		fields := strings.Split(line, "|")
-		if len(fields) < 6 { // \dm+ output has at least 6 fields
-			continue
-		}
-
-		schema := strings.TrimSpace(fields[0])
-		matViewName := strings.TrimSpace(fields[1])
-
-		// Skip if this is a system materialized view
-		if schema == "pg_catalog" || schema == "information_schema" {
-			continue
+	e.progressStep = Progress{}
+	e.failures = nil
+	e.stats = make([]StepStats, len(steps))
+	for i, step := range steps {
+		e.stats[i].Step = step.label
+	}
+	for _, schemaName := range schemaNames {
+		schema := Schema{Name: schemaName}
+		e.failureSchema = schemaName
+		for i, step := range steps {
+			e.progressStep.Step = step.label
+			e.logger.Debug("listing objects", "schema", schemaName, "type", step.label)
+			started := time.Now()
+			objects, err := step.extract(ctx, schemaName)
+			if err != nil {
+				err = fmt.Errorf("error extracting %s from schema %s: %w", step.label, schemaName, err)
+				if !e.continueOnError || ctx.Err() != nil {
+					return err
+				}
+				e.recordFailure(err)
+			}
+			e.stats[i].Objects += len(objects)
+			e.stats[i].Duration += time.Since(started)
+			if e.normalize {
+				for i := range objects {
+					objects[i].Definition = normalizeDefinition(objects[i])
+				}
+			}
+			schema.Objects = append(schema.Objects, objects...)
 		}
-
-		// Get the materialized view definition
-		defCmd := fmt.Sprintf(`\d+ %s.%s`, schemaName, matViewName)
-		definition, err := e.execPsql(defCmd)
+		defaultPrivileges, err := e.extractDefaultPrivileges(ctx, schemaName)
 		if err != nil {
-			return nil, fmt.Errorf("error getting materialized view definition for %s: %w", matViewName, err)
+			return fmt.Errorf("error extracting default privileges of schema %s: %w", schemaName, err)
+		}
+		schema.DefaultPrivileges = defaultPrivileges
+		if err := e.describeSchema(ctx, &schema); err != nil {
+			return fmt.Errorf("error describing schema %s: %w", schemaName, err)
 		}
 
-		obj := Object{
-			Schema:     schemaName,
-			Name:       matViewName,
-			Type:       MaterializedView,
-			Definition: definition,
+		e.logger.Info("extracted schema", "schema", schemaName, "objects", len(schema.Objects))
+		if err := fn(schema); err != nil {
+			return err
 		}
-		objects = append(objects, obj)
 	}
-
-	return objects, nil
+	return nil
 }
 
-func (e *Extractor) extractFunctions(schemaName string) ([]Object, error) {
-	// First get regular functions
-	functions, err := e.extractRegularFunctions(schemaName)
-	if err != nil {
-		return nil, err
-	}
+// step extracts the objects of one type from a schema
+type step struct {
+	label   string
+	objType ObjectType
+	extract func(context.Context, string) ([]Object, error)
+}
 
-	// Then get aggregate functions
-	aggregates, err := e.extractAggregateFunctions(schemaName)
-	if err != nil {
-		return nil, err
+// steps returns the extraction steps, in the order of SchemaObjectTypes
+func (e *Extractor) steps() []step {
+	return []step{
+		{"collations", CollationType, e.extractCollations},
+		{"text search dictionaries", TextSearchDictionaryType, e.extractTextSearchDictionaries},
+		{"text search configurations", TextSearchConfigType, e.extractTextSearchConfigurations},
+		{"types", TypeType, e.extractTypes},
+		{"domains", DomainType, e.extractDomains},
+		{"tables", TableType, e.extractTables},
+		{"foreign tables", ForeignTableType, e.extractForeignTables},
+		{"sequences", SequenceType, e.extractSequences},
+		{"constraints", ConstraintType, e.extractConstraints},
+		{"indexes", IndexType, e.extractIndexes},
+		{"views", ViewType, e.extractViews},
+		{"materialized views", MaterializedView, e.extractMaterializedViews},
+		{"functions", FunctionType, e.extractFunctions},
+		{"operators", OperatorType, e.extractOperators},
+		{"casts", CastType, e.extractCasts},
+		{"policies", PolicyType, e.extractPolicies},
+		{"rules", RuleType, e.extractRules},
+		{"foreign keys", ForeignKeyType, e.extractForeignKeys},
+		{"operator families", OperatorFamilyType, e.extractOperatorFamilies},
 	}
+}
 
-	return append(functions, aggregates...), nil
+// describeSchema fills the owner and comment of a schema
+func (e *Extractor) describeSchema(ctx context.Context, s *Schema) error {
+	return e.db.QueryRowContext(ctx, `SELECT pg_get_userbyid(n.nspowner), COALESCE(obj_description(n.oid, 'pg_namespace'), '')
+		FROM pg_namespace n
+		WHERE n.nspname = $1`, s.Name).Scan(&s.Owner, &s.Comment)
 }
 
-func (e *Extractor) extractRegularFunctions(schemaName string) ([]Object, error) {
-	// List functions, excluding system functions and aggregates
-	listCmd := fmt.Sprintf(`\df+ %s.*`, schemaName)
-	funcList, err := e.execPsql(listCmd)
-	if err != nil {
-		return nil, fmt.Errorf("error listing functions: %w", err)
+// checkSearchPath fails unless the session runs with the empty search_path pinned by
+// database.Connect, since pg_get_viewdef, format_type and the like leave the objects found
+// on the search_path unqualified, which would make exports depend on the connecting role
+func (e *Extractor) checkSearchPath(ctx context.Context) error {
+	var searchPath string
+	if err := e.db.QueryRowContext(ctx, `SELECT current_setting('search_path')`).Scan(&searchPath); err != nil {
+		return fmt.Errorf("error checking search_path: %w", err)
 	}
-
-	var objects []Object
-	for _, line := range strings.Split(strings.TrimSpace(funcList), "\n") {
-		if line == "" {
-			continue
-		}
-
-		// Parse the function name from the output (pipe separated)
-		fields := strings.Split(line, "|")
-		if len(fields) < 12 { // \df+ output has 12 fields
-			continue
-		}
-
-		schema := strings.TrimSpace(fields[0])
-		funcName := strings.TrimSpace(fields[1])
-		argTypes := strings.TrimSpace(fields[3]) // Column 4 contains argument types
-		kind := strings.TrimSpace(fields[4])     // Column 5 contains the kind (func/agg/etc)
-
-		// Skip if this is a system function or an aggregate
-		if schema == "pg_catalog" || schema == "information_schema" || kind != "func" {
-			continue
-		}
-
-		// Get the function definition
-		// Include argument types to handle overloaded functions
-		defCmd := fmt.Sprintf(`\sf %s.%s(%s)`, schemaName, funcName, argTypes)
-		definition, err := e.execPsql(defCmd)
-		if err != nil {
-			return nil, fmt.Errorf("error getting function definition for %s(%s): %w", funcName, argTypes, err)
-		}
-
-		obj := Object{
-			Schema:     schemaName,
-			Name:       funcName,
-			Type:       FunctionType,
-			Definition: definition,
-		}
-		objects = append(objects, obj)
+	if searchPath != "" {
+		return fmt.Errorf("extraction needs an empty search_path, as set by database.Connect, got %q", searchPath)
 	}
-
-	return objects, nil
+	return nil
 }
 
-func (e *Extractor) extractAggregateFunctions(schemaName string) ([]Object, error) {
-	// List aggregate functions
-	listCmd := fmt.Sprintf(`\da+ %s.*`, schemaName)
-	funcList, err := e.execPsql(listCmd)
+// checkSchemasExist fails naming the requested schemas missing from the database, so a
+// misspelled schema is not mistaken for an empty one
+func (e *Extractor) checkSchemasExist(ctx context.Context, schemaNames []string) error {
+	rows, err := e.db.QueryContext(ctx, `SELECT s.name
+		FROM unnest($1::text[]) AS s(name)
+		WHERE NOT EXISTS (SELECT 1 FROM pg_namespace n WHERE n.nspname = s.name)`, pq.Array(schemaNames))
 	if err != nil {
-		return nil, fmt.Errorf("error listing aggregate functions: %w", err)
+		return fmt.Errorf("error checking schemas: %w", err)
 	}
+	defer rows.Close()
 
-	var objects []Object
-	for _, line := range strings.Split(strings.TrimSpace(funcList), "\n") {
-		if line == "" {
-			continue
-		}
-
-		// Parse the aggregate function name from the output (pipe separated)
-		fields := strings.Split(line, "|")
-		if len(fields) < 4 { // \da+ output has at least 4 fields
-			continue
-		}
-
-		schema := strings.TrimSpace(fields[0])
-		funcName := strings.TrimSpace(fields[1])
-		argTypes := strings.TrimSpace(fields[2]) // Column 3 contains argument types
-
-		// Skip if this is a system aggregate
-		if schema == "pg_catalog" || schema == "information_schema" {
-			continue
+	var missing []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("error checking schemas: %w", err)
 		}
+		missing = append(missing, name)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error checking schemas: %w", err)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("schema(s) not found in the database: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
 
-		// For aggregates, we need to get the definition using a SQL query
-		defCmd := fmt.Sprintf(`SELECT pg_get_functiondef(p.oid)
-			FROM pg_proc p
-			JOIN pg_namespace n ON p.pronamespace = n.oid
-			WHERE n.nspname = '%s'
-			AND p.proname = '%s'
-			AND p.proargtypes::regtype[] = ARRAY[%s]::regtype[]`,
-			schemaName, funcName, e.formatArgTypesForSQL(argTypes))
-
-		definition, err := e.execPsql(defCmd)
-		if err != nil {
-			return nil, fmt.Errorf("error getting aggregate function definition for %s(%s): %w", funcName, argTypes, err)
-		}
+// Stats returns the number of objects extracted and the time spent for each object type
+// by the last call to ExtractSchemas, summed over its schemas, in extraction order
+func (e *Extractor) Stats() []StepStats {
+	return e.stats
+}
 
-		obj := Object{
-			Schema:     schemaName,
-			Name:       funcName,
-			Type:       FunctionType,
-			Definition: definition,
-		}
-		objects = append(objects, obj)
-	}
+// Failures returns the errors ContinueOnError recorded during the last call to
+// ExtractSchemas, each leaving an object or a whole object type of a schema out
+func (e *Extractor) Failures() []Failure {
+	return e.failures
+}
 
-	return objects, nil
+// recordFailure records an error of the step and schema being extracted
+func (e *Extractor) recordFailure(err error) {
+	e.progressMu.Lock()
+	defer e.progressMu.Unlock()
+	e.logger.Debug("skipping after error", "schema", e.failureSchema, "type", e.progressStep.Step, "error", err)
+	e.failures = append(e.failures, Failure{Schema: e.failureSchema, Step: e.progressStep.Step, Err: err})
 }
 
-// formatArgTypesForSQL formats argument types for use in a SQL query
-// Example: "text, integer" becomes "'text', 'integer'"
-func (e *Extractor) formatArgTypesForSQL(argTypes string) string {
-	if argTypes == "" {
-		return ""
+// reportProgress adds listed objects to the total and fetched objects to the done count,
+// then reports the new progress
+func (e *Extractor) reportProgress(listed, fetched int) {
+	if e.progress == nil {
+		return
 	}
+	e.progressMu.Lock()
+	defer e.progressMu.Unlock()
+	e.progressStep.Total += listed
+	e.progressStep.Done += fetched
+	e.progress(e.progressStep)
+}
 
-	types := strings.Split(argTypes, ",")
-	for i, t := range types {
-		types[i] = fmt.Sprintf("'%s'", strings.TrimSpace(t))
+// decide applies the filter to a listed object and logs the objects whose definition
+// is about to be fetched
+func (e *Extractor) decide(c Candidate) bool {
+	if !e.filter.Decide(c).Include {
+		return false
 	}
-	return strings.Join(types, ",")
+	e.logger.Debug("extracting object", "schema", c.Schema, "name", c.Name, "type", c.Type)
+	return true
 }
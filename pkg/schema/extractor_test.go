@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/database"
+)
+
+func TestNewExtractorDefaults(t *testing.T) {
+	e := NewExtractor(nil, database.Config{}, Options{})
+
+	if e.filter == nil || len(e.filter.Include) > 0 || len(e.filter.Exclude) > 0 {
+		t.Errorf("filter = %+v, want an empty Filter", e.filter)
+	}
+	if e.logger == nil {
+		t.Error("logger is nil, want a discarding logger")
+	}
+	if e.types != nil {
+		t.Errorf("types = %v, want every type", e.types)
+	}
+	if e.concurrency != runtime.NumCPU() {
+		t.Errorf("concurrency = %d, want the number of CPUs %d", e.concurrency, runtime.NumCPU())
+	}
+	if e.tableFormat != TableDDL {
+		t.Errorf("tableFormat = %q, want %q", e.tableFormat, TableDDL)
+	}
+	if e.usePsql || e.includeSecurityLabels || e.normalize || e.continueOnError {
+		t.Error("zero Options enable psql, security labels, normalization or continuing on errors")
+	}
+	if !e.redactPasswords || !e.redactConninfo {
+		t.Error("zero Options keep passwords or subscription connection strings")
+	}
+	if e.retrier.maxRetries != 0 {
+		t.Errorf("maxRetries = %d, want no retries", e.retrier.maxRetries)
+	}
+}
+
+func TestNewExtractorOptions(t *testing.T) {
+	filter := &Filter{Include: []string{"app.*"}}
+	e := NewExtractor(nil, database.Config{}, Options{
+		Filter:        filter,
+		Concurrency:   3,
+		TableFormat:   TableDescribe,
+		KeepPasswords: true,
+		MaxRetries:    2,
+	})
+	if e.filter != filter || e.concurrency != 3 || e.tableFormat != TableDescribe ||
+		e.redactPasswords || e.retrier.maxRetries != 2 {
+		t.Errorf("options not applied: filter %p, concurrency %d, tableFormat %q, redactPasswords %v, maxRetries %d",
+			e.filter, e.concurrency, e.tableFormat, e.redactPasswords, e.retrier.maxRetries)
+	}
+}
+
+func TestNewExtractorKeepsConfig(t *testing.T) {
+	config := database.Config{Host: "db.example.com", Port: 6432, DBName: "app", User: "alice",
+		Password: "secret", SSLMode: "require", PsqlPath: "/usr/lib/postgresql/16/bin/psql"}
+	if e := NewExtractor(nil, config, Options{}); e.config != config {
+		t.Errorf("config = %+v, want %+v", e.config, config)
+	}
+}
+
+func TestExtractSchemasEmptyAndMissing(t *testing.T) {
+	db, config := testSchema(t, "pgsac_empty")
+	e := NewExtractor(db, config, Options{})
+
+	schemas, err := e.ExtractSchemas(context.Background(), []string{"pgsac_empty"})
+	if err != nil {
+		t.Fatalf("ExtractSchemas(empty schema) error = %v", err)
+	}
+	if len(schemas) != 1 || schemas[0].Name != "pgsac_empty" || len(schemas[0].Objects) != 0 {
+		t.Errorf("ExtractSchemas(empty schema) = %+v, want the schema without objects", schemas)
+	}
+
+	_, err = e.ExtractSchemas(context.Background(), []string{"pgsac_empty", "pgsac_missing"})
+	if err == nil || !strings.Contains(err.Error(), "schema(s) not found in the database: pgsac_missing") {
+		t.Errorf("ExtractSchemas(missing schema) error = %v, want it to name pgsac_missing", err)
+	}
+}
+
+func TestStreamSchemas(t *testing.T) {
+	db, config := testSchema(t, "pgsac_stream_a", `CREATE TABLE pgsac_stream_a.items (id int)`)
+	testSchema(t, "pgsac_stream_b", `CREATE TABLE pgsac_stream_b.items (id int)`)
+	e := NewExtractor(db, config, Options{})
+
+	// Each schema is passed on before the next is extracted
+	var streamed []string
+	stop := errors.New("stop")
+	err := e.StreamSchemas(context.Background(), []string{"pgsac_stream_a", "pgsac_stream_b"}, func(s Schema) error {
+		streamed = append(streamed, s.Name)
+		if len(s.Objects) != 1 || s.Objects[0].Schema != s.Name {
+			t.Errorf("schema %s streamed with %+v, want its table", s.Name, s.Objects)
+		}
+		return stop
+	})
+	if err != stop {
+		t.Errorf("StreamSchemas() error = %v, want the error of fn", err)
+	}
+	if len(streamed) != 1 || streamed[0] != "pgsac_stream_a" {
+		t.Errorf("streamed %v, want only pgsac_stream_a", streamed)
+	}
+}
+
+func TestExtractIgnoresSearchPathOption(t *testing.T) {
+	_, config := testSchema(t, "pgsac_search_path",
+		`CREATE TABLE pgsac_search_path.items (id int)`,
+		`CREATE VIEW pgsac_search_path.item_ids AS SELECT id FROM pgsac_search_path.items`)
+
+	// The search_path given in the options is overridden by the one Connect pins
+	config.Options = "-c search_path=pgsac_search_path"
+	db, err := database.Connect(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	view := extractTestObject(t, db, config, Options{}, "pgsac_search_path", "item_ids")
+	if !strings.Contains(view.Definition, "FROM pgsac_search_path.items") {
+		t.Errorf("Definition = %q, want the table qualified", view.Definition)
+	}
+}
+
+func TestExtractRequiresEmptySearchPath(t *testing.T) {
+	_, config := testSchema(t, "pgsac_search_path")
+
+	db, err := sql.Open("postgres", strings.Replace(config.ConnString(), "search_path=''", "search_path='public'", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = NewExtractor(db, config, Options{}).ExtractSchemas(context.Background(), []string{"pgsac_search_path"})
+	if err == nil || !strings.Contains(err.Error(), "extraction needs an empty search_path") {
+		t.Errorf("ExtractSchemas() error = %v, want the search_path rejected", err)
+	}
+}
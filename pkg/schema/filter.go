@@ -0,0 +1,199 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Candidate is an object found while listing a schema, before its definition is fetched
+type Candidate struct {
+	Schema string
+	Name   string
+	Type   ObjectType
+	Kind   string // Sub-kind: function kind reported by psql (func, agg, window, proc), identity for sequences, partition for tables, constraint or partition for indexes, inherited or partition for constraints and foreign keys
+	// Extension is the extension the object, or the table it is attached to, belongs to;
+	// empty for objects created by users
+	Extension string
+}
+
+// Decision records whether a candidate is extracted and which rule decided it
+type Decision struct {
+	Include bool
+	Rule    string // Name of the rule that made the decision
+	Reason  string // Human-readable explanation of the decision
+}
+
+// Filter decides which listed objects are extracted
+type Filter struct {
+	// ExplainSkip is a glob matched against "name" or "schema.name"; decisions for
+	// matching candidates are traced to Log. Empty disables tracing.
+	ExplainSkip string
+	// Log receives explain traces. Defaults to os.Stderr.
+	Log io.Writer
+	// Include, when not empty, restricts extraction to candidates matching one of these
+	// globs, matched against "name" or "schema.name" like ExplainSkip.
+	Include []string
+	// Exclude drops candidates matching any of these globs.
+	Exclude []string
+	// SkipPartitions drops partitions, e.g. when they are created dynamically, keeping
+	// their partitioned parents.
+	SkipPartitions bool
+	// IncludeExtensionObjects keeps objects created by extensions, which are skipped by
+	// default as CREATE EXTENSION recreates them.
+	IncludeExtensionObjects bool
+
+	skipped         []Skip
+	matchedIncludes map[string]bool
+}
+
+// Skip records a candidate excluded by the filter and the decision that excluded it
+type Skip struct {
+	Candidate
+	Decision
+}
+
+// Decide runs the filter rules against a candidate and returns the first decisive one
+func (f *Filter) Decide(c Candidate) Decision {
+	d := f.decide(c)
+	f.explain(c, d)
+	// Objects handled elsewhere or filtered out on request are not unexpected skips
+	if !d.Include && d.Rule != "handled-elsewhere" && d.Rule != "include" && d.Rule != "exclude" && d.Rule != "skip-partitions" && d.Rule != "extension-member" {
+		f.skipped = append(f.skipped, Skip{Candidate: c, Decision: d})
+	}
+	return d
+}
+
+// Skipped returns every candidate excluded so far, in decision order
+func (f *Filter) Skipped() []Skip {
+	return f.skipped
+}
+
+// UnmatchedIncludes returns the Include globs that matched no candidate so far
+func (f *Filter) UnmatchedIncludes() []string {
+	var unmatched []string
+	for _, pattern := range f.Include {
+		if !f.matchedIncludes[pattern] {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	return unmatched
+}
+
+// CheckSkips returns an error naming every skipped object that matches none of the allow globs
+func (f *Filter) CheckSkips(allow []string) error {
+	var unexpected []string
+	for _, s := range f.skipped {
+		allowed := false
+		for _, pattern := range allow {
+			if s.Candidate.matches(pattern) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			unexpected = append(unexpected, fmt.Sprintf("%s %s.%s (%s: %s)", s.Type, s.Schema, s.Name, s.Rule, s.Reason))
+		}
+	}
+
+	if len(unexpected) > 0 {
+		return fmt.Errorf("%d object(s) skipped:\n  %s", len(unexpected), strings.Join(unexpected, "\n  "))
+	}
+	return nil
+}
+
+func (f *Filter) decide(c Candidate) Decision {
+	if c.Schema == "pg_catalog" || c.Schema == "information_schema" {
+		return Decision{Rule: "system-schema", Reason: fmt.Sprintf("schema %s is a system schema", c.Schema)}
+	}
+
+	if c.Extension != "" && !f.IncludeExtensionObjects {
+		return Decision{Rule: "extension-member", Reason: fmt.Sprintf("belongs to extension %s", c.Extension)}
+	}
+
+	if c.Type == FunctionType && c.Kind != "" && c.Kind != "func" && c.Kind != "agg" {
+		return Decision{Rule: "type-filter", Reason: fmt.Sprintf("function kind %q is not supported", c.Kind)}
+	}
+
+	if c.Type == SequenceType && c.Kind == "identity" {
+		return Decision{Rule: "handled-elsewhere", Reason: "identity sequences are created by their column"}
+	}
+
+	if c.Type == IndexType && c.Kind == "constraint" {
+		return Decision{Rule: "handled-elsewhere", Reason: "constraint indexes are created by their constraint"}
+	}
+	if c.Type == IndexType && c.Kind == "partition" {
+		return Decision{Rule: "handled-elsewhere", Reason: "partition indexes are created by their parent index, which is replayed without ONLY"}
+	}
+
+	isConstraint := c.Type == ConstraintType || c.Type == ForeignKeyType
+	if isConstraint && c.Kind == "inherited" {
+		return Decision{Rule: "handled-elsewhere", Reason: "inherited constraints are created by their parent table's constraint"}
+	}
+	if isConstraint && c.Kind == "partition" {
+		return Decision{Rule: "handled-elsewhere", Reason: "partition constraints are created by their parent constraint"}
+	}
+
+	if f.SkipPartitions && c.Type == TableType && c.Kind == "partition" {
+		return Decision{Rule: "skip-partitions", Reason: "partitions are skipped on request"}
+	}
+
+	for _, pattern := range f.Exclude {
+		if c.matches(pattern) {
+			return Decision{Rule: "exclude", Reason: fmt.Sprintf("matches exclude pattern %q", pattern)}
+		}
+	}
+
+	if len(f.Include) > 0 {
+		matched := ""
+		for _, pattern := range f.Include {
+			if c.matches(pattern) {
+				if f.matchedIncludes == nil {
+					f.matchedIncludes = make(map[string]bool)
+				}
+				f.matchedIncludes[pattern] = true
+				if matched == "" {
+					matched = pattern
+				}
+			}
+		}
+		if matched == "" {
+			return Decision{Rule: "include", Reason: "matches no include pattern"}
+		}
+		return Decision{Include: true, Rule: "include", Reason: fmt.Sprintf("matches include pattern %q", matched)}
+	}
+
+	return Decision{Include: true, Rule: "default", Reason: "no rule excluded the object"}
+}
+
+func (f *Filter) explain(c Candidate, d Decision) {
+	if f.ExplainSkip == "" {
+		return
+	}
+
+	if !c.matches(f.ExplainSkip) {
+		return
+	}
+
+	w := f.Log
+	if w == nil {
+		w = os.Stderr
+	}
+
+	verdict := "included"
+	if !d.Include {
+		verdict = "excluded"
+	}
+	fmt.Fprintf(w, "explain: %s %s.%s: %s by %s (%s)\n", c.Type, c.Schema, c.Name, verdict, d.Rule, d.Reason)
+}
+
+// matches reports whether a glob matches the candidate's name or qualified name
+func (c Candidate) matches(pattern string) bool {
+	if ok, _ := path.Match(pattern, c.Name); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, c.Schema+"."+c.Name)
+	return ok
+}
@@ -0,0 +1,232 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// redactedPassword replaces the password of user mappings and subscription connection
+// strings when passwords are redacted
+const redactedPassword = RedactedPlaceholder
+
+// optionsClause renders generic options, stored as "name=value" strings, as an
+// OPTIONS (...) clause, or "" when there are none. The password option is redacted
+// when redactPassword is set.
+func optionsClause(options []string, redactPassword bool) string {
+	if len(options) == 0 {
+		return ""
+	}
+	rendered := make([]string, len(options))
+	for i, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		if redactPassword && name == "password" {
+			value = redactedPassword
+		}
+		rendered[i] = quoteIdent(name) + " " + pq.QuoteLiteral(value)
+	}
+	return " OPTIONS (" + strings.Join(rendered, ", ") + ")"
+}
+
+// extractForeignTables extracts foreign tables with their columns, server and options.
+// Their servers are created beforehand by the statements of ExtractForeignServers.
+func (e *Extractor) extractForeignTables(ctx context.Context, schemaName string) ([]Object, error) {
+	tables, err := e.listRelations(ctx, schemaName, "f")
+	if err != nil {
+		return nil, fmt.Errorf("error listing foreign tables: %w", err)
+	}
+
+	var included []relation
+	for _, t := range tables {
+		if e.decide(Candidate{Schema: schemaName, Name: t.name, Type: ForeignTableType,
+			Extension: e.extensionOf("pg_class", schemaName, t.name)}) {
+			included = append(included, t)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, t relation) (Object, error) {
+		qualified := qualify(schemaName, t.name)
+		definition, err := e.foreignTableDefinition(ctx, qualified, t.oid)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting foreign table definition for %s: %w", t.name, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       t.name,
+			Type:       ForeignTableType,
+			Definition: definition,
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(t.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// foreignTableDefinition renders a CREATE FOREIGN TABLE statement from the table's columns
+// and foreign table options
+func (e *Extractor) foreignTableDefinition(ctx context.Context, qualified string, oid uint32) (string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT quote_ident(a.attname), format_type(a.atttypid, a.atttypmod),
+			a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), COALESCE(a.attfdwoptions, '{}')
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, oid)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var (
+			name, dataType, expr string
+			notNull              bool
+			options              []string
+		)
+		if err := rows.Scan(&name, &dataType, &notNull, &expr, pq.Array(&options)); err != nil {
+			return "", err
+		}
+
+		column := fmt.Sprintf("    %s %s%s", name, dataType, optionsClause(options, false))
+		if expr != "" {
+			column += " DEFAULT " + expr
+		}
+		if notNull {
+			column += " NOT NULL"
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	var server string
+	var options []string
+	err = e.db.QueryRowContext(ctx, `SELECT quote_ident(s.srvname), COALESCE(f.ftoptions, '{}')
+		FROM pg_foreign_table f
+		JOIN pg_foreign_server s ON s.oid = f.ftserver
+		WHERE f.ftrelid = $1`, oid).Scan(&server, pq.Array(&options))
+	if err != nil {
+		return "", err
+	}
+
+	definition := fmt.Sprintf("CREATE FOREIGN TABLE %s (\n%s\n)\nSERVER %s%s",
+		qualified, strings.Join(columns, ",\n"), server, optionsClause(options, false))
+
+	comments, err := e.tableComments(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	for _, stmt := range comments {
+		definition += ";\n\n" + stmt
+	}
+	return definition, nil
+}
+
+// ExtractForeignServers returns the statements creating the foreign data wrappers not
+// provided by an extension, the foreign servers and the user mappings of the database,
+// in that order. They are database-wide, so these are not tied to any extracted schema.
+// User mapping passwords are redacted unless disabled in Options.
+func (e *Extractor) ExtractForeignServers(ctx context.Context) ([]string, error) {
+	var statements []string
+
+	wrappers, err := e.queryStatements(ctx, `SELECT w.fdwname, COALESCE(w.fdwhandler::regproc::text, '-'),
+			COALESCE(w.fdwvalidator::regproc::text, '-'), COALESCE(w.fdwoptions, '{}')
+		FROM pg_foreign_data_wrapper w
+		WHERE NOT EXISTS (SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_foreign_data_wrapper'::regclass AND d.objid = w.oid AND d.deptype = 'e')
+		ORDER BY w.fdwname`, func(rows scanner) (string, error) {
+		var name, handler, validator string
+		var options []string
+		if err := rows.Scan(&name, &handler, &validator, pq.Array(&options)); err != nil {
+			return "", err
+		}
+		stmt := "CREATE FOREIGN DATA WRAPPER " + quoteIdent(name)
+		if handler != "-" {
+			stmt += " HANDLER " + handler
+		}
+		if validator != "-" {
+			stmt += " VALIDATOR " + validator
+		}
+		return stmt + optionsClause(options, false) + ";", nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing foreign data wrappers: %w", err)
+	}
+	statements = append(statements, wrappers...)
+
+	servers, err := e.queryStatements(ctx, `SELECT s.srvname, COALESCE(s.srvtype, ''), COALESCE(s.srvversion, ''),
+			w.fdwname, COALESCE(s.srvoptions, '{}')
+		FROM pg_foreign_server s
+		JOIN pg_foreign_data_wrapper w ON w.oid = s.srvfdw
+		ORDER BY s.srvname`, func(rows scanner) (string, error) {
+		var name, serverType, version, wrapper string
+		var options []string
+		if err := rows.Scan(&name, &serverType, &version, &wrapper, pq.Array(&options)); err != nil {
+			return "", err
+		}
+		stmt := "CREATE SERVER " + quoteIdent(name)
+		if serverType != "" {
+			stmt += " TYPE " + pq.QuoteLiteral(serverType)
+		}
+		if version != "" {
+			stmt += " VERSION " + pq.QuoteLiteral(version)
+		}
+		return stmt + " FOREIGN DATA WRAPPER " + quoteIdent(wrapper) + optionsClause(options, false) + ";", nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing foreign servers: %w", err)
+	}
+	statements = append(statements, servers...)
+
+	// Options are only visible to superusers and to the mapped user
+	mappings, err := e.queryStatements(ctx, `SELECT u.usename, u.srvname, COALESCE(u.umoptions, '{}')
+		FROM pg_user_mappings u
+		ORDER BY u.srvname, u.usename`, func(rows scanner) (string, error) {
+		var user, server string
+		var options []string
+		if err := rows.Scan(&user, &server, pq.Array(&options)); err != nil {
+			return "", err
+		}
+		if user != "public" {
+			user = quoteIdent(user)
+		}
+		return fmt.Sprintf("CREATE USER MAPPING FOR %s SERVER %s%s;",
+			user, quoteIdent(server), optionsClause(options, e.redactPasswords)), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing user mappings: %w", err)
+	}
+	return append(statements, mappings...), nil
+}
+
+// scanner is the row being read by a queryStatements callback
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// queryStatements runs a query and renders each row into a statement
+func (e *Extractor) queryStatements(ctx context.Context, query string, render func(scanner) (string, error)) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		stmt, err := render(rows)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, rows.Err()
+}
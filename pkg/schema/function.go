@@ -0,0 +1,196 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// functionKinds maps pg_proc.prokind codes to the kind names psql reports
+var functionKinds = map[string]string{
+	"f": "func",
+	"a": "agg",
+	"w": "window",
+	"p": "proc",
+}
+
+func (e *Extractor) extractFunctions(ctx context.Context, schemaName string) ([]Object, error) {
+	functions, err := e.listFunctions(ctx, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing functions: %w", err)
+	}
+
+	var included []listedFunction
+	for _, f := range functions {
+		if e.decide(Candidate{Schema: schemaName, Name: f.name, Type: FunctionType, Kind: f.kind,
+			Extension: e.extensionOf("pg_proc", schemaName, f.name)}) {
+			included = append(included, f)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, f listedFunction) (Object, error) {
+		// pg_get_functiondef, like \sf, renders CREATE OR REPLACE so a definition replays over
+		// an existing function; it does not support aggregates
+		var (
+			definition string
+			err        error
+		)
+		switch {
+		case f.kind == "agg":
+			definition, err = e.aggregateDefinition(ctx, qualify(schemaName, f.name), f.oid)
+		case e.usePsql:
+			definition, err = e.execPsql(ctx, fmt.Sprintf(`\sf %d`, f.oid))
+		default:
+			err = e.db.QueryRowContext(ctx, `SELECT pg_get_functiondef($1::oid)`, f.oid).Scan(&definition)
+		}
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting function definition for %s: %w", f.name, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       f.name,
+			Type:       FunctionType,
+			Definition: definition,
+			Args:       f.args,
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(f.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// listedFunction is a row of pg_proc listed for extraction
+type listedFunction struct {
+	oid  uint32
+	name string
+	kind string // As named by functionKinds
+	args string // Argument types, e.g. "integer, text"
+}
+
+// listFunctions lists the functions, procedures and aggregates of a schema, overloads
+// ordered by their arguments
+func (e *Extractor) listFunctions(ctx context.Context, schemaName string) ([]listedFunction, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT p.oid, p.proname, p.prokind::text, oidvectortypes(p.proargtypes)
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1
+		ORDER BY p.proname, pg_get_function_identity_arguments(p.oid)`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var functions []listedFunction
+	for rows.Next() {
+		var f listedFunction
+		var prokind string
+		if err := rows.Scan(&f.oid, &f.name, &prokind, &f.args); err != nil {
+			return nil, err
+		}
+		f.kind = functionKinds[prokind]
+		functions = append(functions, f)
+	}
+	return functions, rows.Err()
+}
+
+// aggregateDefinition renders a CREATE AGGREGATE statement from pg_aggregate,
+// omitting options left at their default
+func (e *Extractor) aggregateDefinition(ctx context.Context, qualified string, oid uint32) (string, error) {
+	var (
+		args, aggKind, transFn, transType           string
+		transSpace                                  int
+		finalFn                                     string
+		finalExtra                                  bool
+		combineFn, serialFn, deserialFn             string
+		mTransFn, mInvTransFn, mTransType, mFinalFn string
+		mFinalExtra                                 bool
+		initVal, mInitVal                           sql.NullString
+		sortOp, parallel                            string
+	)
+	err := e.db.QueryRowContext(ctx, `SELECT pg_get_function_arguments(p.oid), a.aggkind::text,
+			a.aggtransfn::regproc::text, format_type(a.aggtranstype, NULL), a.aggtransspace,
+			CASE WHEN a.aggfinalfn <> 0 THEN a.aggfinalfn::regproc::text ELSE '' END, a.aggfinalextra,
+			CASE WHEN a.aggcombinefn <> 0 THEN a.aggcombinefn::regproc::text ELSE '' END,
+			CASE WHEN a.aggserialfn <> 0 THEN a.aggserialfn::regproc::text ELSE '' END,
+			CASE WHEN a.aggdeserialfn <> 0 THEN a.aggdeserialfn::regproc::text ELSE '' END,
+			CASE WHEN a.aggmtransfn <> 0 THEN a.aggmtransfn::regproc::text ELSE '' END,
+			CASE WHEN a.aggminvtransfn <> 0 THEN a.aggminvtransfn::regproc::text ELSE '' END,
+			CASE WHEN a.aggmtranstype <> 0 THEN format_type(a.aggmtranstype, NULL) ELSE '' END,
+			CASE WHEN a.aggmfinalfn <> 0 THEN a.aggmfinalfn::regproc::text ELSE '' END, a.aggmfinalextra,
+			a.agginitval, a.aggminitval,
+			CASE WHEN a.aggsortop <> 0 THEN 'OPERATOR(' || opn.nspname || '.' || o.oprname || ')' ELSE '' END,
+			p.proparallel::text
+		FROM pg_aggregate a
+		JOIN pg_proc p ON p.oid = a.aggfnoid
+		LEFT JOIN pg_operator o ON o.oid = a.aggsortop
+		LEFT JOIN pg_namespace opn ON opn.oid = o.oprnamespace
+		WHERE a.aggfnoid = $1`, oid).Scan(
+		&args, &aggKind, &transFn, &transType, &transSpace,
+		&finalFn, &finalExtra, &combineFn, &serialFn, &deserialFn,
+		&mTransFn, &mInvTransFn, &mTransType, &mFinalFn, &mFinalExtra,
+		&initVal, &mInitVal, &sortOp, &parallel)
+	if err != nil {
+		return "", err
+	}
+
+	if args == "" {
+		args = "*"
+	}
+
+	options := []string{"SFUNC = " + transFn, "STYPE = " + transType}
+	if transSpace != 0 {
+		options = append(options, fmt.Sprintf("SSPACE = %d", transSpace))
+	}
+	if finalFn != "" {
+		options = append(options, "FINALFUNC = "+finalFn)
+		if finalExtra {
+			options = append(options, "FINALFUNC_EXTRA")
+		}
+	}
+	if combineFn != "" {
+		options = append(options, "COMBINEFUNC = "+combineFn)
+	}
+	if serialFn != "" {
+		options = append(options, "SERIALFUNC = "+serialFn, "DESERIALFUNC = "+deserialFn)
+	}
+	if initVal.Valid {
+		options = append(options, "INITCOND = "+pq.QuoteLiteral(initVal.String))
+	}
+	if mTransFn != "" {
+		options = append(options, "MSFUNC = "+mTransFn, "MINVFUNC = "+mInvTransFn, "MSTYPE = "+mTransType)
+		if mFinalFn != "" {
+			options = append(options, "MFINALFUNC = "+mFinalFn)
+			if mFinalExtra {
+				options = append(options, "MFINALFUNC_EXTRA")
+			}
+		}
+		if mInitVal.Valid {
+			options = append(options, "MINITCOND = "+pq.QuoteLiteral(mInitVal.String))
+		}
+	}
+	if sortOp != "" {
+		options = append(options, "SORTOP = "+sortOp)
+	}
+	if aggKind == "h" {
+		options = append(options, "HYPOTHETICAL")
+	}
+	switch parallel {
+	case "s":
+		options = append(options, "PARALLEL = SAFE")
+	case "r":
+		options = append(options, "PARALLEL = RESTRICTED")
+	}
+
+	return fmt.Sprintf("CREATE AGGREGATE %s(%s) (\n    %s\n)", qualified, args, strings.Join(options, ",\n    ")), nil
+}
@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAggregateDefinitionQuotesNames(t *testing.T) {
+	db, config := testSchema(t, "pgsac_Agg",
+		`CREATE AGGREGATE "pgsac_Agg"."o'brien"(integer) (SFUNC = int4pl, STYPE = integer, INITCOND = '0')`)
+
+	obj := extractTestObject(t, db, config, Options{}, "pgsac_Agg", "o'brien")
+	want := "CREATE AGGREGATE \"pgsac_Agg\".\"o'brien\"(integer) (\n    SFUNC = int4pl,\n    STYPE = integer,\n    INITCOND = '0'\n)"
+	if obj.Definition != want {
+		t.Errorf("Definition =\n%s\nwant\n%s", obj.Definition, want)
+	}
+}
+
+func TestCreateOrReplaceDefinitions(t *testing.T) {
+	db, config := testSchema(t, "pgsac_replace",
+		`CREATE FUNCTION pgsac_replace.add(a integer, b integer) RETURNS integer
+			LANGUAGE sql IMMUTABLE AS 'SELECT a + b'`,
+		`CREATE PROCEDURE pgsac_replace.noop() LANGUAGE sql AS 'SELECT 1'`,
+		`CREATE VIEW pgsac_replace.sums AS SELECT pgsac_replace.add(1, 2) AS total`,
+		`CREATE MATERIALIZED VIEW pgsac_replace.cached AS SELECT 1 AS one`)
+
+	tests := []struct {
+		name   string
+		prefix string
+		replay bool
+	}{
+		{name: "add", prefix: "CREATE OR REPLACE FUNCTION pgsac_replace.add(a integer, b integer)", replay: true},
+		{name: "noop", prefix: "CREATE OR REPLACE PROCEDURE pgsac_replace.noop()", replay: true},
+		{name: "sums", prefix: "CREATE OR REPLACE VIEW pgsac_replace.sums AS\n", replay: true},
+		// Materialized views cannot be replaced
+		{name: "cached", prefix: "CREATE MATERIALIZED VIEW pgsac_replace.cached AS\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := extractTestObject(t, db, config, Options{}, "pgsac_replace", tt.name)
+			if !strings.HasPrefix(obj.Definition, tt.prefix) {
+				t.Fatalf("Definition =\n%s\nwant it to start with\n%s", obj.Definition, tt.prefix)
+			}
+			if !tt.replay {
+				return
+			}
+			// Replaying over the existing object succeeds without dropping it first
+			if _, err := db.Exec(obj.Definition); err != nil {
+				t.Errorf("replaying the definition: %v", err)
+			}
+		})
+	}
+}
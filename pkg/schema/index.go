@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func (e *Extractor) extractIndexes(ctx context.Context, schemaName string) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT i.oid, i.relname, t.relname, pg_get_indexdef(i.oid),
+			EXISTS (SELECT 1 FROM pg_constraint c
+				WHERE c.conindid = x.indexrelid
+					AND c.conrelid = x.indrelid
+					AND c.contype IN ('p', 'u', 'x')),
+			i.relispartition, t.relkind = 'p', quote_ident(n.nspname) || '.' || quote_ident(t.relname)
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = i.relnamespace
+		WHERE n.nspname = $1
+		ORDER BY i.relname`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var (
+			oid                        uint32
+			indexName, tableName       string
+			definition                 string
+			backsConstraint, partition bool
+			partitioned                bool
+			qualifiedTable             string
+		)
+		if err := rows.Scan(&oid, &indexName, &tableName, &definition, &backsConstraint, &partition,
+			&partitioned, &qualifiedTable); err != nil {
+			return nil, fmt.Errorf("error reading index: %w", err)
+		}
+
+		// Indexes backing primary key, unique and exclusion constraints are created by the constraint,
+		// and partition indexes are created by their parent index
+		kind := ""
+		switch {
+		case backsConstraint:
+			kind = "constraint"
+		case partition:
+			kind = "partition"
+		}
+		if !e.decide(Candidate{Schema: schemaName, Name: indexName, Type: IndexType, Kind: kind,
+			Extension: e.extensionOf("pg_class", schemaName, tableName)}) {
+			continue
+		}
+
+		if partitioned {
+			definition = cascadePartitionIndex(definition, qualifiedTable)
+		}
+
+		objects = append(objects, Object{
+			Schema:     schemaName,
+			Name:       indexName,
+			Type:       IndexType,
+			Definition: definition,
+			Depends:    []string{schemaName + "." + tableName},
+			OID:        oid,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing indexes: %w", err)
+	}
+
+	return objects, nil
+}
+
+// cascadePartitionIndex turns the CREATE INDEX ... ON ONLY statement pg_get_indexdef renders
+// for a partitioned table into a plain ON, so that replaying it also creates the indexes of
+// the partitions, which are not extracted on their own, and leaves the parent index valid
+func cascadePartitionIndex(definition, qualifiedTable string) string {
+	return strings.Replace(definition, " ON ONLY "+qualifiedTable+" ", " ON "+qualifiedTable+" ", 1)
+}
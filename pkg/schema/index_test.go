@@ -0,0 +1,38 @@
+package schema
+
+import "testing"
+
+func TestCascadePartitionIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		table      string
+		want       string
+	}{
+		{
+			name:       "partitioned table",
+			definition: "CREATE INDEX events_at_idx ON ONLY public.events USING btree (at)",
+			table:      "public.events",
+			want:       "CREATE INDEX events_at_idx ON public.events USING btree (at)",
+		},
+		{
+			name:       "quoted names",
+			definition: `CREATE UNIQUE INDEX "x ON ONLY y" ON ONLY "My Schema"."Events" USING btree (id)`,
+			table:      `"My Schema"."Events"`,
+			want:       `CREATE UNIQUE INDEX "x ON ONLY y" ON "My Schema"."Events" USING btree (id)`,
+		},
+		{
+			name:       "plain table",
+			definition: "CREATE INDEX t_a_idx ON public.t USING btree (a)",
+			table:      "public.t",
+			want:       "CREATE INDEX t_a_idx ON public.t USING btree (a)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cascadePartitionIndex(tt.definition, tt.table); got != tt.want {
+				t.Errorf("cascadePartitionIndex() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
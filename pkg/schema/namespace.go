@@ -0,0 +1,20 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// CreateStatements returns the statements creating the schema if it does not exist, then
+// setting its owner when withOwner is set and it is known, and its comment
+func (s Schema) CreateStatements(withOwner bool) []string {
+	statements := []string{fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", quoteIdent(s.Name))}
+	if withOwner && s.Owner != "" {
+		statements = append(statements, fmt.Sprintf("ALTER SCHEMA %s OWNER TO %s;", quoteIdent(s.Name), pq.QuoteIdentifier(s.Owner)))
+	}
+	if s.Comment != "" {
+		statements = append(statements, fmt.Sprintf("COMMENT ON SCHEMA %s IS %s;", quoteIdent(s.Name), pq.QuoteLiteral(s.Comment)))
+	}
+	return statements
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/sqltoken"
+)
+
+// normalizeDefinition canonicalizes the layout of a definition so an unchanged object
+// always renders the same: line endings become \n, trailing whitespace is dropped from
+// every line, runs of blank lines collapse into one and leading or trailing blank lines
+// are removed. The SET clauses of a function header, whose order Postgres ignores, are
+// sorted. String literals, quoted identifiers and dollar-quoted bodies are kept byte for
+// byte, and a definition the tokenizer cannot read is left as it is.
+func normalizeDefinition(obj Object) string {
+	tokens, err := sqltoken.Tokenize(obj.Definition)
+	if err != nil {
+		return obj.Definition
+	}
+
+	// Trailing whitespace is dropped along with the leading blank lines
+	for len(tokens) > 0 && isWhitespace(tokens[len(tokens)-1]) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	var b strings.Builder
+	for i, tok := range tokens {
+		switch tok.Kind {
+		case sqltoken.Space:
+			// Spaces before a line break are trailing whitespace
+			if i+1 < len(tokens) && tokens[i+1].Kind == sqltoken.Newline {
+				continue
+			}
+			b.WriteString(tok.Text)
+		case sqltoken.Newline:
+			text := strings.ReplaceAll(tok.Text, "\r\n", "\n")
+			breaks := strings.Count(text, "\n")
+			indent := strings.TrimRight(text[strings.LastIndexByte(text, '\n')+1:], "\r")
+			switch {
+			case b.Len() == 0:
+			case breaks > 1:
+				b.WriteString("\n\n")
+			default:
+				b.WriteString("\n")
+			}
+			b.WriteString(indent)
+		case sqltoken.Comment:
+			if strings.HasPrefix(tok.Text, "--") {
+				b.WriteString(strings.TrimRight(tok.Text, " \t\r"))
+			} else {
+				b.WriteString(tok.Text)
+			}
+		default:
+			b.WriteString(tok.Text)
+		}
+	}
+	definition := b.String()
+
+	if obj.Type == FunctionType {
+		lines := strings.Split(definition, "\n")
+		sortFunctionSettings(lines)
+		definition = strings.Join(lines, "\n")
+	}
+	return definition
+}
+
+// isWhitespace reports whether tok is a run of whitespace
+func isWhitespace(tok sqltoken.Token) bool {
+	return tok.Kind == sqltoken.Space || tok.Kind == sqltoken.Newline
+}
+
+// sortFunctionSettings sorts the " SET name ..." lines pg_get_functiondef puts in the
+// function header, in place. Lines from the body onwards are not touched.
+func sortFunctionSettings(lines []string) {
+	for i := 0; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], " AS ") || strings.HasPrefix(lines[i], "AS ") {
+			return
+		}
+		end := i
+		for end < len(lines) && strings.HasPrefix(lines[end], " SET ") {
+			end++
+		}
+		if end > i {
+			slices.Sort(lines[i:end])
+			i = end - 1
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package schema
+
+import "testing"
+
+func TestNormalizeDefinition(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  Object
+		want string
+	}{
+		{
+			name: "layout",
+			obj:  Object{Type: ViewType, Definition: "\r\n\n CREATE VIEW v AS  \r\n\n\n\n SELECT 1;  \n\n"},
+			want: " CREATE VIEW v AS\n\n SELECT 1;",
+		},
+		{
+			name: "line comment",
+			obj:  Object{Type: ViewType, Definition: "-- note  \nSELECT 1"},
+			want: "-- note\nSELECT 1",
+		},
+		{
+			name: "dollar-quoted body",
+			obj: Object{Type: FunctionType, Definition: "CREATE OR REPLACE FUNCTION public.f()\n" +
+				" RETURNS text\n LANGUAGE plpgsql\nAS $function$\nBEGIN  \r\n\n\n\n  RETURN 'x';   \nEND;\n$function$\n"},
+			want: "CREATE OR REPLACE FUNCTION public.f()\n" +
+				" RETURNS text\n LANGUAGE plpgsql\nAS $function$\nBEGIN  \r\n\n\n\n  RETURN 'x';   \nEND;\n$function$",
+		},
+		{
+			name: "string literal",
+			obj:  Object{Type: ViewType, Definition: "SELECT 'a  \n\n\nb' AS s,  \n\n\n E'c\\'  \n' AS e"},
+			want: "SELECT 'a  \n\n\nb' AS s,\n\n E'c\\'  \n' AS e",
+		},
+		{
+			name: "function settings",
+			obj: Object{Type: FunctionType, Definition: "CREATE OR REPLACE FUNCTION public.f()\n" +
+				" RETURNS void\n SET work_mem TO '64MB'\n SET search_path TO 'public'\nAS $$ SELECT 1 $$"},
+			want: "CREATE OR REPLACE FUNCTION public.f()\n" +
+				" RETURNS void\n SET search_path TO 'public'\n SET work_mem TO '64MB'\nAS $$ SELECT 1 $$",
+		},
+		{
+			name: "unterminated literal",
+			obj:  Object{Type: ViewType, Definition: "SELECT 'a  \n"},
+			want: "SELECT 'a  \n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeDefinition(tt.obj); got != tt.want {
+				t.Errorf("normalizeDefinition() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
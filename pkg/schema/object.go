@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// objectTypeQuery lists the types of the objects of a schema with a given name, in order of
+// preference: relations, functions, types, constraints, indexes (after the constraints
+// they may back), then the other named objects. Constraints are named after their table.
+// Composite types backing relations, and policies and rules, also named after their table,
+// are left out.
+const objectTypeQuery = `SELECT t FROM (
+		SELECT CASE WHEN c.relkind IN ('i', 'I') THEN 5 ELSE 1 END AS rank, CASE c.relkind
+				WHEN 'r' THEN 'table' WHEN 'p' THEN 'table' WHEN 'v' THEN 'view'
+				WHEN 'm' THEN 'materialized_view' WHEN 'S' THEN 'sequence'
+				WHEN 'f' THEN 'foreign_table' WHEN 'i' THEN 'index' WHEN 'I' THEN 'index' END AS t
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1 AND c.relname = $2
+		UNION ALL
+		SELECT 2, 'function'
+			FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE n.nspname = $1 AND p.proname = $2
+		UNION ALL
+		SELECT 3, CASE t.typtype WHEN 'd' THEN 'domain' ELSE 'type' END
+			FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace
+			LEFT JOIN pg_class c ON c.oid = t.typrelid
+			WHERE n.nspname = $1 AND t.typname = $2 AND t.typtype IN ('e', 'c', 'r', 'd')
+				AND (t.typtype <> 'c' OR c.relkind = 'c')
+		UNION ALL
+		SELECT 4, CASE con.contype WHEN 'f' THEN 'foreign_key' ELSE 'constraint' END
+			FROM pg_constraint con JOIN pg_namespace n ON n.oid = con.connamespace
+			JOIN pg_class t ON t.oid = con.conrelid
+			WHERE n.nspname = $1 AND con.conrelid <> 0
+				AND t.relname || '_' || con.conname = $2
+		UNION ALL
+		SELECT 6, 'collation'
+			FROM pg_collation co JOIN pg_namespace n ON n.oid = co.collnamespace
+			WHERE n.nspname = $1 AND co.collname = $2
+		UNION ALL
+		SELECT 7, 'text_search_configuration'
+			FROM pg_ts_config cfg JOIN pg_namespace n ON n.oid = cfg.cfgnamespace
+			WHERE n.nspname = $1 AND cfg.cfgname = $2
+		UNION ALL
+		SELECT 8, 'text_search_dictionary'
+			FROM pg_ts_dict d JOIN pg_namespace n ON n.oid = d.dictnamespace
+			WHERE n.nspname = $1 AND d.dictname = $2
+		UNION ALL
+		SELECT 9, 'operator_family'
+			FROM pg_opfamily f JOIN pg_namespace n ON n.oid = f.opfnamespace
+			WHERE n.nspname = $1 AND f.opfname = $2
+		UNION ALL
+		SELECT 10, 'operator'
+			FROM pg_operator o JOIN pg_namespace n ON n.oid = o.oprnamespace
+			WHERE n.nspname = $1 AND o.oprname = $2
+	) found
+	WHERE t IS NOT NULL
+	ORDER BY rank
+	LIMIT 1`
+
+// ExtractObject extracts a single object, finding its type from the catalog, without
+// extracting the rest of its schema. Objects created by extensions are extracted too.
+// Overloaded functions sharing the name are reported as ambiguous.
+func (e *Extractor) ExtractObject(ctx context.Context, schemaName, name string) (Object, error) {
+	if err := e.checkSearchPath(ctx); err != nil {
+		return Object{}, err
+	}
+	if err := e.checkSchemasExist(ctx, []string{schemaName}); err != nil {
+		return Object{}, err
+	}
+
+	var objType ObjectType
+	if err := e.db.QueryRowContext(ctx, objectTypeQuery, schemaName, name).Scan(&objType); err != nil {
+		return Object{}, fmt.Errorf("object %s.%s not found: %w", schemaName, name, err)
+	}
+
+	members, err := e.listExtensionMembers(ctx)
+	if err != nil {
+		return Object{}, fmt.Errorf("error listing extension members: %w", err)
+	}
+	e.extensionMembers = members
+
+	// The step of the type lists its objects, but only fetches the requested one
+	filter := e.filter
+	e.filter = &Filter{Include: []string{globEscape(schemaName) + "." + globEscape(name)}, IncludeExtensionObjects: true}
+	defer func() { e.filter = filter }()
+
+	for _, step := range e.steps() {
+		if step.objType != objType {
+			continue
+		}
+		objects, err := step.extract(ctx, schemaName)
+		if err != nil {
+			return Object{}, fmt.Errorf("error extracting %s %s.%s: %w", objType, schemaName, name, err)
+		}
+		var found []Object
+		for _, obj := range objects {
+			if obj.Name == name {
+				found = append(found, obj)
+			}
+		}
+		switch len(found) {
+		case 0:
+			// E.g. an identity sequence, created by its column
+			return Object{}, fmt.Errorf("%s %s.%s is not extracted on its own", objType, schemaName, name)
+		case 1:
+			if e.normalize {
+				found[0].Definition = normalizeDefinition(found[0])
+			}
+			return found[0], nil
+		default:
+			return Object{}, fmt.Errorf("%s.%s is ambiguous: %d %ss share the name", schemaName, name, len(found), objType)
+		}
+	}
+	return Object{}, fmt.Errorf("%s %s.%s cannot be extracted", objType, schemaName, name)
+}
+
+// globEscape escapes the characters of a name that path.Match would interpret
+func globEscape(name string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`).Replace(name)
+}
@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// operator is a row of pg_operator listed for extraction
+type operator struct {
+	oid   uint32
+	name  string
+	left  string // Left operand type, empty for prefix operators
+	right string
+}
+
+// args returns the operand types as DROP OPERATOR and COMMENT ON OPERATOR take them
+func (o operator) args() string {
+	left := o.left
+	if left == "" {
+		left = "NONE"
+	}
+	return left + ", " + o.right
+}
+
+func (e *Extractor) extractOperators(ctx context.Context, schemaName string) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT o.oid, o.oprname,
+			CASE WHEN o.oprleft <> 0 THEN format_type(o.oprleft, NULL) ELSE '' END,
+			CASE WHEN o.oprright <> 0 THEN format_type(o.oprright, NULL) ELSE 'NONE' END
+		FROM pg_operator o
+		JOIN pg_namespace n ON n.oid = o.oprnamespace
+		WHERE n.nspname = $1
+		ORDER BY o.oprname, o.oprleft, o.oprright`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing operators: %w", err)
+	}
+
+	var operators []operator
+	for rows.Next() {
+		var o operator
+		if err := rows.Scan(&o.oid, &o.name, &o.left, &o.right); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading operator: %w", err)
+		}
+		operators = append(operators, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing operators: %w", err)
+	}
+
+	var included []operator
+	for _, o := range operators {
+		if e.decide(Candidate{Schema: schemaName, Name: o.name, Type: OperatorType,
+			Extension: e.extensionOf("pg_operator", schemaName, o.name)}) {
+			included = append(included, o)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, o operator) (Object, error) {
+		definition, err := e.operatorDefinition(ctx, schemaName, o)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting operator definition for %s(%s): %w", o.name, o.args(), err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       o.name,
+			Type:       OperatorType,
+			Definition: definition,
+			Args:       o.args(),
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(o.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// operatorDetails holds the pg_operator columns of an operator beyond its name and operands
+type operatorDetails struct {
+	function, restrict, join string // Qualified functions, empty when not set
+	comSchema, comName       string // Commutator, empty when not set
+	negSchema, negName       string // Negator, empty when not set
+	hashes, merges           bool
+}
+
+// operatorDefinition renders the CREATE OPERATOR statement of an operator. Its function
+// and operand types are found by extractDepends.
+func (e *Extractor) operatorDefinition(ctx context.Context, schemaName string, o operator) (string, error) {
+	var d operatorDetails
+	err := e.db.QueryRowContext(ctx, `SELECT quote_ident(pn.nspname) || '.' || quote_ident(p.proname),
+			COALESCE(quote_ident(rn.nspname) || '.' || quote_ident(r.proname), ''),
+			COALESCE(quote_ident(jn.nspname) || '.' || quote_ident(j.proname), ''),
+			COALESCE(cn.nspname, ''), COALESCE(c.oprname, ''),
+			COALESCE(nn.nspname, ''), COALESCE(ng.oprname, ''),
+			o.oprcanhash, o.oprcanmerge
+		FROM pg_operator o
+		JOIN pg_proc p ON p.oid = o.oprcode
+		JOIN pg_namespace pn ON pn.oid = p.pronamespace
+		LEFT JOIN pg_proc r ON r.oid = o.oprrest
+		LEFT JOIN pg_namespace rn ON rn.oid = r.pronamespace
+		LEFT JOIN pg_proc j ON j.oid = o.oprjoin
+		LEFT JOIN pg_namespace jn ON jn.oid = j.pronamespace
+		LEFT JOIN pg_operator c ON c.oid = o.oprcom
+		LEFT JOIN pg_namespace cn ON cn.oid = c.oprnamespace
+		LEFT JOIN pg_operator ng ON ng.oid = o.oprnegate
+		LEFT JOIN pg_namespace nn ON nn.oid = ng.oprnamespace
+		WHERE o.oid = $1`, o.oid).Scan(&d.function, &d.restrict, &d.join, &d.comSchema, &d.comName,
+		&d.negSchema, &d.negName, &d.hashes, &d.merges)
+	if err != nil {
+		return "", err
+	}
+	return renderOperator(schemaName, o, d), nil
+}
+
+// renderOperator renders a CREATE OPERATOR statement. The commutator and negator are not
+// dependencies: operators linked to before they exist are created as shells, filled in by
+// their own definition, and mutual pairs such as < and > would otherwise form a cycle.
+func renderOperator(schemaName string, o operator, d operatorDetails) string {
+	options := []string{"FUNCTION = " + d.function}
+	if o.left != "" {
+		options = append(options, "LEFTARG = "+o.left)
+	}
+	options = append(options, "RIGHTARG = "+o.right)
+
+	for _, link := range []struct{ option, schema, name string }{
+		{"COMMUTATOR", d.comSchema, d.comName},
+		{"NEGATOR", d.negSchema, d.negName},
+	} {
+		if link.name != "" {
+			options = append(options, fmt.Sprintf("%s = OPERATOR(%s.%s)", link.option, quoteIdent(link.schema), link.name))
+		}
+	}
+	if d.restrict != "" {
+		options = append(options, "RESTRICT = "+d.restrict)
+	}
+	if d.join != "" {
+		options = append(options, "JOIN = "+d.join)
+	}
+	if d.hashes {
+		options = append(options, "HASHES")
+	}
+	if d.merges {
+		options = append(options, "MERGES")
+	}
+
+	return fmt.Sprintf("CREATE OPERATOR %s.%s (\n    %s\n)", quoteIdent(schemaName), o.name, strings.Join(options, ",\n    "))
+}
@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderOperator(t *testing.T) {
+	o := operator{name: "<", left: "app.money", right: "app.money"}
+	d := operatorDetails{function: "app.money_lt", restrict: "scalarltsel", join: "scalarltjoinsel",
+		comSchema: "app", comName: ">", negSchema: "app", negName: ">="}
+	want := "CREATE OPERATOR app.< (\n    FUNCTION = app.money_lt,\n    LEFTARG = app.money,\n    RIGHTARG = app.money,\n" +
+		"    COMMUTATOR = OPERATOR(app.>),\n    NEGATOR = OPERATOR(app.>=),\n    RESTRICT = scalarltsel,\n    JOIN = scalarltjoinsel\n)"
+	if got := renderOperator("app", o, d); got != want {
+		t.Errorf("renderOperator() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCommutatorPairSorts(t *testing.T) {
+	// Each operator of the pair names the other, without depending on it; only the function
+	// and operand types found in pg_depend order them
+	pair := []struct {
+		name, function, commutator string
+	}{
+		{"<", "app.money_lt", ">"},
+		{">", "app.money_gt", "<"},
+	}
+	objects := []Object{{Schema: "app", Name: "money", Type: TypeType}}
+	for _, p := range pair {
+		o := operator{name: p.name, left: "app.money", right: "app.money"}
+		d := operatorDetails{function: p.function, comSchema: "app", comName: p.commutator}
+		objects = append(objects, Object{Schema: "app", Name: p.name, Type: OperatorType, Args: o.args(),
+			Definition: renderOperator("app", o, d), Depends: []string{p.function, "app.money"}})
+	}
+	objects = append(objects,
+		Object{Schema: "app", Name: "money_gt", Type: FunctionType, Depends: []string{"app.money"}},
+		Object{Schema: "app", Name: "money_lt", Type: FunctionType, Depends: []string{"app.money"}})
+
+	sorted, err := SortByDependencies(objects)
+	if err != nil {
+		t.Fatalf("SortByDependencies() error = %v", err)
+	}
+	var names []string
+	for _, obj := range sorted {
+		names = append(names, obj.QualifiedName())
+	}
+	want := []string{"app.money", "app.money_gt", "app.>", "app.money_lt", "app.<"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("SortByDependencies() = %v, want %v", names, want)
+	}
+}
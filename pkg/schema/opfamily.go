@@ -0,0 +1,180 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// operatorFamily is a row of pg_opfamily
+type operatorFamily struct {
+	oid    uint32
+	name   string
+	method string
+}
+
+func (e *Extractor) extractOperatorFamilies(ctx context.Context, schemaName string) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT f.oid, f.opfname, a.amname
+		FROM pg_opfamily f
+		JOIN pg_namespace n ON n.oid = f.opfnamespace
+		JOIN pg_am a ON a.oid = f.opfmethod
+		WHERE n.nspname = $1
+		ORDER BY f.opfname, a.amname`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing operator families: %w", err)
+	}
+
+	var families []operatorFamily
+	for rows.Next() {
+		var f operatorFamily
+		if err := rows.Scan(&f.oid, &f.name, &f.method); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading operator family: %w", err)
+		}
+		families = append(families, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing operator families: %w", err)
+	}
+
+	var included []operatorFamily
+	for _, f := range families {
+		// Families of the same name may exist for several access methods
+		name := fmt.Sprintf("%s_%s", f.name, f.method)
+		if e.decide(Candidate{Schema: schemaName, Name: name, Type: OperatorFamilyType,
+			Extension: e.extensionOf("pg_opfamily", schemaName, f.name)}) {
+			included = append(included, f)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, f operatorFamily) (Object, error) {
+		name := fmt.Sprintf("%s_%s", f.name, f.method)
+		definition, depends, err := e.operatorFamilyDefinition(ctx, schemaName, f)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting operator family definition for %s: %w", name, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       name,
+			Type:       OperatorFamilyType,
+			Definition: definition,
+			Depends:    depends,
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(f.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// operatorFamilyDefinition builds the CREATE OPERATOR FAMILY statement followed by an
+// ALTER OPERATOR FAMILY ... ADD listing its operators then its support functions, and
+// returns the operators, functions and types the members reference.
+func (e *Extractor) operatorFamilyDefinition(ctx context.Context, schemaName string, f operatorFamily) (string, []string, error) {
+	qualified := fmt.Sprintf("%s USING %s", qualify(schemaName, f.name), quoteIdent(f.method))
+	deps := newDependencySet()
+
+	var members []string
+
+	opRows, err := e.db.QueryContext(ctx, `SELECT o.amopstrategy, o.amopopr::regoperator::text,
+			COALESCE(sf.nspname || '.' || sf.opfname, ''),
+			opn.nspname, op.oprname,
+			ln.nspname, lt.typname, rn.nspname, rt.typname
+		FROM pg_amop o
+		JOIN pg_operator op ON op.oid = o.amopopr
+		JOIN pg_namespace opn ON opn.oid = op.oprnamespace
+		JOIN pg_type lt ON lt.oid = o.amoplefttype
+		JOIN pg_namespace ln ON ln.oid = lt.typnamespace
+		JOIN pg_type rt ON rt.oid = o.amoprighttype
+		JOIN pg_namespace rn ON rn.oid = rt.typnamespace
+		LEFT JOIN (SELECT sf.oid, sn.nspname, sf.opfname
+			FROM pg_opfamily sf JOIN pg_namespace sn ON sn.oid = sf.opfnamespace) sf
+			ON sf.oid = o.amopsortfamily
+		WHERE o.amopfamily = $1
+		ORDER BY o.amopstrategy, o.amoplefttype, o.amoprighttype`, f.oid)
+	if err != nil {
+		return "", nil, fmt.Errorf("error listing operators: %w", err)
+	}
+	for opRows.Next() {
+		var (
+			strategy               int
+			operator, sortFamily   string
+			opSchema, opName       string
+			leftSchema, leftType   string
+			rightSchema, rightType string
+		)
+		if err := opRows.Scan(&strategy, &operator, &sortFamily, &opSchema, &opName,
+			&leftSchema, &leftType, &rightSchema, &rightType); err != nil {
+			opRows.Close()
+			return "", nil, fmt.Errorf("error reading operator: %w", err)
+		}
+
+		member := fmt.Sprintf("OPERATOR %d %s", strategy, operator)
+		if sortFamily != "" {
+			member += " FOR ORDER BY " + sortFamily
+		}
+		members = append(members, member)
+
+		deps.add(opSchema, opName)
+		deps.add(leftSchema, leftType)
+		deps.add(rightSchema, rightType)
+	}
+	opRows.Close()
+	if err := opRows.Err(); err != nil {
+		return "", nil, fmt.Errorf("error listing operators: %w", err)
+	}
+
+	procRows, err := e.db.QueryContext(ctx, `SELECT p.amprocnum,
+			format_type(p.amproclefttype, NULL), format_type(p.amprocrighttype, NULL),
+			p.amproc::regprocedure::text,
+			pn.nspname, pr.proname,
+			ln.nspname, lt.typname, rn.nspname, rt.typname
+		FROM pg_amproc p
+		JOIN pg_proc pr ON pr.oid = p.amproc
+		JOIN pg_namespace pn ON pn.oid = pr.pronamespace
+		JOIN pg_type lt ON lt.oid = p.amproclefttype
+		JOIN pg_namespace ln ON ln.oid = lt.typnamespace
+		JOIN pg_type rt ON rt.oid = p.amprocrighttype
+		JOIN pg_namespace rn ON rn.oid = rt.typnamespace
+		WHERE p.amprocfamily = $1
+		ORDER BY p.amprocnum, p.amproclefttype, p.amprocrighttype`, f.oid)
+	if err != nil {
+		return "", nil, fmt.Errorf("error listing support functions: %w", err)
+	}
+	for procRows.Next() {
+		var (
+			number                 int
+			left, right, function  string
+			procSchema, procName   string
+			leftSchema, leftType   string
+			rightSchema, rightType string
+		)
+		if err := procRows.Scan(&number, &left, &right, &function, &procSchema, &procName,
+			&leftSchema, &leftType, &rightSchema, &rightType); err != nil {
+			procRows.Close()
+			return "", nil, fmt.Errorf("error reading support function: %w", err)
+		}
+
+		members = append(members, fmt.Sprintf("FUNCTION %d (%s, %s) %s", number, left, right, function))
+
+		deps.add(procSchema, procName)
+		deps.add(leftSchema, leftType)
+		deps.add(rightSchema, rightType)
+	}
+	procRows.Close()
+	if err := procRows.Err(); err != nil {
+		return "", nil, fmt.Errorf("error listing support functions: %w", err)
+	}
+
+	definition := fmt.Sprintf("CREATE OPERATOR FAMILY %s", qualified)
+	if len(members) > 0 {
+		definition += fmt.Sprintf(";\n\nALTER OPERATOR FAMILY %s ADD\n    %s", qualified, strings.Join(members, ",\n    "))
+	}
+
+	return definition, deps.list(), nil
+}
@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExtractOperatorFamilies(t *testing.T) {
+	db, config := testSchema(t, "pgsac_opfamily",
+		`CREATE FUNCTION pgsac_opfamily.cmp(integer, bigint) RETURNS integer
+			LANGUAGE sql IMMUTABLE AS 'SELECT btint48cmp($1, $2)'`,
+		`CREATE OPERATOR FAMILY pgsac_opfamily.mixed_ops USING btree`,
+		`ALTER OPERATOR FAMILY pgsac_opfamily.mixed_ops USING btree ADD
+			OPERATOR 5 > (integer, bigint),
+			OPERATOR 1 < (integer, bigint),
+			OPERATOR 3 = (integer, bigint),
+			FUNCTION 1 (integer, bigint) pgsac_opfamily.cmp(integer, bigint)`)
+
+	e := NewExtractor(db, config, Options{Types: []ObjectType{OperatorFamilyType}})
+	schemas, err := e.ExtractSchemas(context.Background(), []string{"pgsac_opfamily"})
+	if err != nil {
+		t.Fatalf("ExtractSchemas() error = %v", err)
+	}
+	if len(schemas[0].Objects) != 1 {
+		t.Fatalf("extracted %d operator families, want 1", len(schemas[0].Objects))
+	}
+	obj := schemas[0].Objects[0]
+
+	if obj.Name != "mixed_ops_btree" {
+		t.Errorf("Name = %q, want mixed_ops_btree", obj.Name)
+	}
+	// Members come in strategy order, operators before support functions
+	want := "CREATE OPERATOR FAMILY pgsac_opfamily.mixed_ops USING btree;\n\n" +
+		"ALTER OPERATOR FAMILY pgsac_opfamily.mixed_ops USING btree ADD\n" +
+		"    OPERATOR 1 <(integer,bigint),\n" +
+		"    OPERATOR 3 =(integer,bigint),\n" +
+		"    OPERATOR 5 >(integer,bigint),\n" +
+		"    FUNCTION 1 (integer, bigint) pgsac_opfamily.cmp(integer,bigint)"
+	if obj.Definition != want {
+		t.Errorf("Definition =\n%s\nwant\n%s", obj.Definition, want)
+	}
+	// Built-in operators and types are not dependencies
+	if want := []string{"pgsac_opfamily.cmp"}; !reflect.DeepEqual(obj.Depends, want) {
+		t.Errorf("Depends = %v, want %v", obj.Depends, want)
+	}
+}
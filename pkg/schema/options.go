@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Options configures an Extractor. The zero value extracts every supported object
+// through catalog queries on the database connection, without tracing filter
+// decisions, without security labels and without logging.
+type Options struct {
+	// Filter decides which listed objects are extracted. Nil uses an empty Filter.
+	Filter *Filter
+	// Types restricts ExtractSchemas to these object types, see ParseObjectTypes; the
+	// others are not even listed. Empty extracts every type.
+	Types []ObjectType
+	// IncludeSecurityLabels captures SECURITY LABEL assignments of extracted objects.
+	IncludeSecurityLabels bool
+	// UsePsql fetches the definitions of views, materialized views and functions by running
+	// the psql client (\d+ and \sf) instead of querying the catalog. Objects are always
+	// listed from the catalog, and table definitions still follow TableFormat.
+	UsePsql bool
+	// TableFormat selects how table definitions are rendered. Empty uses TableDDL;
+	// TableDescribe needs the psql client even without UsePsql.
+	TableFormat TableFormat
+	// Concurrency is the number of object definitions fetched at once, which also caps
+	// simultaneous psql processes. Zero or less uses the number of CPUs.
+	Concurrency int
+	// Normalize canonicalizes the whitespace of definitions and the order of storage
+	// parameters, so an unchanged database always extracts byte-identical definitions.
+	Normalize bool
+	// KeepPasswords keeps the password option of user mappings in the statements of
+	// ExtractForeignServers. By default it is replaced with a placeholder.
+	KeepPasswords bool
+	// IncludeSubscriptionConninfo keeps the password in the connection strings of the
+	// statements of ExtractSubscriptions. By default it is replaced with a placeholder.
+	IncludeSubscriptionConninfo bool
+	// MaxRetries is the number of times a query or psql run failing with a transient error,
+	// such as a reset connection, is retried. Zero disables retries.
+	MaxRetries int
+	// RetryDelay is the wait before the first retry, doubled after each attempt.
+	RetryDelay time.Duration
+	// ContinueOnError records the errors of objects, and of object types that cannot be
+	// listed, as Failures and extracts everything else, instead of failing on the first one.
+	ContinueOnError bool
+	// Progress, if set, is called as object types are listed and each time the definition
+	// of an object has been fetched. Calls are serialized.
+	Progress func(Progress)
+	// Logger receives progress and per-object debug records. Nil discards them.
+	Logger *slog.Logger
+}
+
+// Progress reports how far ExtractSchemas has got. Counts cover every object type and
+// schema extracted so far, and Total grows as each object type is listed.
+type Progress struct {
+	Step  string // Object type being extracted, e.g. "tables"
+	Done  int
+	Total int
+}
+
+// StepStats reports the objects of a type extracted by ExtractSchemas and the time it took,
+// over every schema
+type StepStats struct {
+	Step     string // Object type, e.g. "functions"
+	Objects  int
+	Duration time.Duration
+}
+
+// Failure is an error Options.ContinueOnError left an object, or a whole object type, out for
+type Failure struct {
+	Schema string // Empty for objects of the database as a whole
+	Step   string // Object type being extracted, e.g. "functions"
+	Err    error
+}
+
+// TableFormat selects how table definitions are rendered
+type TableFormat string
+
+const (
+	// TableDDL renders a replayable CREATE TABLE statement from the catalog
+	TableDDL TableFormat = "ddl"
+	// TableDescribe keeps the psql \d+ description, readable for review but not replayable
+	TableDescribe TableFormat = "describe"
+)
+
+// ParseTableFormat returns the table format with the given name
+func ParseTableFormat(name string) (TableFormat, error) {
+	switch f := TableFormat(name); f {
+	case TableDDL, TableDescribe:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown table format %q (expected ddl or describe)", name)
+}
@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+)
+
+// CycleError reports objects whose dependencies form a cycle
+type CycleError struct {
+	Cycle []string // Qualified names along the cycle, the first one repeated at the end
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// QualifiedName returns the "schema.name" form used in Depends
+func (o Object) QualifiedName() string {
+	return o.Schema + "." + o.Name
+}
+
+// SortByDependencies orders objects so that each one comes after the objects it depends on.
+// Dependencies on objects missing from the list are ignored, and objects not constrained by
+// a dependency keep their relative order. A *CycleError is returned when no order exists.
+func SortByDependencies(objects []Object) ([]Object, error) {
+	// Several objects may share a name, e.g. overloaded functions
+	byName := make(map[string][]int)
+	for i, obj := range objects {
+		byName[obj.QualifiedName()] = append(byName[obj.QualifiedName()], i)
+	}
+
+	dependents := make([][]int, len(objects))
+	pending := make([]int, len(objects))
+	for i, obj := range objects {
+		for _, dep := range obj.Depends {
+			if dep == obj.QualifiedName() {
+				continue
+			}
+			for _, j := range byName[dep] {
+				dependents[j] = append(dependents[j], i)
+				pending[i]++
+			}
+		}
+	}
+
+	ready := &indexHeap{}
+	for i := range objects {
+		if pending[i] == 0 {
+			heap.Push(ready, i)
+		}
+	}
+
+	sorted := make([]Object, 0, len(objects))
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		sorted = append(sorted, objects[i])
+		for _, j := range dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				heap.Push(ready, j)
+			}
+		}
+	}
+
+	if len(sorted) < len(objects) {
+		return nil, &CycleError{Cycle: findCycle(objects, byName, pending)}
+	}
+	return sorted, nil
+}
+
+// findCycle walks dependencies from an object left unsorted until a name repeats
+func findCycle(objects []Object, byName map[string][]int, pending []int) []string {
+	start := 0
+	for i := range objects {
+		if pending[i] > 0 {
+			start = i
+			break
+		}
+	}
+
+	position := make(map[int]int)
+	var path []int
+	for i := start; ; {
+		if p, seen := position[i]; seen {
+			var names []string
+			for _, j := range path[p:] {
+				names = append(names, objects[j].QualifiedName())
+			}
+			return append(names, objects[i].QualifiedName())
+		}
+		position[i] = len(path)
+		path = append(path, i)
+
+		// Follow any dependency that is itself still unsorted
+		next := -1
+		for _, dep := range objects[i].Depends {
+			if dep == objects[i].QualifiedName() {
+				continue
+			}
+			for _, j := range byName[dep] {
+				if pending[j] > 0 {
+					next = j
+					break
+				}
+			}
+			if next >= 0 {
+				break
+			}
+		}
+		if next < 0 {
+			return []string{objects[i].QualifiedName()}
+		}
+		i = next
+	}
+}
+
+// indexHeap is a min-heap of object positions, keeping the original order among ready objects
+type indexHeap []int
+
+func (h indexHeap) Len() int           { return len(h) }
+func (h indexHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h indexHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *indexHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *indexHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
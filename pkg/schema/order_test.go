@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSortByDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []Object
+		want    []string
+		cycle   []string
+	}{
+		{
+			name: "default calling a function of another schema",
+			objects: []Object{
+				{Schema: "app", Name: "orders", Depends: []string{"ids.gen_id"}},
+				{Schema: "ids", Name: "gen_id"},
+			},
+			want: []string{"ids.gen_id", "app.orders"},
+		},
+		{
+			name: "unconstrained objects keep their order",
+			objects: []Object{
+				{Schema: "app", Name: "c"},
+				{Schema: "app", Name: "v", Depends: []string{"app.t"}},
+				{Schema: "app", Name: "a"},
+				{Schema: "app", Name: "t"},
+			},
+			want: []string{"app.c", "app.a", "app.t", "app.v"},
+		},
+		{
+			name: "overloads and missing or self dependencies",
+			objects: []Object{
+				{Schema: "app", Name: "caller", Depends: []string{"app.f", "other.missing", "app.caller"}},
+				{Schema: "app", Name: "f"},
+				{Schema: "app", Name: "f"},
+			},
+			want: []string{"app.f", "app.f", "app.caller"},
+		},
+		{
+			name: "cycle",
+			objects: []Object{
+				{Schema: "app", Name: "free"},
+				{Schema: "app", Name: "a", Depends: []string{"app.b"}},
+				{Schema: "app", Name: "b", Depends: []string{"app.a"}},
+			},
+			cycle: []string{"app.a", "app.b", "app.a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := SortByDependencies(tt.objects)
+			if tt.cycle != nil {
+				var cycleErr *CycleError
+				if !errors.As(err, &cycleErr) {
+					t.Fatalf("SortByDependencies() error = %v, want a *CycleError", err)
+				}
+				if !reflect.DeepEqual(cycleErr.Cycle, tt.cycle) {
+					t.Errorf("Cycle = %v, want %v", cycleErr.Cycle, tt.cycle)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, obj := range sorted {
+				names = append(names, obj.QualifiedName())
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("SortByDependencies() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// policyCommands maps pg_policy.polcmd codes to the command a policy applies to
+var policyCommands = map[string]string{
+	"*": "ALL",
+	"r": "SELECT",
+	"a": "INSERT",
+	"w": "UPDATE",
+	"d": "DELETE",
+}
+
+// policy is a row-level security policy read from pg_policy
+type policy struct {
+	oid        uint32
+	name       string
+	table      string
+	permissive bool
+	command    string
+	roles      []string // Quoted role names in declaration order, PUBLIC for everyone
+	using      string
+	withCheck  string
+}
+
+// extractPolicies extracts row-level security policies as CREATE POLICY statements.
+// Policy names are only unique per table, so objects are named "<table>_<policy>".
+func (e *Extractor) extractPolicies(ctx context.Context, schemaName string) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT p.oid, p.polname, c.relname, p.polpermissive, p.polcmd::text,
+			ARRAY(
+				SELECT CASE WHEN r.oid = 0 THEN 'PUBLIC' ELSE quote_ident(ro.rolname) END
+				FROM unnest(p.polroles) WITH ORDINALITY AS r(oid, n)
+				LEFT JOIN pg_roles ro ON ro.oid = r.oid
+				ORDER BY r.n
+			),
+			COALESCE(pg_get_expr(p.polqual, p.polrelid), ''),
+			COALESCE(pg_get_expr(p.polwithcheck, p.polrelid), '')
+		FROM pg_policy p
+		JOIN pg_class c ON c.oid = p.polrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		ORDER BY c.relname, p.polname`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing policies: %w", err)
+	}
+
+	var policies []policy
+	for rows.Next() {
+		var p policy
+		if err := rows.Scan(&p.oid, &p.name, &p.table, &p.permissive, &p.command,
+			pq.Array(&p.roles), &p.using, &p.withCheck); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing policies: %w", err)
+	}
+
+	var included []policy
+	for _, p := range policies {
+		if e.decide(Candidate{Schema: schemaName, Name: p.table + "_" + p.name, Type: PolicyType,
+			Extension: e.extensionOf("pg_class", schemaName, p.table)}) {
+			included = append(included, p)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, p policy) (Object, error) {
+		obj := Object{
+			Schema:     schemaName,
+			Name:       p.table + "_" + p.name,
+			Type:       PolicyType,
+			Definition: p.definition(schemaName),
+			Depends:    []string{schemaName + "." + p.table},
+			OID:        p.oid,
+		}
+		// Expressions may call functions or query other tables
+		if err := e.addDepends(ctx, &obj, "pg_policy"); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// definition renders the CREATE POLICY statement
+func (p policy) definition(schemaName string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE POLICY %s ON %s", quoteIdent(p.name), qualify(schemaName, p.table))
+	if p.permissive {
+		b.WriteString("\n    AS PERMISSIVE")
+	} else {
+		b.WriteString("\n    AS RESTRICTIVE")
+	}
+	fmt.Fprintf(&b, "\n    FOR %s", policyCommands[p.command])
+	fmt.Fprintf(&b, "\n    TO %s", strings.Join(p.roles, ", "))
+	if p.using != "" {
+		fmt.Fprintf(&b, "\n    USING (%s)", p.using)
+	}
+	if p.withCheck != "" {
+		fmt.Fprintf(&b, "\n    WITH CHECK (%s)", p.withCheck)
+	}
+	return b.String()
+}
+
+// rowSecurity returns the ALTER TABLE statements enabling, and possibly forcing, row-level
+// security on a table. They live with the table so they are kept even without policies.
+func (e *Extractor) rowSecurity(ctx context.Context, qualified string, oid uint32) ([]string, error) {
+	var enabled, forced bool
+	err := e.db.QueryRowContext(ctx, `SELECT relrowsecurity, relforcerowsecurity FROM pg_class WHERE oid = $1`, oid).
+		Scan(&enabled, &forced)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	if enabled {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", qualified))
+	}
+	if forced {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", qualified))
+	}
+	return statements, nil
+}
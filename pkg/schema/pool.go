@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// fetchAll builds the object of each listed item, fetching definitions on up to
+// e.concurrency goroutines, and returns the objects in listing order so the output does
+// not depend on scheduling. The first error cancels the fetches still running, unless
+// ContinueOnError records it as a failure and leaves the object out.
+func fetchAll[T any](ctx context.Context, e *Extractor, items []T, fetch func(context.Context, T) (Object, error)) ([]Object, error) {
+	objects := make([]Object, len(items))
+	fetched := make([]bool, len(items))
+	e.reportProgress(len(items), 0)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(e.concurrency)
+	for i, item := range items {
+		g.Go(func() error {
+			obj, err := fetch(gctx, item)
+			if err != nil {
+				// A cancelled or timed out run stops even when continuing on errors
+				if !e.continueOnError || ctx.Err() != nil {
+					return err
+				}
+				e.recordFailure(err)
+			} else {
+				objects[i] = obj
+				fetched[i] = true
+			}
+			e.reportProgress(0, 1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	kept := objects[:0]
+	for i, obj := range objects {
+		if fetched[i] {
+			kept = append(kept, obj)
+		}
+	}
+	return kept, nil
+}
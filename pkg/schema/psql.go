@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+)
+
+// execPsql executes a psql command and returns its output
+func (e *Extractor) execPsql(ctx context.Context, command string) (string, error) {
+	args := []string{
+		"-h", e.config.Host,
+		"-p", fmt.Sprintf("%d", e.config.Port),
+		"-U", e.config.User,
+		"-d", e.config.DBName,
+		"-c", command,
+		"--no-align",    // Unaligned output mode
+		"--tuples-only", // Print rows only
+		"-q",            // Run quietly (no messages, only query output)
+	}
+
+	psql := e.config.PsqlPath
+	if psql == "" {
+		psql = "psql"
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := e.retrier.do(ctx, "psql", func(error) bool { return transientPsqlError(stderr.String()) }, func() error {
+		cmd := exec.CommandContext(ctx, psql, args...)
+
+		// Pass the password and SSL files through the environment, and read output as UTF-8
+		// whatever the server or locale encoding
+		cmd.Env = append(cmd.Environ(), e.config.Environ()...)
+		cmd.Env = append(cmd.Env, "PGCLIENTENCODING=UTF8")
+
+		stdout.Reset()
+		stderr.Reset()
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		return cmd.Run()
+	})
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("psql client not found (%s); install it or give its path with --psql-path or PGSAC_PSQL: %w", psql, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("psql error: %w\nstderr: %s", err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
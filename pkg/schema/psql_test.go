@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ofux/pgsac/pkg/database"
+)
+
+// fakePsql writes a psql stand-in printing its arguments, one per line, then the libpq
+// variables it was given, and returns its path
+func fakePsql(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake psql is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "psql")
+	script := `#!/bin/sh
+for arg in "$@"; do echo "arg=$arg"; done
+echo "PGPASSWORD=$PGPASSWORD"
+echo "PGSSLMODE=$PGSSLMODE"
+echo "PGSSLROOTCERT=$PGSSLROOTCERT"
+echo "PGCLIENTENCODING=$PGCLIENTENCODING"
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExecPsqlUsesConfig(t *testing.T) {
+	config := database.Config{Host: "db.example.com", Port: 6432, DBName: "app", User: "alice",
+		Password: "secret", PsqlPath: fakePsql(t)}
+	out, err := NewExtractor(nil, config, Options{}).execPsql(context.Background(), `\d+ app.t`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	for _, want := range [][]string{
+		{"arg=-h", "arg=db.example.com"},
+		{"arg=-p", "arg=6432"},
+		{"arg=-U", "arg=alice"},
+		{"arg=-d", "arg=app"},
+		{"arg=-c", `arg=\d+ app.t`},
+		{"PGPASSWORD=secret"},
+	} {
+		if !containsRun(lines, want) {
+			t.Errorf("psql was not given %q:\n%s", want, out)
+		}
+	}
+}
+
+// containsRun reports whether lines holds run as consecutive lines
+func containsRun(lines, run []string) bool {
+	for i := 0; i+len(run) <= len(lines); i++ {
+		if slices.Equal(lines[i:i+len(run)], run) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExecPsqlReadsUTF8(t *testing.T) {
+	// Whatever the locale asks for, psql output is read as UTF-8
+	t.Setenv("PGCLIENTENCODING", "LATIN1")
+	config := database.Config{Host: "localhost", Port: 5432, DBName: "app", User: "alice", PsqlPath: fakePsql(t)}
+	out, err := NewExtractor(nil, config, Options{}).execPsql(context.Background(), `\d+ app.t`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "PGCLIENTENCODING=UTF8\n") {
+		t.Errorf("psql was not given PGCLIENTENCODING=UTF8:\n%s", out)
+	}
+}
+
+func TestExecPsqlSSLMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string // PGSSLMODE of the environment pgsac runs in
+		sslmode string
+		want    string
+	}{
+		{name: "default matches the connection", want: "disable"},
+		{name: "configured", sslmode: "require", want: "require"},
+		{name: "configured over the environment", env: "verify-full", sslmode: "disable", want: "disable"},
+	}
+	psql := fakePsql(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PGSSLMODE", tt.env)
+			config := database.Config{Host: "localhost", Port: 5432, DBName: "app", User: "alice",
+				SSLMode: tt.sslmode, PsqlPath: psql}
+			out, err := NewExtractor(nil, config, Options{}).execPsql(context.Background(), `\d+ app.t`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "PGSSLMODE=" + tt.want + "\n"; !strings.Contains(out, want) {
+				t.Errorf("psql was not given %s:\n%s", strings.TrimSpace(want), out)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// plainIdentifier matches identifiers that need no quoting unless they are keywords
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// quotedKeywords lists the keywords quote_ident quotes: every keyword that is not
+// unreserved, i.e. reserved, type or function name, and column name keywords
+var quotedKeywords = make(map[string]bool)
+
+func init() {
+	for _, k := range strings.Fields(`
+		all analyse analyze and any array as asc asymmetric both case cast check collate
+		column constraint create current_catalog current_date current_role current_time
+		current_timestamp current_user default deferrable desc distinct do else end except
+		false fetch for foreign from grant group having in initially intersect into lateral
+		leading limit localtime localtimestamp not null offset on only or order placing
+		primary references returning select session_user some symmetric system_user table
+		then to trailing true union unique user using variadic when where window with
+
+		authorization binary collation concurrently cross current_schema freeze full ilike
+		inner is isnull join left like natural notnull outer overlaps right similar
+		tablesample verbose
+
+		between bigint bit boolean char character coalesce dec decimal exists extract float
+		greatest grouping inout int integer interval json json_array json_arrayagg
+		json_exists json_object json_objectagg json_query json_scalar json_serialize
+		json_table json_value least merge_action national nchar none normalize nullif
+		numeric out overlay position precision real row setof smallint substring time
+		timestamp treat trim values varchar xmlattributes xmlconcat xmlelement xmlexists
+		xmlforest xmlnamespaces xmlparse xmlpi xmlroot xmlserialize xmltable`) {
+		quotedKeywords[k] = true
+	}
+}
+
+// quoteIdent quotes an identifier the way quote_ident does: only when it has uppercase
+// or special characters, or is a keyword that cannot be used bare
+func quoteIdent(name string) string {
+	if plainIdentifier.MatchString(name) && !quotedKeywords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// qualify returns the schema-qualified reference to an object, quoted where needed, for
+// use in generated SQL, reg* casts and psql meta-commands alike
+func qualify(schemaName, name string) string {
+	return quoteIdent(schemaName) + "." + quoteIdent(name)
+}
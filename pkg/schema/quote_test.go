@@ -0,0 +1,44 @@
+package schema
+
+import "testing"
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "users", want: "users"},
+		{name: "_tmp$1", want: "_tmp$1"},
+		{name: "MyTable", want: `"MyTable"`},
+		{name: "o'brien", want: `"o'brien"`},
+		{name: `say "hi"`, want: `"say ""hi"""`},
+		{name: "1st", want: `"1st"`},
+		{name: "order", want: `"order"`},
+		{name: "user", want: `"user"`},
+		{name: "integer", want: `"integer"`},
+		{name: "name", want: "name"},
+		{name: "été", want: `"été"`},
+		{name: "", want: `""`},
+	}
+	for _, tt := range tests {
+		if got := quoteIdent(tt.name); got != tt.want {
+			t.Errorf("quoteIdent(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQualify(t *testing.T) {
+	tests := []struct {
+		schema, name, want string
+	}{
+		{"public", "users", "public.users"},
+		{"public", "order", `public."order"`},
+		{"Sales", "MyTable", `"Sales"."MyTable"`},
+		{"app", "a.b", `app."a.b"`},
+	}
+	for _, tt := range tests {
+		if got := qualify(tt.schema, tt.name); got != tt.want {
+			t.Errorf("qualify(%q, %q) = %s, want %s", tt.schema, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package schema
+
+import "regexp"
+
+// RedactedPlaceholder replaces secrets left out of extracted definitions
+const RedactedPlaceholder = "***REDACTED***"
+
+// Redact replaces every match of patterns in the definitions of the objects of schemas
+// with RedactedPlaceholder, and marks the objects changed as Redacted
+func Redact(schemas []Schema, patterns []*regexp.Regexp) []Schema {
+	if len(patterns) == 0 {
+		return schemas
+	}
+	redacted := make([]Schema, len(schemas))
+	for i, s := range schemas {
+		s.Objects = RedactObjects(s.Objects, patterns)
+		redacted[i] = s
+	}
+	return redacted
+}
+
+// RedactObjects redacts the definitions of objects like Redact
+func RedactObjects(objects []Object, patterns []*regexp.Regexp) []Object {
+	if len(patterns) == 0 {
+		return objects
+	}
+	redacted := make([]Object, len(objects))
+	for i, obj := range objects {
+		for _, pattern := range patterns {
+			if definition := pattern.ReplaceAllLiteralString(obj.Definition, RedactedPlaceholder); definition != obj.Definition {
+				obj.Definition = definition
+				obj.Redacted = true
+			}
+		}
+		redacted[i] = obj
+	}
+	return redacted
+}
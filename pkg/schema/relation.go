@@ -0,0 +1,566 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// relation is a row of pg_class listed for extraction
+type relation struct {
+	oid        uint32
+	name       string
+	reloptions []string
+	partition  bool // A partition of a partitioned table
+}
+
+// listRelations lists the relations of a schema having one of the given pg_class relkinds
+func (e *Extractor) listRelations(ctx context.Context, schemaName string, relkinds ...string) ([]relation, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT c.oid, c.relname, COALESCE(c.reloptions, '{}'), c.relispartition
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind::text = ANY($2)
+		ORDER BY c.relname`, schemaName, pq.Array(relkinds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []relation
+	for rows.Next() {
+		var r relation
+		if err := rows.Scan(&r.oid, &r.name, pq.Array(&r.reloptions), &r.partition); err != nil {
+			return nil, err
+		}
+		relations = append(relations, r)
+	}
+	return relations, rows.Err()
+}
+
+func (e *Extractor) extractTables(ctx context.Context, schemaName string) ([]Object, error) {
+	tables, err := e.listRelations(ctx, schemaName, "r", "p")
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
+	}
+
+	var included []relation
+	for _, t := range tables {
+		if e.decide(Candidate{Schema: schemaName, Name: t.name, Type: TableType, Kind: partitionKind(t.partition),
+			Extension: e.extensionOf("pg_class", schemaName, t.name)}) {
+			included = append(included, t)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, t relation) (Object, error) {
+		qualified := qualify(schemaName, t.name)
+		definition, err := e.renderTable(ctx, qualified, t.oid)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting table definition for %s: %w", t.name, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       t.name,
+			Type:       TableType,
+			Definition: definition,
+		}
+		if obj.Depends, err = e.tableParents(ctx, t.oid); err != nil {
+			return Object{}, fmt.Errorf("error getting parents of table %s: %w", t.name, err)
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(t.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// renderTable renders a table definition in the configured table format
+func (e *Extractor) renderTable(ctx context.Context, qualified string, oid uint32) (string, error) {
+	if e.tableFormat != TableDescribe {
+		return e.tableDefinition(ctx, qualified, oid)
+	}
+
+	definition, err := e.execPsql(ctx, `\d+ `+qualified)
+	if err != nil {
+		return "", err
+	}
+
+	// \d+ shows comments and row security for review only; keep them replayable
+	settings, err := e.columnSettings(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	security, err := e.rowSecurity(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	comments, err := e.tableComments(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	for _, stmt := range append(append(settings, security...), comments...) {
+		definition = strings.TrimSpace(definition) + ";\n\n" + stmt
+	}
+	return definition, nil
+}
+
+// identityKinds maps pg_attribute.attidentity codes to their GENERATED clause
+var identityKinds = map[string]string{
+	"a": "ALWAYS",
+	"d": "BY DEFAULT",
+}
+
+// generatedKinds maps pg_attribute.attgenerated codes to the keyword ending their
+// GENERATED ALWAYS AS clause; virtual generated columns come with Postgres 18
+var generatedKinds = map[string]string{
+	"s": "STORED",
+	"v": "VIRTUAL",
+}
+
+// tableColumn is a column of a table read by tableDefinition
+type tableColumn struct {
+	name, dataType, collation string
+	expr                      string // Default or generation expression
+	generated                 string // pg_attribute.attgenerated
+	identity                  string // pg_attribute.attidentity
+	identitySequence          string // Qualified name of the sequence of an identity column
+	sequence                  sequence
+	notNull                   bool
+	local                     bool // Not only inherited from a parent table
+}
+
+// definition renders the column in a CREATE TABLE statement
+func (c tableColumn) definition() string {
+	column := fmt.Sprintf("    %s %s", c.name, c.dataType)
+	if c.collation != "" {
+		column += " COLLATE " + c.collation
+	}
+	return column + c.constraints()
+}
+
+// typedDefinition renders the column in the WITH OPTIONS list of a typed table, or ""
+// when it only has what the type gives it
+func (c tableColumn) typedDefinition() string {
+	constraints := c.constraints()
+	if constraints == "" {
+		return ""
+	}
+	return fmt.Sprintf("    %s WITH OPTIONS%s", c.name, constraints)
+}
+
+// constraints renders the default, generation and NOT NULL clauses of the column
+func (c tableColumn) constraints() string {
+	var column string
+	switch {
+	case identityKinds[c.identity] != "":
+		column += fmt.Sprintf(" GENERATED %s AS IDENTITY", identityKinds[c.identity])
+		if c.identitySequence != "" {
+			column += " (" + c.sequence.identityOptions(c.identitySequence) + ")"
+		}
+	case generatedKinds[c.generated] != "":
+		column += fmt.Sprintf(" GENERATED ALWAYS AS (%s) %s", c.expr, generatedKinds[c.generated])
+	case c.expr != "":
+		column += " DEFAULT " + c.expr
+	}
+	if c.notNull {
+		column += " NOT NULL"
+	}
+	return column
+}
+
+// persistenceKeywords maps pg_class.relpersistence codes to the keyword of CREATE TABLE
+var persistenceKeywords = map[string]string{
+	"u": "UNLOGGED ",
+	"t": "TEMPORARY ",
+}
+
+// tableDefinition renders a CREATE TABLE statement from the table's columns, see
+// renderCreateTable, followed by the statements restoring the rest of the table. Identity
+// columns get their GENERATED ... AS IDENTITY clause with the options of their implicit
+// sequence; serial columns keep their nextval default, their sequence being extracted on
+// its own and owned by the column, see ownedSequences.
+func (e *Extractor) tableDefinition(ctx context.Context, qualified string, oid uint32) (string, error) {
+	columns, err := e.tableColumns(ctx, oid)
+	if err != nil {
+		return "", err
+	}
+
+	var partitionOf, bound, partitionKey, persistence, ofType string
+	var parents []string
+	err = e.db.QueryRowContext(ctx, `SELECT
+			COALESCE((SELECT quote_ident(pn.nspname) || '.' || quote_ident(p.relname)
+				FROM pg_inherits i
+				JOIN pg_class p ON p.oid = i.inhparent
+				JOIN pg_namespace pn ON pn.oid = p.relnamespace
+				WHERE c.relispartition AND i.inhrelid = c.oid), ''),
+			COALESCE(pg_get_expr(c.relpartbound, c.oid), ''),
+			COALESCE(pg_get_partkeydef(c.oid), ''),
+			c.relpersistence::text,
+			COALESCE((SELECT quote_ident(tn.nspname) || '.' || quote_ident(t.typname)
+				FROM pg_type t
+				JOIN pg_namespace tn ON tn.oid = t.typnamespace
+				WHERE t.oid = c.reloftype), ''),
+			ARRAY(SELECT quote_ident(pn.nspname) || '.' || quote_ident(p.relname)
+				FROM pg_inherits i
+				JOIN pg_class p ON p.oid = i.inhparent
+				JOIN pg_namespace pn ON pn.oid = p.relnamespace
+				WHERE NOT c.relispartition AND i.inhrelid = c.oid
+				ORDER BY i.inhseqno)
+		FROM pg_class c
+		WHERE c.oid = $1`, oid).Scan(&partitionOf, &bound, &partitionKey, &persistence, &ofType, pq.Array(&parents))
+	if err != nil {
+		return "", err
+	}
+
+	definition := renderCreateTable(qualified, persistenceKeywords[persistence], columns, partitionOf, bound, ofType, parents)
+	if partitionKey != "" {
+		definition += " PARTITION BY " + partitionKey
+	}
+
+	// heap is the default access method and is left implicit, as is the default tablespace
+	var accessMethod, accessMethodExtension, tablespace string
+	var options []string
+	err = e.db.QueryRowContext(ctx, `SELECT COALESCE(am.amname, ''),
+			COALESCE((SELECT x.extname
+				FROM pg_depend d
+				JOIN pg_extension x ON x.oid = d.refobjid
+				WHERE d.classid = 'pg_am'::regclass AND d.objid = am.oid
+					AND d.refclassid = 'pg_extension'::regclass AND d.deptype = 'e'), ''),
+			COALESCE(ts.spcname, ''),
+			COALESCE(c.reloptions, '{}') || COALESCE(ARRAY(SELECT 'toast.' || o
+				FROM unnest(t.reloptions) o), '{}')
+		FROM pg_class c
+		LEFT JOIN pg_am am ON am.oid = c.relam
+		LEFT JOIN pg_tablespace ts ON ts.oid = c.reltablespace
+		LEFT JOIN pg_class t ON t.oid = c.reltoastrelid
+		WHERE c.oid = $1`, oid).Scan(&accessMethod, &accessMethodExtension, &tablespace, pq.Array(&options))
+	if err != nil {
+		return "", err
+	}
+	if accessMethod != "" && accessMethod != "heap" {
+		definition += " USING " + quoteIdent(accessMethod)
+		// Extensions are exported with the schemas and created before them; an access method
+		// created on its own is not exported at all
+		if accessMethodExtension == "" {
+			e.logger.Warn("table uses an access method no extension provides, which is not exported; create it before replaying",
+				"table", qualified, "access_method", accessMethod)
+		}
+	}
+	if len(options) > 0 {
+		if e.normalize {
+			options = slices.Sorted(slices.Values(options))
+		}
+		definition += fmt.Sprintf(" WITH (%s)", strings.Join(options, ", "))
+	}
+	if tablespace != "" {
+		definition += " TABLESPACE " + quoteIdent(tablespace)
+	}
+
+	owned, err := e.ownedSequences(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	settings, err := e.columnSettings(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	security, err := e.rowSecurity(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	comments, err := e.tableComments(ctx, qualified, oid)
+	if err != nil {
+		return "", err
+	}
+	var statements []string
+	for _, group := range [][]string{owned, settings, security, comments} {
+		statements = append(statements, group...)
+	}
+	for _, stmt := range statements {
+		definition += ";\n\n" + stmt
+	}
+	return definition, nil
+}
+
+// tableColumns reads the columns of a table, in order
+func (e *Extractor) tableColumns(ctx context.Context, oid uint32) ([]tableColumn, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT quote_ident(a.attname), format_type(a.atttypid, a.atttypmod),
+			a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attgenerated::text,
+			CASE WHEN a.attcollation <> 0 AND a.attcollation <> t.typcollation
+				THEN quote_ident(cn.nspname) || '.' || quote_ident(co.collname) ELSE '' END,
+			a.attislocal, a.attidentity::text, COALESCE(ids.name, ''), COALESCE(ids.seqstart, 0), COALESCE(ids.seqincrement, 0),
+			COALESCE(ids.seqmin, 0), COALESCE(ids.seqmax, 0), COALESCE(ids.seqcache, 0), COALESCE(ids.seqcycle, false)
+		FROM pg_attribute a
+		JOIN pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		LEFT JOIN pg_collation co ON co.oid = a.attcollation
+		LEFT JOIN pg_namespace cn ON cn.oid = co.collnamespace
+		LEFT JOIN LATERAL (
+			SELECT quote_ident(sn.nspname) || '.' || quote_ident(s.relname) AS name, sq.*
+			FROM pg_depend dep
+			JOIN pg_class s ON s.oid = dep.objid AND s.relkind = 'S'
+			JOIN pg_namespace sn ON sn.oid = s.relnamespace
+			JOIN pg_sequence sq ON sq.seqrelid = s.oid
+			WHERE dep.classid = 'pg_class'::regclass AND dep.refclassid = 'pg_class'::regclass
+				AND dep.refobjid = a.attrelid AND dep.refobjsubid = a.attnum AND dep.deptype = 'i'
+		) ids ON a.attidentity <> ''
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []tableColumn
+	for rows.Next() {
+		var c tableColumn
+		seq := &c.sequence
+		if err := rows.Scan(&c.name, &c.dataType, &c.notNull, &c.expr, &c.generated, &c.collation,
+			&c.local, &c.identity, &c.identitySequence, &seq.start, &seq.increment, &seq.min, &seq.max, &seq.cache, &seq.cycle); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// renderCreateTable renders the CREATE TABLE statement of a table, up to its partition
+// key: a partition takes its columns from its parent and a typed table from its type, the
+// WITH OPTIONS list holding only what the table adds to them, and a table inheriting from
+// parents lists only the columns it does not get from them alone
+func renderCreateTable(qualified, persistence string, columns []tableColumn, partitionOf, bound, ofType string, parents []string) string {
+	create := fmt.Sprintf("CREATE %sTABLE %s", persistence, qualified)
+	if partitionOf != "" {
+		return fmt.Sprintf("%s PARTITION OF %s %s", create, partitionOf, bound)
+	}
+
+	var definitions []string
+	for _, c := range columns {
+		switch {
+		case ofType != "":
+			if column := c.typedDefinition(); column != "" {
+				definitions = append(definitions, column)
+			}
+		case c.local:
+			definitions = append(definitions, c.definition())
+		}
+	}
+
+	if ofType != "" {
+		create += " OF " + ofType
+		if len(definitions) == 0 {
+			return create
+		}
+	}
+	if len(definitions) == 0 {
+		create += " ()"
+	} else {
+		create += fmt.Sprintf(" (\n%s\n)", strings.Join(definitions, ",\n"))
+	}
+	if len(parents) > 0 {
+		create += fmt.Sprintf(" INHERITS (%s)", strings.Join(parents, ", "))
+	}
+	return create
+}
+
+// tableParents returns the qualified names of what a table is created from: the table a
+// partition belongs to, the parents of an inheriting table and the type of a typed table
+func (e *Extractor) tableParents(ctx context.Context, oid uint32) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT pn.nspname || '.' || p.relname
+		FROM pg_inherits i
+		JOIN pg_class p ON p.oid = i.inhparent
+		JOIN pg_namespace pn ON pn.oid = p.relnamespace
+		WHERE i.inhrelid = $1
+		UNION ALL
+		SELECT tn.nspname || '.' || t.typname
+		FROM pg_class c
+		JOIN pg_type t ON t.oid = c.reloftype
+		JOIN pg_namespace tn ON tn.oid = t.typnamespace
+		WHERE c.oid = $1`, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parents []string
+	for rows.Next() {
+		var parent string
+		if err := rows.Scan(&parent); err != nil {
+			return nil, err
+		}
+		parents = append(parents, parent)
+	}
+	return parents, rows.Err()
+}
+
+// partitionKind returns the filter sub-kind of a table
+func partitionKind(partition bool) string {
+	if partition {
+		return "partition"
+	}
+	return ""
+}
+
+// ownedSequences returns the ALTER SEQUENCE ... OWNED BY statements for sequences owned by
+// the table's columns, e.g. serial columns. They live with the table so the sequence can be
+// created first for the column default.
+func (e *Extractor) ownedSequences(ctx context.Context, qualified string, oid uint32) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT quote_ident(sn.nspname) || '.' || quote_ident(s.relname), quote_ident(a.attname)
+		FROM pg_depend d
+		JOIN pg_class s ON s.oid = d.objid AND s.relkind = 'S'
+		JOIN pg_namespace sn ON sn.oid = s.relnamespace
+		JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE d.classid = 'pg_class'::regclass
+			AND d.refclassid = 'pg_class'::regclass
+			AND d.refobjid = $1
+			AND d.deptype = 'a'
+		ORDER BY a.attnum`, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var sequence, column string
+		if err := rows.Scan(&sequence, &column); err != nil {
+			return nil, err
+		}
+		statements = append(statements, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s", sequence, qualified, column))
+	}
+	return statements, rows.Err()
+}
+
+// storageNames maps pg_attribute.attstorage codes to their SET STORAGE keyword
+var storageNames = map[string]string{
+	"p": "PLAIN",
+	"e": "EXTERNAL",
+	"m": "MAIN",
+	"x": "EXTENDED",
+}
+
+// columnSettings returns the ALTER TABLE statements restoring the per-column tuning of a
+// table: storage strategies differing from the column type's, statistics targets and
+// attribute options such as n_distinct. Columns left at their defaults get none.
+func (e *Extractor) columnSettings(ctx context.Context, qualified string, oid uint32) ([]string, error) {
+	// attstattarget is -1 for the default before Postgres 17 and NULL since
+	rows, err := e.db.QueryContext(ctx, `SELECT quote_ident(a.attname), a.attstorage::text, t.typstorage::text,
+			COALESCE(a.attstattarget, -1)::int, COALESCE(a.attoptions, '{}')
+		FROM pg_attribute a
+		JOIN pg_type t ON t.oid = a.atttypid
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var (
+			column, storage, typeStorage string
+			statistics                   int
+			options                      []string
+		)
+		if err := rows.Scan(&column, &storage, &typeStorage, &statistics, pq.Array(&options)); err != nil {
+			return nil, err
+		}
+
+		alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", qualified, column)
+		if storage != typeStorage {
+			statements = append(statements, fmt.Sprintf("%s SET STORAGE %s", alter, storageNames[storage]))
+		}
+		if statistics >= 0 {
+			statements = append(statements, fmt.Sprintf("%s SET STATISTICS %d", alter, statistics))
+		}
+		if len(options) > 0 {
+			if e.normalize {
+				slices.Sort(options)
+			}
+			statements = append(statements, fmt.Sprintf("%s SET (%s)", alter, strings.Join(options, ", ")))
+		}
+	}
+	return statements, rows.Err()
+}
+
+func (e *Extractor) extractViews(ctx context.Context, schemaName string) ([]Object, error) {
+	return e.extractViewLike(ctx, schemaName, "v", ViewType, "VIEW")
+}
+
+func (e *Extractor) extractMaterializedViews(ctx context.Context, schemaName string) ([]Object, error) {
+	return e.extractViewLike(ctx, schemaName, "m", MaterializedView, "MATERIALIZED VIEW")
+}
+
+// extractViewLike extracts views or materialized views, whose definitions both come from pg_get_viewdef
+func (e *Extractor) extractViewLike(ctx context.Context, schemaName, relkind string, objType ObjectType, keyword string) ([]Object, error) {
+	views, err := e.listRelations(ctx, schemaName, relkind)
+	if err != nil {
+		return nil, fmt.Errorf("error listing %ss: %w", strings.ToLower(keyword), err)
+	}
+
+	var included []relation
+	for _, v := range views {
+		if e.decide(Candidate{Schema: schemaName, Name: v.name, Type: objType, Extension: e.extensionOf("pg_class", schemaName, v.name)}) {
+			included = append(included, v)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, v relation) (Object, error) {
+		definition, err := e.viewDefinition(ctx, schemaName, v, keyword)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting %s definition for %s: %w", strings.ToLower(keyword), v.name, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       v.name,
+			Type:       objType,
+			Definition: definition,
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(v.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// viewDefinition renders a CREATE OR REPLACE VIEW or CREATE MATERIALIZED VIEW statement
+// from pg_get_viewdef, run through psql with UsePsql so the definition replays either way.
+// Materialized views have no OR REPLACE.
+func (e *Extractor) viewDefinition(ctx context.Context, schemaName string, v relation, keyword string) (string, error) {
+	var query string
+	if e.usePsql {
+		var err error
+		if query, err = e.execPsql(ctx, fmt.Sprintf(`SELECT pg_get_viewdef(%d, true)`, v.oid)); err != nil {
+			return "", err
+		}
+	} else if err := e.db.QueryRowContext(ctx, `SELECT pg_get_viewdef($1::oid, true)`, v.oid).Scan(&query); err != nil {
+		return "", err
+	}
+
+	create := "CREATE"
+	if keyword == "VIEW" {
+		create = "CREATE OR REPLACE"
+	}
+	definition := fmt.Sprintf("%s %s %s", create, keyword, qualify(schemaName, v.name))
+	if len(v.reloptions) > 0 {
+		options := v.reloptions
+		if e.normalize {
+			options = slices.Sorted(slices.Values(options))
+		}
+		definition += fmt.Sprintf(" WITH (%s)", strings.Join(options, ", "))
+	}
+	return definition + " AS\n" + strings.TrimSuffix(strings.TrimSpace(query), ";"), nil
+}
@@ -0,0 +1,330 @@
+package schema
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTableColumnDefinition(t *testing.T) {
+	tests := []struct {
+		name   string
+		column tableColumn
+		want   string
+	}{
+		{
+			name: "identity always",
+			column: tableColumn{name: "id", dataType: "integer", identity: "a", notNull: true,
+				identitySequence: "public.t_id_seq",
+				sequence:         sequence{start: 1, increment: 1, min: 1, max: 2147483647, cache: 1}},
+			want: "    id integer GENERATED ALWAYS AS IDENTITY (SEQUENCE NAME public.t_id_seq START WITH 1 INCREMENT BY 1" +
+				" MINVALUE 1 MAXVALUE 2147483647 CACHE 1 NO CYCLE) NOT NULL",
+		},
+		{
+			name: "identity by default",
+			column: tableColumn{name: "id", dataType: "bigint", identity: "d", notNull: true,
+				identitySequence: "public.t_id_seq",
+				sequence:         sequence{start: 100, increment: -2, min: -50, max: 100, cache: 10, cycle: true}},
+			want: "    id bigint GENERATED BY DEFAULT AS IDENTITY (SEQUENCE NAME public.t_id_seq START WITH 100 INCREMENT BY -2" +
+				" MINVALUE -50 MAXVALUE 100 CACHE 10 CYCLE) NOT NULL",
+		},
+		{
+			name:   "serial",
+			column: tableColumn{name: "n", dataType: "integer", expr: "nextval('public.t_n_seq'::regclass)", notNull: true},
+			want:   "    n integer DEFAULT nextval('public.t_n_seq'::regclass) NOT NULL",
+		},
+		{
+			name:   "stored generated",
+			column: tableColumn{name: "total", dataType: "numeric", expr: "(price * qty)", generated: "s"},
+			want:   "    total numeric GENERATED ALWAYS AS ((price * qty)) STORED",
+		},
+		{
+			name:   "virtual generated",
+			column: tableColumn{name: "total", dataType: "numeric", expr: "(price * qty)", generated: "v"},
+			want:   "    total numeric GENERATED ALWAYS AS ((price * qty)) VIRTUAL",
+		},
+		{
+			name:   "collation",
+			column: tableColumn{name: `"Name"`, dataType: "text", collation: `public."C"`},
+			want:   `    "Name" text COLLATE public."C"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.column.definition(); got != tt.want {
+				t.Errorf("definition() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderCreateTable(t *testing.T) {
+	id := tableColumn{name: "id", dataType: "integer", notNull: true, local: true}
+	note := tableColumn{name: "note", dataType: "text", expr: "''::text", local: true}
+	inherited := tableColumn{name: "id", dataType: "integer", notNull: true}
+	typed := tableColumn{name: "name", dataType: "text", local: true}
+
+	tests := []struct {
+		name        string
+		persistence string
+		columns     []tableColumn
+		partitionOf string
+		bound       string
+		ofType      string
+		parents     []string
+		want        string
+	}{
+		{
+			name:    "plain",
+			columns: []tableColumn{id, note},
+			want:    "CREATE TABLE public.t (\n    id integer NOT NULL,\n    note text DEFAULT ''::text\n)",
+		},
+		{
+			name:        "unlogged",
+			persistence: "UNLOGGED ",
+			columns:     []tableColumn{id},
+			want:        "CREATE UNLOGGED TABLE public.t (\n    id integer NOT NULL\n)",
+		},
+		{
+			name:    "inherits",
+			columns: []tableColumn{inherited, note},
+			parents: []string{"public.base", `"Other".base`},
+			want:    "CREATE TABLE public.t (\n    note text DEFAULT ''::text\n) INHERITS (public.base, \"Other\".base)",
+		},
+		{
+			name:    "inherits every column",
+			columns: []tableColumn{inherited},
+			parents: []string{"public.base"},
+			want:    "CREATE TABLE public.t () INHERITS (public.base)",
+		},
+		{
+			name:    "typed",
+			columns: []tableColumn{typed},
+			ofType:  "public.person",
+			want:    "CREATE TABLE public.t OF public.person",
+		},
+		{
+			name:    "typed with options",
+			columns: []tableColumn{id, typed},
+			ofType:  "public.person",
+			want:    "CREATE TABLE public.t OF public.person (\n    id WITH OPTIONS NOT NULL\n)",
+		},
+		{
+			name:        "unlogged partition",
+			persistence: "UNLOGGED ",
+			columns:     []tableColumn{id},
+			partitionOf: "public.events",
+			bound:       "FOR VALUES IN (1)",
+			want:        "CREATE UNLOGGED TABLE public.t PARTITION OF public.events FOR VALUES IN (1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderCreateTable("public.t", tt.persistence, tt.columns, tt.partitionOf, tt.bound, tt.ofType, tt.parents)
+			if got != tt.want {
+				t.Errorf("renderCreateTable() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableDefinitionParents(t *testing.T) {
+	db, config := testSchema(t, "pgsac_parents",
+		`CREATE TYPE pgsac_parents.person AS (name text, age integer)`,
+		`CREATE TABLE pgsac_parents.base (id integer NOT NULL)`,
+		`CREATE UNLOGGED TABLE pgsac_parents.child (note text) INHERITS (pgsac_parents.base)`,
+		`CREATE TABLE pgsac_parents.people OF pgsac_parents.person (name WITH OPTIONS NOT NULL)`)
+
+	child := extractTestObject(t, db, config, Options{}, "pgsac_parents", "child")
+	want := "CREATE UNLOGGED TABLE pgsac_parents.child (\n    note text\n) INHERITS (pgsac_parents.base)"
+	if !strings.HasPrefix(child.Definition, want) {
+		t.Errorf("child definition =\n%s\nwant it to start with\n%s", child.Definition, want)
+	}
+	if !slices.Contains(child.Depends, "pgsac_parents.base") {
+		t.Errorf("child Depends = %v, want pgsac_parents.base", child.Depends)
+	}
+
+	people := extractTestObject(t, db, config, Options{}, "pgsac_parents", "people")
+	want = "CREATE TABLE pgsac_parents.people OF pgsac_parents.person (\n    name WITH OPTIONS NOT NULL\n)"
+	if !strings.HasPrefix(people.Definition, want) {
+		t.Errorf("people definition =\n%s\nwant it to start with\n%s", people.Definition, want)
+	}
+	if !slices.Contains(people.Depends, "pgsac_parents.person") {
+		t.Errorf("people Depends = %v, want pgsac_parents.person", people.Depends)
+	}
+}
+
+func TestTableDefinitionIdentityAndSerial(t *testing.T) {
+	db, config := testSchema(t, "pgsac_identity",
+		`CREATE TABLE pgsac_identity.t (
+			id integer GENERATED ALWAYS AS IDENTITY (START WITH 10 INCREMENT BY 5),
+			n serial,
+			price numeric,
+			qty integer,
+			total numeric GENERATED ALWAYS AS (price * qty) STORED
+		)`)
+
+	table := extractTestObject(t, db, config, Options{}, "pgsac_identity", "t")
+	for _, want := range []string{
+		"    id integer GENERATED ALWAYS AS IDENTITY (SEQUENCE NAME pgsac_identity.t_id_seq START WITH 10 INCREMENT BY 5" +
+			" MINVALUE 1 MAXVALUE 2147483647 CACHE 1 NO CYCLE) NOT NULL",
+		"    n integer DEFAULT nextval('pgsac_identity.t_n_seq'::regclass) NOT NULL",
+		"    total numeric GENERATED ALWAYS AS (",
+		") STORED",
+		"ALTER SEQUENCE pgsac_identity.t_n_seq OWNED BY pgsac_identity.t.n",
+	} {
+		if !strings.Contains(table.Definition, want) {
+			t.Errorf("table definition lacks %q:\n%s", want, table.Definition)
+		}
+	}
+	if strings.Contains(table.Definition, "t_id_seq OWNED BY") {
+		t.Errorf("identity sequence is owned like a serial one:\n%s", table.Definition)
+	}
+}
+
+func TestTableDefinitionAccessMethod(t *testing.T) {
+	db, config := testSchema(t, "pgsac_access_method")
+	// A table access method of its own, using the heap handler, is not heap and comes from
+	// no extension
+	for _, stmt := range []string{
+		"DROP ACCESS METHOD IF EXISTS pgsac_heap CASCADE",
+		"CREATE ACCESS METHOD pgsac_heap TYPE TABLE HANDLER heap_tableam_handler",
+		"CREATE TABLE pgsac_access_method.t (id integer) USING pgsac_heap",
+		"CREATE TABLE pgsac_access_method.plain (id integer)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	t.Cleanup(func() { db.Exec("DROP ACCESS METHOD IF EXISTS pgsac_heap CASCADE") })
+
+	var logs strings.Builder
+	opts := Options{Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+
+	table := extractTestObject(t, db, config, opts, "pgsac_access_method", "t")
+	if want := "CREATE TABLE pgsac_access_method.t (\n    id integer\n) USING pgsac_heap"; !strings.HasPrefix(table.Definition, want) {
+		t.Errorf("table definition =\n%s\nwant it to start with\n%s", table.Definition, want)
+	}
+	if !strings.Contains(logs.String(), "access_method=pgsac_heap") {
+		t.Errorf("no warning about the access method, logs:\n%s", logs.String())
+	}
+
+	logs.Reset()
+	plain := extractTestObject(t, db, config, opts, "pgsac_access_method", "plain")
+	if strings.Contains(plain.Definition, "USING") || strings.Contains(logs.String(), "access method") {
+		t.Errorf("heap table definition =\n%s\nlogs:\n%s", plain.Definition, logs.String())
+	}
+}
+
+func TestTableDefinitionDefaultCallingOtherSchema(t *testing.T) {
+	db, config := testSchema(t, "pgsac_default_ids",
+		`CREATE SEQUENCE pgsac_default_ids.ids`,
+		`CREATE FUNCTION pgsac_default_ids.gen_id() RETURNS bigint
+			LANGUAGE sql AS 'SELECT nextval(''pgsac_default_ids.ids'')'`)
+	testSchema(t, "pgsac_default_app",
+		`CREATE TABLE pgsac_default_app.orders (id bigint DEFAULT pgsac_default_ids.gen_id())`)
+
+	obj := extractTestObject(t, db, config, Options{}, "pgsac_default_app", "orders")
+	if !strings.Contains(obj.Definition, "DEFAULT pgsac_default_ids.gen_id()") {
+		t.Errorf("Definition lost the schema of the default function:\n%s", obj.Definition)
+	}
+	if !slices.Contains(obj.Depends, "pgsac_default_ids.gen_id") {
+		t.Errorf("Depends = %v, want pgsac_default_ids.gen_id", obj.Depends)
+	}
+}
+
+func TestTableDefinitionPartitionBounds(t *testing.T) {
+	db, config := testSchema(t, "pgsac_bounds",
+		`CREATE TABLE pgsac_bounds.hashed (id integer) PARTITION BY HASH (id)`,
+		`CREATE TABLE pgsac_bounds.hashed_1 PARTITION OF pgsac_bounds.hashed FOR VALUES WITH (MODULUS 4, REMAINDER 1)`,
+		`CREATE TABLE pgsac_bounds.events (yr integer, mo integer) PARTITION BY RANGE (yr, mo)`,
+		`CREATE TABLE pgsac_bounds.events_2024h1 PARTITION OF pgsac_bounds.events FOR VALUES FROM (2024, 1) TO (2024, 7)`,
+		`CREATE TABLE pgsac_bounds.events_rest PARTITION OF pgsac_bounds.events DEFAULT`)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "hashed", want: "CREATE TABLE pgsac_bounds.hashed (\n    id integer\n) PARTITION BY HASH (id)"},
+		{name: "hashed_1", want: "CREATE TABLE pgsac_bounds.hashed_1 PARTITION OF pgsac_bounds.hashed FOR VALUES WITH (modulus 4, remainder 1)"},
+		{name: "events", want: "CREATE TABLE pgsac_bounds.events (\n    yr integer,\n    mo integer\n) PARTITION BY RANGE (yr, mo)"},
+		{name: "events_2024h1", want: "CREATE TABLE pgsac_bounds.events_2024h1 PARTITION OF pgsac_bounds.events FOR VALUES FROM (2024, 1) TO (2024, 7)"},
+		{name: "events_rest", want: "CREATE TABLE pgsac_bounds.events_rest PARTITION OF pgsac_bounds.events DEFAULT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := extractTestObject(t, db, config, Options{}, "pgsac_bounds", tt.name)
+			if !strings.HasPrefix(obj.Definition, tt.want) {
+				t.Errorf("definition =\n%s\nwant it to start with\n%s", obj.Definition, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotedIdentifiersRoundTrip(t *testing.T) {
+	db, config := testSchema(t, "pgsac_Quoted",
+		`CREATE TABLE "pgsac_Quoted"."order" ("select" integer)`,
+		`CREATE TABLE "pgsac_Quoted"."MyTable" (id integer)`,
+		`CREATE VIEW "pgsac_Quoted"."MyView" AS SELECT "select" FROM "pgsac_Quoted"."order"`)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "order", want: "CREATE TABLE \"pgsac_Quoted\".\"order\" (\n    \"select\" integer\n)"},
+		{name: "MyTable", want: "CREATE TABLE \"pgsac_Quoted\".\"MyTable\" (\n    id integer\n)"},
+		{name: "MyView", want: "CREATE OR REPLACE VIEW \"pgsac_Quoted\".\"MyView\" AS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := extractTestObject(t, db, config, Options{}, "pgsac_Quoted", tt.name)
+			if !strings.HasPrefix(obj.Definition, tt.want) {
+				t.Errorf("definition =\n%s\nwant it to start with\n%s", obj.Definition, tt.want)
+			}
+		})
+	}
+
+	// psql meta-commands get the same quoting
+	config.PsqlPath = fakePsql(t)
+	table := extractTestObject(t, db, config, Options{TableFormat: TableDescribe}, "pgsac_Quoted", "MyTable")
+	if want := `arg=\d+ "pgsac_Quoted"."MyTable"`; !strings.Contains(table.Definition, want) {
+		t.Errorf("psql was not given %s:\n%s", want, table.Definition)
+	}
+
+	// Views fetched with psql are still replayable statements
+	view := extractTestObject(t, db, config, Options{UsePsql: true}, "pgsac_Quoted", "MyView")
+	if want := "CREATE OR REPLACE VIEW \"pgsac_Quoted\".\"MyView\" AS\n"; !strings.HasPrefix(view.Definition, want) {
+		t.Errorf("definition =\n%s\nwant it to start with\n%s", view.Definition, want)
+	}
+	if !strings.Contains(view.Definition, "arg=SELECT pg_get_viewdef(") {
+		t.Errorf("psql was not asked for pg_get_viewdef:\n%s", view.Definition)
+	}
+}
+
+// Names that broke splitting psql list output on "|" and trimming its fields are listed
+// from the catalog as they are
+func TestListTablesWithAwkwardNames(t *testing.T) {
+	names := []string{"Name", " padded ", "a|b", "line\nbreak", "plain"}
+	var statements []string
+	for _, name := range names {
+		statements = append(statements, "CREATE TABLE pgsac_listing."+quoteIdent(name)+" (id integer)")
+	}
+	db, config := testSchema(t, "pgsac_listing", statements...)
+
+	e := NewExtractor(db, config, Options{Types: []ObjectType{TableType}})
+	schemas, err := e.ExtractSchemas(context.Background(), []string{"pgsac_listing"})
+	if err != nil {
+		t.Fatalf("ExtractSchemas() error = %v", err)
+	}
+	var got []string
+	for _, obj := range schemas[0].Objects {
+		got = append(got, obj.Name)
+	}
+	slices.Sort(got)
+	want := slices.Sorted(slices.Values(names))
+	if !slices.Equal(got, want) {
+		t.Errorf("tables = %q, want %q", got, want)
+	}
+}
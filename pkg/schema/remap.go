@@ -0,0 +1,168 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ofux/pgsac/pkg/sqltoken"
+)
+
+// Remap moves objects between schemas according to mapping (source name to destination
+// name). Several sources may map to one destination, in which case their objects are
+// merged; objects of the same type and name coming from different sources are reported
+// as collisions. Qualified references in definitions, dependencies and identities are
+// rewritten to the destination schemas. Unmapped schemas are kept as-is.
+func Remap(schemas []Schema, mapping map[string]string) ([]Schema, error) {
+	if len(mapping) == 0 {
+		return schemas, nil
+	}
+
+	rewrite := schemaRewriter(mapping)
+
+	var result []Schema
+	index := make(map[string]int)     // Destination name to position in result
+	origin := make(map[string]string) // Destination type/name to source schema
+	var collisions []string
+
+	for _, s := range schemas {
+		dst := s.Name
+		if mapped, ok := mapping[s.Name]; ok {
+			dst = mapped
+		}
+
+		i, ok := index[dst]
+		if !ok {
+			i = len(result)
+			index[dst] = i
+			// Merged schemas keep the owner and comment of the first source
+			result = append(result, Schema{Name: dst, Owner: s.Owner, Comment: s.Comment})
+		}
+
+		for _, obj := range s.Objects {
+			// Argument types may be qualified by a mapped schema too; overloads do not collide
+			obj.Args = rewrite(obj.Args)
+			key := fmt.Sprintf("%s %s.%s", obj.Type, dst, obj.Name)
+			if obj.Args != "" {
+				key += "(" + obj.Args + ")"
+			}
+			if src, seen := origin[key]; seen && src != s.Name {
+				collisions = append(collisions, fmt.Sprintf("%s (from %s and %s)", key, src, s.Name))
+			}
+			origin[key] = s.Name
+
+			obj.Schema = dst
+			obj.Definition = rewrite(obj.Definition)
+
+			// Dependencies name objects by their unquoted QualifiedName
+			depends := make([]string, len(obj.Depends))
+			for j, dep := range obj.Depends {
+				depends[j] = remapName(dep, mapping)
+			}
+			obj.Depends = depends
+
+			if obj.Security != nil {
+				sec := *obj.Security
+				sec.Identity = rewrite(sec.Identity)
+				sec.Grants = make([]string, len(obj.Security.Grants))
+				for j, grant := range obj.Security.Grants {
+					sec.Grants[j] = rewrite(grant)
+				}
+				obj.Security = &sec
+			}
+
+			result[i].Objects = append(result[i].Objects, obj)
+		}
+
+		for _, stmt := range s.DefaultPrivileges {
+			stmt = strings.Replace(stmt, " IN SCHEMA "+quoteIdent(s.Name)+" ", " IN SCHEMA "+quoteIdent(dst)+" ", 1)
+			result[i].DefaultPrivileges = append(result[i].DefaultPrivileges, stmt)
+		}
+	}
+
+	if len(collisions) > 0 {
+		return nil, fmt.Errorf("%d name collision(s) after schema mapping:\n  %s", len(collisions), strings.Join(collisions, "\n  "))
+	}
+	return result, nil
+}
+
+// schemaRewriter returns a function replacing schema qualifiers ("src." or "\"src\".")
+// of every mapped schema in a single pass, so chained or swapped mappings are safe. String
+// literals and comments are left alone, while dollar-quoted function bodies are rewritten
+// like the statement around them. Text the tokenizer cannot read, such as some psql
+// descriptions, is rewritten as a whole.
+func schemaRewriter(mapping map[string]string) func(string) string {
+	var sources []string
+	for src := range mapping {
+		sources = append(sources, src)
+	}
+	// Longest first so a schema never matches as the prefix of another
+	sort.Slice(sources, func(i, j int) bool { return len(sources[i]) > len(sources[j]) })
+
+	var alternatives []string
+	for _, src := range sources {
+		alternatives = append(alternatives, regexp.QuoteMeta(src), regexp.QuoteMeta(`"`+src+`"`))
+	}
+	re := regexp.MustCompile(`(^|[^\w".$])(` + strings.Join(alternatives, "|") + `)\.`)
+
+	replace := func(code string) string {
+		return re.ReplaceAllStringFunc(code, func(match string) string {
+			sub := re.FindStringSubmatch(match)
+			src := strings.Trim(sub[2], `"`)
+			return sub[1] + quoteIdent(mapping[src]) + "."
+		})
+	}
+
+	var rewrite func(string) string
+	rewrite = func(text string) string {
+		tokens, err := sqltoken.Tokenize(text)
+		if err != nil {
+			return replace(text)
+		}
+		var b, code strings.Builder
+		for _, tok := range tokens {
+			// Quoted identifiers may be qualifiers themselves
+			if tok.Kind != sqltoken.Comment && (tok.Kind != sqltoken.Literal || strings.HasPrefix(tok.Text, `"`)) {
+				code.WriteString(tok.Text)
+				continue
+			}
+			b.WriteString(replace(code.String()))
+			code.Reset()
+			if tag, body, ok := dollarQuoted(tok.Text); ok {
+				b.WriteString(tag + rewrite(body) + tag)
+			} else {
+				b.WriteString(tok.Text)
+			}
+		}
+		b.WriteString(replace(code.String()))
+		return b.String()
+	}
+	return rewrite
+}
+
+// dollarQuoted splits a $tag$...$tag$ literal into its tag and body
+func dollarQuoted(literal string) (tag, body string, ok bool) {
+	if !strings.HasPrefix(literal, "$") {
+		return "", "", false
+	}
+	end := strings.IndexByte(literal[1:], '$') + 2
+	tag = literal[:end]
+	return tag, literal[len(tag) : len(literal)-len(tag)], true
+}
+
+// remapName moves an unquoted qualified name, as found in Object.Depends, to its
+// destination schema
+func remapName(name string, mapping map[string]string) string {
+	// The longest source wins, should a schema name contain a dot
+	src := ""
+	for s := range mapping {
+		if strings.HasPrefix(name, s+".") && len(s) > len(src) {
+			src = s
+		}
+	}
+	if src == "" {
+		return name
+	}
+	return mapping[src] + strings.TrimPrefix(name, src)
+}
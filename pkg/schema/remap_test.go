@@ -0,0 +1,82 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaRewriter(t *testing.T) {
+	rewrite := schemaRewriter(map[string]string{"tenant_a": "user", "Tenant B": "tenant_b", "old": "new"})
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"keyword destination", "SELECT * FROM tenant_a.t", `SELECT * FROM "user".t`},
+		{"quoted source", `SELECT * FROM "Tenant B".t JOIN "old"."T" USING (id)`, `SELECT * FROM tenant_b.t JOIN new."T" USING (id)`},
+		{"prefix of another name", "SELECT * FROM bold.t, old.t, x.old.t", "SELECT * FROM bold.t, new.t, x.old.t"},
+		{"string literal", "SELECT 'old.t', E'old.\\'t' FROM old.t", "SELECT 'old.t', E'old.\\'t' FROM new.t"},
+		{"comments", "-- old.t\nSELECT /* old.t */ 1 FROM old.t", "-- old.t\nSELECT /* old.t */ 1 FROM new.t"},
+		{
+			"function body",
+			"CREATE FUNCTION old.f() RETURNS int AS $f$ SELECT count(*) FROM old.t WHERE s <> 'old.t' $f$",
+			"CREATE FUNCTION new.f() RETURNS int AS $f$ SELECT count(*) FROM new.t WHERE s <> 'old.t' $f$",
+		},
+		{"unreadable text", "Table old.t -- it's", "Table new.t -- it's"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewrite(tt.text); got != tt.want {
+				t.Errorf("rewrite() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemap(t *testing.T) {
+	schemas := []Schema{
+		{Name: "public", Owner: "app", Objects: []Object{
+			{Schema: "public", Name: "v", Type: ViewType, Definition: "CREATE VIEW public.v AS SELECT * FROM public.t", Depends: []string{"public.t"}},
+			{Schema: "public", Name: "t", Type: TableType, Definition: "CREATE TABLE public.t (id int)"},
+		}},
+		{Name: "other", Objects: []Object{
+			{Schema: "other", Name: "u", Type: TableType, Definition: "CREATE TABLE other.u (id int)"},
+		}},
+	}
+
+	remapped, err := Remap(schemas, map[string]string{"public": "user"})
+	if err != nil {
+		t.Fatalf("Remap() error = %v", err)
+	}
+	if remapped[0].Name != "user" || remapped[0].Owner != "app" || remapped[1].Name != "other" {
+		t.Fatalf("Remap() schemas = %+v", remapped)
+	}
+	view := remapped[0].Objects[0]
+	if view.Schema != "user" || view.Definition != `CREATE VIEW "user".v AS SELECT * FROM "user".t` {
+		t.Errorf("view = %s %q", view.Schema, view.Definition)
+	}
+	if !reflect.DeepEqual(view.Depends, []string{"user.t"}) {
+		t.Errorf("view Depends = %v, want the unquoted qualified name", view.Depends)
+	}
+
+	// Dependencies still match the moved objects
+	sorted, err := SortByDependencies(remapped[0].Objects)
+	if err != nil {
+		t.Fatalf("SortByDependencies() error = %v", err)
+	}
+	if sorted[0].Name != "t" || sorted[1].Name != "v" {
+		t.Errorf("SortByDependencies() order = %s, %s, want t, v", sorted[0].Name, sorted[1].Name)
+	}
+}
+
+func TestRemapCollisions(t *testing.T) {
+	schemas := []Schema{
+		{Name: "a", Objects: []Object{{Schema: "a", Name: "t", Type: TableType}}},
+		{Name: "b", Objects: []Object{{Schema: "b", Name: "t", Type: TableType}}},
+	}
+	_, err := Remap(schemas, map[string]string{"a": "c", "b": "c"})
+	if err == nil || !strings.Contains(err.Error(), "table c.t (from a and b)") {
+		t.Errorf("Remap() error = %v, want a collision on c.t", err)
+	}
+}
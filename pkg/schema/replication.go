@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ExtractPublications returns the CREATE PUBLICATION statements of the database, with the
+// tables they publish or FOR ALL TABLES. Publications are database-wide and may list tables
+// of several schemas, so these are not tied to any extracted schema.
+func (e *Extractor) ExtractPublications(ctx context.Context) ([]string, error) {
+	statements, err := e.queryStatements(ctx, `SELECT p.pubname, p.puballtables,
+			p.pubinsert, p.pubupdate, p.pubdelete, p.pubtruncate, p.pubviaroot,
+			ARRAY(SELECT quote_ident(n.nspname) || '.' || quote_ident(c.relname)
+				FROM pg_publication_rel r
+				JOIN pg_class c ON c.oid = r.prrelid
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE r.prpubid = p.oid
+				ORDER BY n.nspname, c.relname)
+		FROM pg_publication p
+		ORDER BY p.pubname`, func(rows scanner) (string, error) {
+		var name string
+		var allTables, insert, update, del, truncate, viaRoot bool
+		var tables []string
+		if err := rows.Scan(&name, &allTables, &insert, &update, &del, &truncate, &viaRoot, pq.Array(&tables)); err != nil {
+			return "", err
+		}
+
+		stmt := "CREATE PUBLICATION " + quoteIdent(name)
+		switch {
+		case allTables:
+			stmt += " FOR ALL TABLES"
+		case len(tables) > 0:
+			stmt += " FOR TABLE " + strings.Join(tables, ", ")
+		}
+
+		// Only parameters differing from the defaults are written
+		var params []string
+		var publish []string
+		for _, action := range []struct {
+			name string
+			on   bool
+		}{{"insert", insert}, {"update", update}, {"delete", del}, {"truncate", truncate}} {
+			if action.on {
+				publish = append(publish, action.name)
+			}
+		}
+		if len(publish) < 4 {
+			params = append(params, "publish = "+pq.QuoteLiteral(strings.Join(publish, ", ")))
+		}
+		if viaRoot {
+			params = append(params, "publish_via_partition_root = true")
+		}
+		if len(params) > 0 {
+			stmt += " WITH (" + strings.Join(params, ", ") + ")"
+		}
+		return stmt + ";", nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing publications: %w", err)
+	}
+	return statements, nil
+}
+
+// ExtractSubscriptions returns the CREATE SUBSCRIPTION statements of the current database.
+// Like pg_dump, they do not connect to the publisher when replayed, so subscriptions are
+// created disabled and reuse their existing replication slot once enabled.
+// The password in connection strings is redacted unless Options.IncludeSubscriptionConninfo.
+// Reading connection strings requires superuser privileges; without them no subscription
+// is returned and a warning is logged.
+func (e *Extractor) ExtractSubscriptions(ctx context.Context) ([]string, error) {
+	var allowed bool
+	if err := e.db.QueryRowContext(ctx, `SELECT has_column_privilege('pg_subscription', 'subconninfo', 'SELECT')`).
+		Scan(&allowed); err != nil {
+		return nil, fmt.Errorf("error checking access to subscriptions: %w", err)
+	}
+	if !allowed {
+		e.logger.Warn("skipping subscriptions: reading their connection strings requires superuser privileges")
+		return nil, nil
+	}
+
+	statements, err := e.queryStatements(ctx, `SELECT s.subname, s.subconninfo,
+			COALESCE(s.subslotname, ''), s.subslotname IS NULL, s.subpublications, s.subsynccommit
+		FROM pg_subscription s
+		WHERE s.subdbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY s.subname`, func(rows scanner) (string, error) {
+		var name, conninfo, slot, syncCommit string
+		var noSlot bool
+		var publications []string
+		if err := rows.Scan(&name, &conninfo, &slot, &noSlot, pq.Array(&publications), &syncCommit); err != nil {
+			return "", err
+		}
+		if e.redactConninfo {
+			conninfo = redactConninfo(conninfo)
+		}
+
+		quoted := make([]string, len(publications))
+		for i, publication := range publications {
+			quoted[i] = quoteIdent(publication)
+		}
+		params := []string{"connect = false"}
+		if noSlot {
+			params = append(params, "slot_name = NONE")
+		} else {
+			params = append(params, "slot_name = "+pq.QuoteLiteral(slot))
+		}
+		if syncCommit != "off" {
+			params = append(params, "synchronous_commit = "+pq.QuoteLiteral(syncCommit))
+		}
+
+		return fmt.Sprintf("CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s WITH (%s);",
+			quoteIdent(name), pq.QuoteLiteral(conninfo), strings.Join(quoted, ", "), strings.Join(params, ", ")), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing subscriptions: %w", err)
+	}
+	return statements, nil
+}
+
+var (
+	// conninfoPassword matches the password of a key/value connection string or of the
+	// query of a connection URI
+	conninfoPassword = regexp.MustCompile(`(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s&]+)`)
+	// uriPassword matches the password in the user info of a connection URI
+	uriPassword = regexp.MustCompile(`^(postgres(?:ql)?://[^:/@]*):[^@/]*@`)
+)
+
+// redactConninfo replaces the password of a libpq connection string with a placeholder
+func redactConninfo(conninfo string) string {
+	conninfo = uriPassword.ReplaceAllString(conninfo, "${1}:"+redactedPassword+"@")
+	return conninfoPassword.ReplaceAllString(conninfo, "${1}"+redactedPassword)
+}
@@ -0,0 +1,146 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// retrier retries operations failing with transient errors, doubling the delay after
+// each attempt
+type retrier struct {
+	maxRetries int
+	delay      time.Duration
+	logger     *slog.Logger
+}
+
+// do runs op until it succeeds, fails with an error transient does not accept, the
+// retries are exhausted or the context ends
+func (r retrier) do(ctx context.Context, what string, transient func(error) bool, op func() error) error {
+	delay := r.delay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt > r.maxRetries || ctx.Err() != nil || !transient(err) {
+			return err
+		}
+
+		r.logger.Debug("retrying after transient error", "operation", what, "attempt", attempt, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// transientError reports whether a query error is worth retrying: the connection was
+// lost or refused, or the server is shutting down or starting up. Errors from the
+// statement itself, such as syntax errors or denied permissions, are not.
+func transientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08 is connection exceptions; 57P01-57P03 are shutdowns and startup
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// transientPsqlMessages are the psql error messages of lost or refused connections
+var transientPsqlMessages = []string{
+	"server closed the connection unexpectedly",
+	"connection reset by peer",
+	"connection refused",
+	"could not connect to server",
+	"the database system is starting up",
+	"the database system is shutting down",
+	"terminating connection due to administrator command",
+}
+
+// transientPsqlError reports whether psql failed because of a lost or refused connection,
+// from its error output
+func transientPsqlError(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, msg := range transientPsqlMessages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryingDB retries queries failing with transient errors while being sent. Errors
+// met while reading rows are not retried, as rows may already have been consumed.
+type retryingDB struct {
+	*sql.DB
+	retrier retrier
+}
+
+// QueryContext runs a query, retrying transient failures
+func (db *retryingDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := db.retrier.do(ctx, "query", transientError, func() error {
+		var err error
+		rows, err = db.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext runs a query expected to return at most one row, retrying transient
+// failures. Like sql.Row, errors are deferred until Scan.
+func (db *retryingDB) QueryRowContext(ctx context.Context, query string, args ...any) *row {
+	rows, err := db.QueryContext(ctx, query, args...)
+	return &row{rows: rows, err: err}
+}
+
+// row is the result of QueryRowContext, scanned like sql.Row
+type row struct {
+	rows *sql.Rows
+	err  error
+}
+
+// Scan copies the columns of the first row into dest, or returns sql.ErrNoRows
+func (r *row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	return r.rows.Close()
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ruleStates maps the pg_rewrite.ev_enabled codes of rules not firing in the default
+// origin mode to the ALTER TABLE action restoring them
+var ruleStates = map[string]string{
+	"D": "DISABLE RULE",
+	"R": "ENABLE REPLICA RULE",
+	"A": "ENABLE ALWAYS RULE",
+}
+
+// rule is a query rewrite rule read from pg_rewrite
+type rule struct {
+	oid        uint32
+	name       string
+	table      string
+	definition string // As rendered by pg_get_ruledef
+	enabled    string // pg_rewrite.ev_enabled
+}
+
+// extractRules extracts query rewrite rules as CREATE RULE statements, leaving out the
+// _RETURN rules implementing views. Rule names are only unique per table, so objects are
+// named "<table>_<rule>".
+func (e *Extractor) extractRules(ctx context.Context, schemaName string) ([]Object, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT r.oid, r.rulename, c.relname, pg_get_ruledef(r.oid, true),
+			r.ev_enabled::text
+		FROM pg_rewrite r
+		JOIN pg_class c ON c.oid = r.ev_class
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND r.rulename <> '_RETURN'
+		ORDER BY c.relname, r.rulename`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing rules: %w", err)
+	}
+
+	var rules []rule
+	for rows.Next() {
+		var r rule
+		if err := rows.Scan(&r.oid, &r.name, &r.table, &r.definition, &r.enabled); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing rules: %w", err)
+	}
+
+	var included []rule
+	for _, r := range rules {
+		if e.decide(Candidate{Schema: schemaName, Name: r.table + "_" + r.name, Type: RuleType,
+			Extension: e.extensionOf("pg_class", schemaName, r.table)}) {
+			included = append(included, r)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, r rule) (Object, error) {
+		obj := Object{
+			Schema:     schemaName,
+			Name:       r.table + "_" + r.name,
+			Type:       RuleType,
+			Definition: r.render(schemaName),
+			Depends:    []string{schemaName + "." + r.table},
+			OID:        r.oid,
+		}
+		// Actions may write to other tables or call functions
+		if err := e.addDepends(ctx, &obj, "pg_rewrite"); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// render returns the CREATE RULE statement, followed by the ALTER TABLE restoring the
+// firing mode of a rule that is disabled or fires in replica mode
+func (r rule) render(schemaName string) string {
+	definition := strings.TrimSuffix(strings.TrimSpace(r.definition), ";")
+	if action, ok := ruleStates[r.enabled]; ok {
+		definition += fmt.Sprintf(";\n\nALTER TABLE %s %s %s", qualify(schemaName, r.table), action, quoteIdent(r.name))
+	}
+	return definition
+}
@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// catalogColumns describes where a system catalog keeps ownership and privileges
+type catalogColumns struct {
+	owner string
+	acl   string
+}
+
+// securityCatalogs maps system catalogs (the classoid side of an object address)
+// to their owner and ACL columns
+var securityCatalogs = map[string]catalogColumns{
+	"pg_class":     {owner: "relowner", acl: "relacl"},
+	"pg_proc":      {owner: "proowner", acl: "proacl"},
+	"pg_type":      {owner: "typowner", acl: "typacl"},
+	"pg_namespace": {owner: "nspowner", acl: "nspacl"},
+	"pg_opfamily":  {owner: "opfowner"},
+	"pg_collation": {owner: "collowner"},
+	"pg_ts_config": {owner: "cfgowner"},
+	"pg_ts_dict":   {owner: "dictowner"},
+	"pg_operator":  {owner: "oprowner"},
+}
+
+// objectCatalog tells which system catalog stores an object type and which
+// reg* type resolves a qualified name into its OID. Catalogs without a reg* type
+// are resolved from the OID itself.
+type objectCatalog struct {
+	catalog    string
+	regType    string
+	aclDefault string // acldefault object type code, empty when the catalog has no ACL
+}
+
+// objectCatalogs maps object types to their (classoid, objoid) resolution.
+// Registering a new object type here is enough for it to get owner and ACL handling.
+var objectCatalogs = map[ObjectType]objectCatalog{
+	TableType:        {catalog: "pg_class", regType: "regclass", aclDefault: "r"},
+	ViewType:         {catalog: "pg_class", regType: "regclass", aclDefault: "r"},
+	MaterializedView: {catalog: "pg_class", regType: "regclass", aclDefault: "r"},
+	FunctionType:     {catalog: "pg_proc", regType: "regprocedure", aclDefault: "f"},
+	SequenceType:     {catalog: "pg_class", regType: "regclass", aclDefault: "s"},
+	TypeType:         {catalog: "pg_type", regType: "regtype", aclDefault: "T"},
+	DomainType:       {catalog: "pg_type", regType: "regtype", aclDefault: "T"},
+	ForeignTableType: {catalog: "pg_class", regType: "regclass", aclDefault: "r"},
+
+	OperatorFamilyType:       {catalog: "pg_opfamily", regType: "oid"},
+	OperatorType:             {catalog: "pg_operator", regType: "oid"},
+	CastType:                 {catalog: "pg_cast", regType: "oid"},
+	CollationType:            {catalog: "pg_collation", regType: "oid"},
+	TextSearchConfigType:     {catalog: "pg_ts_config", regType: "oid"},
+	TextSearchDictionaryType: {catalog: "pg_ts_dict", regType: "oid"},
+}
+
+// extractSecurity resolves the object's OID from ref and captures its owner and ACL.
+// ref is a qualified name, or the OID itself for catalogs without a reg* type.
+// Object types without a registered catalog are left untouched.
+func (e *Extractor) extractSecurity(ctx context.Context, obj *Object, ref string) error {
+	cat, ok := objectCatalogs[obj.Type]
+	if !ok {
+		return nil
+	}
+	cols, ok := securityCatalogs[cat.catalog]
+	if !ok {
+		// E.g. casts, which have no owner
+		return nil
+	}
+
+	// A NULL ACL stands for the default privileges
+	acl, defaultACL := "NULL", "NULL"
+	if cols.acl != "" && cat.aclDefault != "" {
+		acl = "o." + cols.acl
+		defaultACL = fmt.Sprintf("acldefault('%s', o.%s)", cat.aclDefault, cols.owner)
+	}
+
+	query := fmt.Sprintf(`SELECT o.oid, i.type, i.identity, pg_get_userbyid(o.%s), %s::text[], %s::text[]
+		FROM %s o, pg_identify_object('%s'::regclass, o.oid, 0) i
+		WHERE o.oid = $1::%s`,
+		cols.owner, acl, defaultACL, cat.catalog, cat.catalog, cat.regType)
+
+	var sec Security
+	err := e.db.QueryRowContext(ctx, query, ref).Scan(&obj.OID, &sec.Kind, &sec.Identity, &sec.Owner,
+		pq.Array(&sec.ACL), pq.Array(&sec.DefaultACL))
+	if err != nil {
+		return fmt.Errorf("error getting ownership of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+	}
+
+	if sec.Grants, err = sec.grantStatements(); err != nil {
+		return fmt.Errorf("error reading privileges of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+	}
+
+	if e.includeSecurityLabels {
+		labels, err := e.extractSecurityLabels(ctx, cat.catalog, obj.OID)
+		if err != nil {
+			return fmt.Errorf("error getting security labels of %s %s.%s: %w", obj.Type, obj.Schema, obj.Name, err)
+		}
+		sec.Labels = labels
+	}
+
+	obj.Security = &sec
+	return nil
+}
+
+// extractSecurityLabels returns the labels attached to an object and its columns
+func (e *Extractor) extractSecurityLabels(ctx context.Context, catalog string, oid uint32) ([]SecurityLabel, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT l.provider, COALESCE(a.attname, ''), l.label
+		FROM pg_seclabel l
+		LEFT JOIN pg_attribute a ON l.classoid = 'pg_class'::regclass
+			AND a.attrelid = l.objoid
+			AND a.attnum = l.objsubid
+			AND l.objsubid > 0
+		WHERE l.classoid = $1::regclass AND l.objoid = $2
+		ORDER BY l.objsubid, l.provider`, catalog, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []SecurityLabel
+	for rows.Next() {
+		var l SecurityLabel
+		if err := rows.Scan(&l.Provider, &l.Column, &l.Label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+	return labels, rows.Err()
+}
+
+// ExtractRoleSecurityLabels returns the SECURITY LABEL statements attached to roles.
+// Roles are cluster-wide, so these are not tied to any extracted schema.
+func (e *Extractor) ExtractRoleSecurityLabels(ctx context.Context) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT l.provider, r.rolname, l.label
+		FROM pg_shseclabel l
+		JOIN pg_roles r ON r.oid = l.objoid
+		WHERE l.classoid = 'pg_authid'::regclass
+		ORDER BY r.rolname, l.provider`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing role security labels: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var provider, role, label string
+		if err := rows.Scan(&provider, &role, &label); err != nil {
+			return nil, fmt.Errorf("error reading role security label: %w", err)
+		}
+		statements = append(statements, fmt.Sprintf("SECURITY LABEL FOR %s ON ROLE %s IS %s;",
+			pq.QuoteIdentifier(provider), pq.QuoteIdentifier(role), pq.QuoteLiteral(label)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing role security labels: %w", err)
+	}
+
+	return statements, nil
+}
+
+// LabelStatements returns the SECURITY LABEL statements restoring the object's labels
+func (s Security) LabelStatements() []string {
+	var statements []string
+	for _, l := range s.Labels {
+		target := fmt.Sprintf("%s %s", strings.ToUpper(s.Kind), s.Identity)
+		if l.Column != "" {
+			target = fmt.Sprintf("COLUMN %s.%s", s.Identity, pq.QuoteIdentifier(l.Column))
+		}
+		statements = append(statements, fmt.Sprintf("SECURITY LABEL FOR %s ON %s IS %s;",
+			pq.QuoteIdentifier(l.Provider), target, pq.QuoteLiteral(l.Label)))
+	}
+	return statements
+}
+
+// OwnerStatement returns the ALTER ... OWNER TO statement restoring the object's owner
+func (s Security) OwnerStatement() string {
+	return fmt.Sprintf("ALTER %s %s OWNER TO %s;", strings.ToUpper(s.Kind), s.Identity, pq.QuoteIdentifier(s.Owner))
+}
@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestObjectCatalogsHaveSecurityColumns(t *testing.T) {
+	for objType, cat := range objectCatalogs {
+		if _, ok := securityCatalogs[cat.catalog]; !ok && cat.catalog != "pg_cast" {
+			t.Errorf("%s is stored in %s, which has no owner columns in securityCatalogs", objType, cat.catalog)
+		}
+		if cat.aclDefault != "" && securityCatalogs[cat.catalog].acl == "" {
+			t.Errorf("%s has an acldefault code but %s has no ACL column", objType, cat.catalog)
+		}
+	}
+}
+
+func TestOwnerStatement(t *testing.T) {
+	sec := Security{Kind: "sequence", Identity: "app.s", Owner: "Deploy"}
+	if got, want := sec.OwnerStatement(), `ALTER SEQUENCE app.s OWNER TO "Deploy";`; got != want {
+		t.Errorf("OwnerStatement() = %q, want %q", got, want)
+	}
+}
+
+func TestLabelStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sec  Security
+		want []string
+	}{
+		{name: "no labels", sec: Security{Kind: "table", Identity: "app.t"}},
+		{
+			name: "table and column labels",
+			sec: Security{Kind: "table", Identity: "app.t", Labels: []SecurityLabel{
+				{Provider: "selinux", Label: "system_u:object_r:sepgsql_table_t:s0"},
+				{Provider: "selinux", Column: "SSN", Label: "system_u:object_r:sepgsql_secret_table_t:s0"},
+			}},
+			want: []string{
+				`SECURITY LABEL FOR "selinux" ON TABLE app.t IS 'system_u:object_r:sepgsql_table_t:s0';`,
+				`SECURITY LABEL FOR "selinux" ON COLUMN app.t."SSN" IS 'system_u:object_r:sepgsql_secret_table_t:s0';`,
+			},
+		},
+		{
+			name: "function label with a quote",
+			sec: Security{Kind: "function", Identity: "app.f(integer)", Labels: []SecurityLabel{
+				{Provider: "pgaudit", Label: "it's audited"},
+			}},
+			want: []string{`SECURITY LABEL FOR "pgaudit" ON FUNCTION app.f(integer) IS 'it''s audited';`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sec.LabelStatements(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LabelStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSecurity(t *testing.T) {
+	db, config := testSchema(t, "pgsac_security",
+		`CREATE SEQUENCE pgsac_security.s`,
+		`GRANT USAGE ON SEQUENCE pgsac_security.s TO PUBLIC`,
+		`CREATE TYPE pgsac_security.mood AS ENUM ('sad', 'happy')`,
+		`REVOKE USAGE ON TYPE pgsac_security.mood FROM PUBLIC`)
+
+	var owner string
+	if err := db.QueryRow("SELECT current_user").Scan(&owner); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		kind   string
+		grants []string
+	}{
+		{name: "s", kind: "sequence", grants: []string{"GRANT USAGE ON SEQUENCE pgsac_security.s TO PUBLIC;"}},
+		{name: "mood", kind: "type", grants: []string{"REVOKE ALL ON TYPE pgsac_security.mood FROM PUBLIC;"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			obj := extractTestObject(t, db, config, Options{}, "pgsac_security", tt.name)
+			sec := obj.Security
+			if sec == nil {
+				t.Fatal("Security is nil")
+			}
+			if sec.Kind != tt.kind || sec.Identity != "pgsac_security."+tt.name || sec.Owner != owner {
+				t.Errorf("Security = %q %q owned by %q, want %q %q owned by %q",
+					sec.Kind, sec.Identity, sec.Owner, tt.kind, "pgsac_security."+tt.name, owner)
+			}
+			if !reflect.DeepEqual(sec.Grants, tt.grants) {
+				t.Errorf("Grants = %q, want %q", sec.Grants, tt.grants)
+			}
+		})
+	}
+}
+
+func TestExtractSecurityLabels(t *testing.T) {
+	db, config := testSchema(t, "pgsac_labels", `CREATE TABLE pgsac_labels.t (id integer)`)
+	if _, err := db.Exec(`SECURITY LABEL ON TABLE pgsac_labels.t IS 'system_u:object_r:sepgsql_table_t:s0'`); err != nil {
+		if strings.Contains(err.Error(), "no security label providers") {
+			t.Skip("no security label provider is loaded")
+		}
+		t.Fatal(err)
+	}
+
+	obj := extractTestObject(t, db, config, Options{IncludeSecurityLabels: true}, "pgsac_labels", "t")
+	if obj.Security == nil || len(obj.Security.Labels) != 1 {
+		t.Fatalf("Security = %+v, want one label", obj.Security)
+	}
+	if got := obj.Security.Labels[0]; got.Column != "" || got.Label != "system_u:object_r:sepgsql_table_t:s0" {
+		t.Errorf("label = %+v, want the table label", got)
+	}
+
+	if obj := extractTestObject(t, db, config, Options{}, "pgsac_labels", "t"); len(obj.Security.Labels) != 0 {
+		t.Errorf("labels extracted without IncludeSecurityLabels: %+v", obj.Security.Labels)
+	}
+}
@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func (e *Extractor) extractSequences(ctx context.Context, schemaName string) ([]Object, error) {
+	sequences, err := e.listSequences(ctx, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sequences: %w", err)
+	}
+
+	var included []string
+	for _, s := range sequences {
+		// Identity sequences are created by their column
+		if e.decide(Candidate{Schema: schemaName, Name: s.name, Type: SequenceType, Kind: s.kind,
+			Extension: e.extensionOf("pg_class", schemaName, s.name)}) {
+			included = append(included, s.name)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, seqName string) (Object, error) {
+		// Get the sequence parameters
+		qualified := qualify(schemaName, seqName)
+		seq, err := e.querySequence(ctx, qualified)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting sequence definition for %s: %w", seqName, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       seqName,
+			Type:       SequenceType,
+			Definition: seq.definition(qualified),
+		}
+		if err := e.extractSecurity(ctx, &obj, qualified); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// listedSequence is a sequence listed for extraction
+type listedSequence struct {
+	name string
+	kind string // "identity" for sequences backing an identity column, empty for standalone and serial ones
+}
+
+// listSequences lists the sequences of a schema, telling identity sequences apart in the
+// same query
+func (e *Extractor) listSequences(ctx context.Context, schemaName string) ([]listedSequence, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT c.relname,
+			CASE WHEN EXISTS (
+				SELECT 1 FROM pg_depend d
+				WHERE d.classid = 'pg_class'::regclass
+					AND d.objid = c.oid
+					AND d.refclassid = 'pg_class'::regclass
+					AND d.refobjsubid > 0
+					AND d.deptype = 'i'
+			) THEN 'identity' ELSE '' END
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind = 'S'
+		ORDER BY c.relname`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sequences []listedSequence
+	for rows.Next() {
+		var s listedSequence
+		if err := rows.Scan(&s.name, &s.kind); err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, s)
+	}
+	return sequences, rows.Err()
+}
+
+// sequence holds the parameters of a sequence
+type sequence struct {
+	dataType  string
+	start     int64
+	increment int64
+	min       int64
+	max       int64
+	cache     int64
+	cycle     bool
+}
+
+func (e *Extractor) querySequence(ctx context.Context, qualified string) (*sequence, error) {
+	var seq sequence
+	err := e.db.QueryRowContext(ctx, `SELECT format_type(s.seqtypid, NULL), s.seqstart, s.seqincrement,
+			s.seqmin, s.seqmax, s.seqcache, s.seqcycle
+		FROM pg_sequence s
+		WHERE s.seqrelid = $1::regclass`, qualified).Scan(
+		&seq.dataType, &seq.start, &seq.increment, &seq.min, &seq.max, &seq.cache, &seq.cycle)
+	if err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// definition renders the CREATE SEQUENCE statement. Ownership by a column is declared
+// with the owning table instead, since the table's default already depends on the sequence.
+func (s *sequence) definition(qualified string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE SEQUENCE %s\n", qualified)
+	fmt.Fprintf(&b, "    AS %s\n", s.dataType)
+	fmt.Fprintf(&b, "    START WITH %d\n", s.start)
+	fmt.Fprintf(&b, "    INCREMENT BY %d\n", s.increment)
+	fmt.Fprintf(&b, "    MINVALUE %d\n", s.min)
+	fmt.Fprintf(&b, "    MAXVALUE %d\n", s.max)
+	fmt.Fprintf(&b, "    CACHE %d", s.cache)
+	if s.cycle {
+		b.WriteString("\n    CYCLE")
+	} else {
+		b.WriteString("\n    NO CYCLE")
+	}
+	return b.String()
+}
+
+// identityOptions renders the sequence options of an identity column, the sequence taking
+// the column's type
+func (s *sequence) identityOptions(qualified string) string {
+	cycle := "NO CYCLE"
+	if s.cycle {
+		cycle = "CYCLE"
+	}
+	return fmt.Sprintf("SEQUENCE NAME %s START WITH %d INCREMENT BY %d MINVALUE %d MAXVALUE %d CACHE %d %s",
+		qualified, s.start, s.increment, s.min, s.max, s.cache, cycle)
+}
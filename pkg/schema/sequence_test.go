@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExtractSequencesSkipsIdentitySequences(t *testing.T) {
+	db, config := testSchema(t, "pgsac_sequences",
+		`CREATE SEQUENCE pgsac_sequences.standalone`,
+		`CREATE TABLE pgsac_sequences.t (id integer GENERATED ALWAYS AS IDENTITY, n serial)`)
+
+	e := NewExtractor(db, config, Options{Types: []ObjectType{SequenceType}})
+	schemas, err := e.ExtractSchemas(context.Background(), []string{"pgsac_sequences"})
+	if err != nil {
+		t.Fatalf("ExtractSchemas() error = %v", err)
+	}
+	var names []string
+	for _, obj := range schemas[0].Objects {
+		names = append(names, obj.Name)
+	}
+	if want := []string{"standalone", "t_n_seq"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sequences = %v, want %v", names, want)
+	}
+}
+
+func TestSequenceDefinition(t *testing.T) {
+	seq := sequence{dataType: "bigint", start: 1, increment: 1, min: 1, max: 9223372036854775807, cache: 1}
+	want := "CREATE SEQUENCE public.s\n    AS bigint\n    START WITH 1\n    INCREMENT BY 1\n    MINVALUE 1\n" +
+		"    MAXVALUE 9223372036854775807\n    CACHE 1\n    NO CYCLE"
+	if got := seq.definition("public.s"); got != want {
+		t.Errorf("definition() =\n%s\nwant\n%s", got, want)
+	}
+}
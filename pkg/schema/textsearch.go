@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// textSearchObject is a text search configuration or dictionary read from the catalog
+type textSearchObject struct {
+	oid  uint32
+	name string
+}
+
+// extractTextSearchDictionaries extracts text search dictionaries as CREATE TEXT SEARCH
+// DICTIONARY statements with their template and options
+func (e *Extractor) extractTextSearchDictionaries(ctx context.Context, schemaName string) ([]Object, error) {
+	dictionaries, err := e.listTextSearchObjects(ctx, `SELECT d.oid, d.dictname
+		FROM pg_ts_dict d
+		JOIN pg_namespace n ON n.oid = d.dictnamespace
+		WHERE n.nspname = $1
+		ORDER BY d.dictname`, schemaName, "pg_ts_dict", TextSearchDictionaryType)
+	if err != nil {
+		return nil, fmt.Errorf("error listing text search dictionaries: %w", err)
+	}
+
+	return fetchAll(ctx, e, dictionaries, func(ctx context.Context, d textSearchObject) (Object, error) {
+		qualified := qualify(schemaName, d.name)
+		var template, options string
+		err := e.db.QueryRowContext(ctx, `SELECT quote_ident(tn.nspname) || '.' || quote_ident(t.tmplname),
+				COALESCE(d.dictinitoption, '')
+			FROM pg_ts_dict d
+			JOIN pg_ts_template t ON t.oid = d.dicttemplate
+			JOIN pg_namespace tn ON tn.oid = t.tmplnamespace
+			WHERE d.oid = $1`, d.oid).Scan(&template, &options)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting text search dictionary definition for %s: %w", d.name, err)
+		}
+
+		definition := fmt.Sprintf("CREATE TEXT SEARCH DICTIONARY %s (\n    TEMPLATE = %s", qualified, template)
+		if options != "" {
+			definition += ",\n    " + options
+		}
+		obj := Object{
+			Schema:     schemaName,
+			Name:       d.name,
+			Type:       TextSearchDictionaryType,
+			Definition: definition + "\n)",
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(d.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// extractTextSearchConfigurations extracts text search configurations as a CREATE TEXT
+// SEARCH CONFIGURATION statement followed by the mapping of each token type. They depend
+// on the dictionaries they map tokens to.
+func (e *Extractor) extractTextSearchConfigurations(ctx context.Context, schemaName string) ([]Object, error) {
+	configurations, err := e.listTextSearchObjects(ctx, `SELECT c.oid, c.cfgname
+		FROM pg_ts_config c
+		JOIN pg_namespace n ON n.oid = c.cfgnamespace
+		WHERE n.nspname = $1
+		ORDER BY c.cfgname`, schemaName, "pg_ts_config", TextSearchConfigType)
+	if err != nil {
+		return nil, fmt.Errorf("error listing text search configurations: %w", err)
+	}
+
+	return fetchAll(ctx, e, configurations, func(ctx context.Context, c textSearchObject) (Object, error) {
+		definition, err := e.textSearchConfigDefinition(ctx, qualify(schemaName, c.name), c.oid)
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting text search configuration definition for %s: %w", c.name, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       c.name,
+			Type:       TextSearchConfigType,
+			Definition: definition,
+		}
+		if err := e.extractSecurity(ctx, &obj, fmt.Sprint(c.oid)); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractDepends(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// textSearchConfigDefinition renders CREATE TEXT SEARCH CONFIGURATION with its parser,
+// then an ALTER ... ADD MAPPING per token type, dictionaries in lookup order
+func (e *Extractor) textSearchConfigDefinition(ctx context.Context, qualified string, oid uint32) (string, error) {
+	var parser string
+	err := e.db.QueryRowContext(ctx, `SELECT quote_ident(pn.nspname) || '.' || quote_ident(p.prsname)
+		FROM pg_ts_config c
+		JOIN pg_ts_parser p ON p.oid = c.cfgparser
+		JOIN pg_namespace pn ON pn.oid = p.prsnamespace
+		WHERE c.oid = $1`, oid).Scan(&parser)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := e.db.QueryContext(ctx, `SELECT t.alias,
+			string_agg(CASE WHEN dn.nspname = 'pg_catalog' THEN quote_ident(d.dictname)
+				ELSE quote_ident(dn.nspname) || '.' || quote_ident(d.dictname) END, ', ' ORDER BY m.mapseqno)
+		FROM pg_ts_config_map m
+		JOIN pg_ts_config c ON c.oid = m.mapcfg
+		JOIN ts_token_type(c.cfgparser) t ON t.tokid = m.maptokentype
+		JOIN pg_ts_dict d ON d.oid = m.mapdict
+		JOIN pg_namespace dn ON dn.oid = d.dictnamespace
+		WHERE m.mapcfg = $1
+		GROUP BY m.maptokentype, t.alias
+		ORDER BY m.maptokentype`, oid)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	statements := []string{fmt.Sprintf("CREATE TEXT SEARCH CONFIGURATION %s (PARSER = %s)", qualified, parser)}
+	for rows.Next() {
+		var token, dictionaries string
+		if err := rows.Scan(&token, &dictionaries); err != nil {
+			return "", err
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TEXT SEARCH CONFIGURATION %s\n    ADD MAPPING FOR %s WITH %s",
+			qualified, quoteIdent(token), dictionaries))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(statements, ";\n\n"), nil
+}
+
+// listTextSearchObjects lists the oid and name of text search objects with query and
+// applies the filter
+func (e *Extractor) listTextSearchObjects(ctx context.Context, query, schemaName, catalog string, objType ObjectType) ([]textSearchObject, error) {
+	rows, err := e.db.QueryContext(ctx, query, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var included []textSearchObject
+	for rows.Next() {
+		var o textSearchObject
+		if err := rows.Scan(&o.oid, &o.name); err != nil {
+			return nil, err
+		}
+		if e.decide(Candidate{Schema: schemaName, Name: o.name, Type: objType,
+			Extension: e.extensionOf(catalog, schemaName, o.name)}) {
+			included = append(included, o)
+		}
+	}
+	return included, rows.Err()
+}
@@ -0,0 +1,390 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/ofux/pgsac/pkg/sqltoken"
+)
+
+// typeKinds maps pg_type.typtype codes of extracted types to their kind
+var typeKinds = map[string]string{
+	"e": "enum",
+	"c": "composite",
+	"r": "range",
+}
+
+func (e *Extractor) extractTypes(ctx context.Context, schemaName string) ([]Object, error) {
+	// Composite types backing tables, views, etc. are part of their relation, and
+	// multirange types are created along with their range type
+	rows, err := e.db.QueryContext(ctx, `SELECT t.oid, t.typname, t.typtype, t.typrelid
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		LEFT JOIN pg_class c ON c.oid = t.typrelid
+		WHERE n.nspname = $1
+			AND t.typtype IN ('e', 'c', 'r')
+			AND (t.typtype <> 'c' OR c.relkind = 'c')
+		ORDER BY t.typname`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing types: %w", err)
+	}
+
+	type typeRow struct {
+		oid     uint32
+		name    string
+		typtype string
+		relid   uint32
+	}
+	var types []typeRow
+	for rows.Next() {
+		var t typeRow
+		if err := rows.Scan(&t.oid, &t.name, &t.typtype, &t.relid); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading type: %w", err)
+		}
+		types = append(types, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing types: %w", err)
+	}
+
+	var included []typeRow
+	for _, t := range types {
+		kind := typeKinds[t.typtype]
+		if e.decide(Candidate{Schema: schemaName, Name: t.name, Type: TypeType, Kind: kind,
+			Extension: e.extensionOf("pg_type", schemaName, t.name)}) {
+			included = append(included, t)
+		}
+	}
+
+	return fetchAll(ctx, e, included, func(ctx context.Context, t typeRow) (Object, error) {
+		kind := typeKinds[t.typtype]
+		qualified := qualify(schemaName, t.name)
+
+		var (
+			definition string
+			depends    []string
+			err        error
+		)
+		switch kind {
+		case "enum":
+			definition, err = e.enumDefinition(ctx, qualified, t.oid)
+		case "composite":
+			definition, depends, err = e.compositeDefinition(ctx, qualified, t.relid)
+		case "range":
+			definition, depends, err = e.rangeDefinition(ctx, qualified, t.oid)
+		}
+		if err != nil {
+			return Object{}, fmt.Errorf("error getting type definition for %s: %w", t.name, err)
+		}
+
+		obj := Object{
+			Schema:     schemaName,
+			Name:       t.name,
+			Type:       TypeType,
+			Definition: definition,
+			Depends:    depends,
+		}
+		if err := e.extractSecurity(ctx, &obj, qualified); err != nil {
+			return Object{}, err
+		}
+		if err := e.extractComments(ctx, &obj); err != nil {
+			return Object{}, err
+		}
+		return obj, nil
+	})
+}
+
+// enumDefinition renders CREATE TYPE ... AS ENUM with labels in their declared order
+func (e *Extractor) enumDefinition(ctx context.Context, qualified string, oid uint32) (string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT enumlabel FROM pg_enum WHERE enumtypid = $1 ORDER BY enumsortorder`, oid)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return "", err
+		}
+		labels = append(labels, "    "+pq.QuoteLiteral(label))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (\n%s\n)", qualified, strings.Join(labels, ",\n")), nil
+}
+
+// EvolveEnum renders the definition of an enum type as the statements creating it in
+// previous, the content of an earlier export, followed by an ALTER TYPE ... ADD VALUE for
+// each label added since, so appending a label appends a statement instead of rewriting
+// CREATE TYPE. The definition is returned as is when previous does not create the type,
+// and with an error when labels were removed or reordered since, which only recreating the
+// type can express.
+func EvolveEnum(previous string, obj Object) (string, error) {
+	statements, err := splitSQL(obj.Definition)
+	if err != nil || len(statements) == 0 {
+		return obj.Definition, nil
+	}
+	name, labels, ok := enumLabels(statements[0])
+	if !ok {
+		return obj.Definition, nil
+	}
+
+	previousStatements, err := splitSQL(previous)
+	if err != nil {
+		return obj.Definition, fmt.Errorf("error reading the previous definition of enum %s: %w", name, err)
+	}
+	// The previous CREATE TYPE and ADD VALUE statements, and the labels they create in order
+	var kept, had []string
+	for _, stmt := range previousStatements {
+		if created, createdLabels, ok := enumLabels(stmt); ok && created == name {
+			kept, had = []string{stmt}, createdLabels
+			continue
+		}
+		if added, label, position, neighbor, ok := enumAddition(stmt); ok && added == name && kept != nil {
+			kept = append(kept, stmt)
+			had = insertLabel(had, label, position, neighbor)
+		}
+	}
+	if kept == nil {
+		return obj.Definition, nil
+	}
+
+	// The labels still there must keep their order, new ones may go anywhere
+	var remaining []string
+	for _, label := range labels {
+		if slices.Contains(had, label) {
+			remaining = append(remaining, label)
+		}
+	}
+	if !slices.Equal(remaining, had) {
+		return obj.Definition, fmt.Errorf("enum %s had labels removed or reordered since the previous export", name)
+	}
+
+	for i, label := range labels {
+		if slices.Contains(had, label) {
+			continue
+		}
+		// Labels before or between existing ones follow the label now preceding them, the
+		// first one precedes the next existing label
+		var stmt string
+		switch next := slices.IndexFunc(labels[i+1:], func(l string) bool { return slices.Contains(had, l) }); {
+		case i > 0:
+			stmt = fmt.Sprintf("ALTER TYPE %s ADD VALUE %s AFTER %s", name, label, labels[i-1])
+		case next >= 0:
+			stmt = fmt.Sprintf("ALTER TYPE %s ADD VALUE %s BEFORE %s", name, label, labels[i+1+next])
+		default:
+			stmt = fmt.Sprintf("ALTER TYPE %s ADD VALUE %s", name, label)
+		}
+		kept = append(kept, stmt)
+		had = append(had, label)
+	}
+	return strings.Join(append(kept, statements[1:]...), ";\n\n"), nil
+}
+
+// splitSQL splits SQL text into its statements, without their comments and terminating
+// semicolons
+func splitSQL(text string) ([]string, error) {
+	tokens, err := sqltoken.Tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	var statements []string
+	var b strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(b.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		b.Reset()
+	}
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == sqltoken.Comment:
+		case tok.Kind == sqltoken.Symbol && tok.Text == ";":
+			flush()
+		default:
+			b.WriteString(tok.Text)
+		}
+	}
+	flush()
+	return statements, nil
+}
+
+// significantTokens returns the tokens of a statement other than whitespace, with the E
+// prefix of escaped string literals joined to them
+func significantTokens(stmt string) []string {
+	tokens, err := sqltoken.Tokenize(stmt)
+	if err != nil {
+		return nil
+	}
+	var texts []string
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == sqltoken.Space || tok.Kind == sqltoken.Newline || tok.Kind == sqltoken.Comment:
+		case tok.Kind == sqltoken.Literal && len(texts) > 0 && strings.EqualFold(texts[len(texts)-1], "E"):
+			texts[len(texts)-1] += tok.Text
+		default:
+			texts = append(texts, tok.Text)
+		}
+	}
+	return texts
+}
+
+// isStringLiteral reports whether a token is a string literal, escaped or not
+func isStringLiteral(text string) bool {
+	return strings.HasPrefix(text, "'") || strings.HasPrefix(strings.ToUpper(text), "E'")
+}
+
+// enumLabels reads a CREATE TYPE ... AS ENUM statement, returning the type name and its
+// labels as quoted literals, as written
+func enumLabels(stmt string) (name string, labels []string, ok bool) {
+	tokens := significantTokens(stmt)
+	if len(tokens) < 2 || !strings.EqualFold(tokens[0], "CREATE") || !strings.EqualFold(tokens[1], "TYPE") {
+		return "", nil, false
+	}
+	i := 2
+	for ; i < len(tokens) && !strings.EqualFold(tokens[i], "AS"); i++ {
+		name += tokens[i]
+	}
+	if i+2 >= len(tokens) || !strings.EqualFold(tokens[i+1], "ENUM") || tokens[i+2] != "(" {
+		return "", nil, false
+	}
+	for _, tok := range tokens[i+3:] {
+		switch {
+		case tok == "," || tok == ")":
+		case isStringLiteral(tok):
+			labels = append(labels, tok)
+		default:
+			return "", nil, false
+		}
+	}
+	return name, labels, true
+}
+
+// enumAddition reads an ALTER TYPE ... ADD VALUE statement, returning the type name, the
+// added label and, if given, BEFORE or AFTER and the neighboring label
+func enumAddition(stmt string) (name, label, position, neighbor string, ok bool) {
+	tokens := significantTokens(stmt)
+	if len(tokens) < 2 || !strings.EqualFold(tokens[0], "ALTER") || !strings.EqualFold(tokens[1], "TYPE") {
+		return "", "", "", "", false
+	}
+	i := 2
+	for ; i+1 < len(tokens) && !(strings.EqualFold(tokens[i], "ADD") && strings.EqualFold(tokens[i+1], "VALUE")); i++ {
+		name += tokens[i]
+	}
+	rest := tokens[min(i+2, len(tokens)):]
+	if len(rest) >= 3 && strings.EqualFold(rest[0], "IF") {
+		rest = rest[3:] // IF NOT EXISTS
+	}
+	switch {
+	case len(rest) == 1 && isStringLiteral(rest[0]):
+		return name, rest[0], "", "", true
+	case len(rest) == 3 && isStringLiteral(rest[0]) && isStringLiteral(rest[2]):
+		return name, rest[0], strings.ToUpper(rest[1]), rest[2], true
+	}
+	return "", "", "", "", false
+}
+
+// insertLabel adds label to labels BEFORE or AFTER neighbor, or last
+func insertLabel(labels []string, label, position, neighbor string) []string {
+	i := slices.Index(labels, neighbor)
+	switch {
+	case i < 0 || position == "":
+		return append(labels, label)
+	case position == "AFTER":
+		i++
+	}
+	return slices.Insert(labels, i, label)
+}
+
+// compositeDefinition renders CREATE TYPE ... AS (...) with attributes in declared order
+func (e *Extractor) compositeDefinition(ctx context.Context, qualified string, relid uint32) (string, []string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT quote_ident(a.attname), format_type(a.atttypid, a.atttypmod),
+			CASE WHEN a.attcollation <> 0 AND a.attcollation <> t.typcollation
+				THEN quote_ident(cn.nspname) || '.' || quote_ident(co.collname) ELSE '' END,
+			tn.nspname, t.typname
+		FROM pg_attribute a
+		JOIN pg_type t ON t.oid = a.atttypid
+		JOIN pg_namespace tn ON tn.oid = t.typnamespace
+		LEFT JOIN pg_collation co ON co.oid = a.attcollation
+		LEFT JOIN pg_namespace cn ON cn.oid = co.collnamespace
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, relid)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	deps := newDependencySet()
+	var attributes []string
+	for rows.Next() {
+		var name, dataType, collation, typeSchema, typeName string
+		if err := rows.Scan(&name, &dataType, &collation, &typeSchema, &typeName); err != nil {
+			return "", nil, err
+		}
+		attribute := fmt.Sprintf("    %s %s", name, dataType)
+		if collation != "" {
+			attribute += " COLLATE " + collation
+		}
+		attributes = append(attributes, attribute)
+		deps.add(typeSchema, typeName)
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("CREATE TYPE %s AS (\n%s\n)", qualified, strings.Join(attributes, ",\n")), deps.list(), nil
+}
+
+// rangeDefinition renders CREATE TYPE ... AS RANGE, omitting options left at their default
+func (e *Extractor) rangeDefinition(ctx context.Context, qualified string, oid uint32) (string, []string, error) {
+	var (
+		subtype, opclass, collation, canonical, subdiff string
+		subtypeSchema, subtypeName                      string
+	)
+	err := e.db.QueryRowContext(ctx, `SELECT format_type(r.rngsubtype, NULL),
+			CASE WHEN opc.opcdefault THEN '' ELSE quote_ident(opcn.nspname) || '.' || quote_ident(opc.opcname) END,
+			CASE WHEN r.rngcollation <> 0 AND r.rngcollation <> st.typcollation
+				THEN quote_ident(cn.nspname) || '.' || quote_ident(co.collname) ELSE '' END,
+			CASE WHEN r.rngcanonical <> 0 THEN r.rngcanonical::regproc::text ELSE '' END,
+			CASE WHEN r.rngsubdiff <> 0 THEN r.rngsubdiff::regproc::text ELSE '' END,
+			stn.nspname, st.typname
+		FROM pg_range r
+		JOIN pg_type st ON st.oid = r.rngsubtype
+		JOIN pg_namespace stn ON stn.oid = st.typnamespace
+		JOIN pg_opclass opc ON opc.oid = r.rngsubopc
+		JOIN pg_namespace opcn ON opcn.oid = opc.opcnamespace
+		LEFT JOIN pg_collation co ON co.oid = r.rngcollation
+		LEFT JOIN pg_namespace cn ON cn.oid = co.collnamespace
+		WHERE r.rngtypid = $1`, oid).Scan(&subtype, &opclass, &collation, &canonical, &subdiff, &subtypeSchema, &subtypeName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	options := []string{"    SUBTYPE = " + subtype}
+	if opclass != "" {
+		options = append(options, "    SUBTYPE_OPCLASS = "+opclass)
+	}
+	if collation != "" {
+		options = append(options, "    COLLATION = "+collation)
+	}
+	if canonical != "" {
+		options = append(options, "    CANONICAL = "+canonical)
+	}
+	if subdiff != "" {
+		options = append(options, "    SUBTYPE_DIFF = "+subdiff)
+	}
+
+	deps := newDependencySet()
+	deps.add(subtypeSchema, subtypeName)
+
+	return fmt.Sprintf("CREATE TYPE %s AS RANGE (\n%s\n)", qualified, strings.Join(options, ",\n")), deps.list(), nil
+}
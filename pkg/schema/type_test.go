@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnumDefinitionKeepsSortOrder(t *testing.T) {
+	db, config := testSchema(t, "pgsac_enums",
+		`CREATE TYPE pgsac_enums.mood AS ENUM ('sad', 'ok', 'happy')`,
+		`ALTER TYPE pgsac_enums.mood ADD VALUE 'meh' BEFORE 'ok'`,
+		`ALTER TYPE pgsac_enums.mood ADD VALUE 'ecstatic' AFTER 'happy'`,
+		`ALTER TYPE pgsac_enums.mood ADD VALUE 'angry' BEFORE 'sad'`,
+		`ALTER TYPE pgsac_enums.mood ADD VALUE 'it''s fine' AFTER 'meh'`)
+
+	obj := extractTestObject(t, db, config, Options{}, "pgsac_enums", "mood")
+	want := "CREATE TYPE pgsac_enums.mood AS ENUM (\n" +
+		"    'angry',\n    'sad',\n    'meh',\n    'it''s fine',\n    'ok',\n    'happy',\n    'ecstatic'\n)"
+	if obj.Definition != want {
+		t.Errorf("Definition =\n%s\nwant\n%s", obj.Definition, want)
+	}
+}
+
+func TestEvolveEnum(t *testing.T) {
+	enum := func(labels ...string) Object {
+		quoted := make([]string, len(labels))
+		for i, label := range labels {
+			quoted[i] = "    '" + label + "'"
+		}
+		return Object{Schema: "app", Name: "mood", Type: TypeType,
+			Definition: "CREATE TYPE app.mood AS ENUM (\n" + strings.Join(quoted, ",\n") + "\n)"}
+	}
+	previous := "-- Object: app.mood\n-- Type: type\n\n" + enum("sad", "ok").Definition + ";\n\nALTER TYPE app.mood OWNER TO alice;\n"
+
+	tests := []struct {
+		name     string
+		previous string
+		obj      Object
+		want     string
+		err      string
+	}{
+		{
+			name:     "unchanged",
+			previous: previous,
+			obj:      enum("sad", "ok"),
+			want:     enum("sad", "ok").Definition,
+		},
+		{
+			name:     "appended and inserted labels",
+			previous: previous,
+			obj:      enum("angry", "sad", "meh", "ok", "happy"),
+			want: enum("sad", "ok").Definition + ";\n\n" +
+				"ALTER TYPE app.mood ADD VALUE 'angry' BEFORE 'sad';\n\n" +
+				"ALTER TYPE app.mood ADD VALUE 'meh' AFTER 'sad';\n\n" +
+				"ALTER TYPE app.mood ADD VALUE 'happy' AFTER 'ok'",
+		},
+		{
+			name:     "earlier additions kept",
+			previous: previous + "\nALTER TYPE app.mood ADD VALUE 'meh' BEFORE 'ok';\n",
+			obj:      enum("sad", "meh", "ok", "happy"),
+			want: enum("sad", "ok").Definition + ";\n\n" +
+				"ALTER TYPE app.mood ADD VALUE 'meh' BEFORE 'ok';\n\n" +
+				"ALTER TYPE app.mood ADD VALUE 'happy' AFTER 'ok'",
+		},
+		{
+			name:     "reordered labels",
+			previous: previous,
+			obj:      enum("ok", "sad"),
+			want:     enum("ok", "sad").Definition,
+			err:      "enum app.mood had labels removed or reordered",
+		},
+		{
+			name:     "removed label",
+			previous: previous,
+			obj:      enum("ok"),
+			want:     enum("ok").Definition,
+			err:      "enum app.mood had labels removed or reordered",
+		},
+		{
+			name:     "other type in the file",
+			previous: "CREATE TYPE app.other AS ENUM ('a');\n",
+			obj:      enum("sad"),
+			want:     enum("sad").Definition,
+		},
+		{
+			name:     "not an enum",
+			previous: previous,
+			obj:      Object{Schema: "app", Name: "pair", Type: TypeType, Definition: "CREATE TYPE app.pair AS (\n    a integer\n)"},
+			want:     "CREATE TYPE app.pair AS (\n    a integer\n)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvolveEnum(tt.previous, tt.obj)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("EvolveEnum() error = %v, want %q", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("EvolveEnum() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package schema
 
+import (
+	"fmt"
+	"strings"
+)
+
 // ObjectType represents the type of database object
 type ObjectType string
 
@@ -8,19 +13,98 @@ const (
 	ViewType         ObjectType = "view"
 	MaterializedView ObjectType = "materialized_view"
 	FunctionType     ObjectType = "function"
+	SequenceType     ObjectType = "sequence"
+	IndexType        ObjectType = "index"
+	TypeType         ObjectType = "type"
+	DomainType       ObjectType = "domain"
+	ConstraintType   ObjectType = "constraint"
+	ForeignKeyType   ObjectType = "foreign_key"
+	PolicyType       ObjectType = "policy"
+	RuleType         ObjectType = "rule"
+	ForeignTableType ObjectType = "foreign_table"
+	EventTriggerType ObjectType = "event_trigger"
+	CollationType    ObjectType = "collation"
+
+	TextSearchConfigType     ObjectType = "text_search_configuration"
+	TextSearchDictionaryType ObjectType = "text_search_dictionary"
+
+	OperatorFamilyType ObjectType = "operator_family"
+	OperatorType       ObjectType = "operator"
+	CastType           ObjectType = "cast"
 )
 
-// Object represents a database object (table, view, materialized view, or function)
+// SchemaObjectTypes lists the object types ExtractSchemas extracts, in extraction order
+var SchemaObjectTypes = []ObjectType{
+	CollationType, TextSearchDictionaryType, TextSearchConfigType, TypeType, DomainType, TableType, ForeignTableType, SequenceType, ConstraintType, IndexType,
+	ViewType, MaterializedView, FunctionType, OperatorType, CastType, PolicyType, RuleType, ForeignKeyType, OperatorFamilyType,
+}
+
+// ParseObjectTypes returns the object types with the given names, failing on names that
+// are not in SchemaObjectTypes
+func ParseObjectTypes(names []string) ([]ObjectType, error) {
+	var types []ObjectType
+	for _, name := range names {
+		t := ObjectType(strings.TrimSpace(name))
+		if !t.extractable() {
+			valid := make([]string, len(SchemaObjectTypes))
+			for i, st := range SchemaObjectTypes {
+				valid[i] = string(st)
+			}
+			return nil, fmt.Errorf("unknown object type %q (expected one of %s)", name, strings.Join(valid, ", "))
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// extractable reports whether t is one of SchemaObjectTypes
+func (t ObjectType) extractable() bool {
+	for _, st := range SchemaObjectTypes {
+		if st == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Object represents a database object (table, view, materialized view, function, sequence, ...)
 type Object struct {
 	Schema     string
 	Name       string
 	Type       ObjectType
 	Definition string
-	Depends    []string // Names of objects this object depends on
+	Args       string    // Argument types of a function, e.g. "integer, text", or operand types of an operator; empty for other objects
+	Depends    []string  // Names of objects this object depends on
+	OID        uint32    // Catalog OID of the object, zero when not resolved
+	Security   *Security // Ownership and privileges, nil when not captured
+	Redacted   bool      // Parts of Definition were replaced by Redact
+}
+
+// Security holds the ownership and privileges of an object
+type Security struct {
+	Kind       string   // Object kind as reported by pg_identify_object (e.g. "table", "aggregate")
+	Identity   string   // Qualified and quoted identity as reported by pg_identify_object
+	Owner      string   // Role owning the object
+	ACL        []string // Privileges granted on the object, as aclitem strings; nil for the defaults
+	DefaultACL []string // Privileges a new object of this kind gets, as aclitem strings
+	Grants     []string // REVOKE and GRANT statements turning DefaultACL into ACL
+	Labels     []SecurityLabel
+}
+
+// SecurityLabel is a label attached to an object or one of its columns by a label provider
+type SecurityLabel struct {
+	Provider string
+	Column   string // Column the label is attached to, empty for the object itself
+	Label    string
 }
 
 // Schema represents a database schema and its objects
 type Schema struct {
 	Name    string
 	Objects []Object
+	// Owner and Comment of the schema itself; Comment is empty when not set
+	Owner   string
+	Comment string
+	// DefaultPrivileges holds the ALTER DEFAULT PRIVILEGES statements for objects created in the schema
+	DefaultPrivileges []string
 }
@@ -0,0 +1,130 @@
+// Package sqltoken splits SQL text into tokens, telling literals, dollar-quoted bodies and
+// comments apart from the statement text around them
+package sqltoken
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Token is a lexical element of SQL text
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+// Kind is the kind of a Token
+type Kind int
+
+const (
+	Space   Kind = iota // Whitespace within a line
+	Newline             // Whitespace holding line breaks
+	Word                // Keyword, unquoted identifier or number
+	Literal             // String, dollar-quoted body or quoted identifier, kept as is
+	Comment             // -- or /* */ comment, kept as is
+	Symbol              // Punctuation and operators
+)
+
+// Tokenize splits sql into tokens whose texts concatenate back to it, failing on
+// unterminated literals and comments
+func Tokenize(sql string) ([]Token, error) {
+	var tokens []Token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		kind := Symbol
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			for i < len(sql) && strings.IndexByte(" \t\r\n", sql[i]) >= 0 {
+				i++
+			}
+			kind = Space
+			if strings.Contains(sql[start:i], "\n") {
+				kind = Newline
+			}
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end
+			kind = Comment
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+			kind = Comment
+		case c == '\'':
+			// E'...' strings, whose E was read as a word, escape quotes with backslashes
+			escaped := len(tokens) > 0 && strings.EqualFold(tokens[len(tokens)-1].Text, "E")
+			end, err := quotedEnd(sql, i, '\'', escaped)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			kind = Literal
+		case c == '"':
+			end, err := quotedEnd(sql, i, '"', false)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			kind = Literal
+		case c == '$' && dollarTag(sql[i:]) != "":
+			tag := dollarTag(sql[i:])
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %s string", tag)
+			}
+			i += end + 2*len(tag)
+			kind = Literal
+		case isWordByte(c):
+			for i < len(sql) && (isWordByte(sql[i]) || sql[i] == '$') {
+				i++
+			}
+			kind = Word
+		default:
+			i++
+		}
+		tokens = append(tokens, Token{Kind: kind, Text: sql[start:i]})
+	}
+	return tokens, nil
+}
+
+// quotedEnd returns the index after the quoted text starting at start, doubled quotes
+// and, when escaped, backslash escapes included
+func quotedEnd(sql string, start int, quote byte, escaped bool) (int, error) {
+	for i := start + 1; i < len(sql); i++ {
+		switch {
+		case escaped && sql[i] == '\\':
+			i++
+		case sql[i] == quote && i+1 < len(sql) && sql[i+1] == quote:
+			i++
+		case sql[i] == quote:
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %c literal", quote)
+}
+
+// dollarTag returns the $tag$ opening a dollar-quoted string at the start of s, or ""
+// when s starts with something else, such as a $1 parameter
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case !isWordByte(s[i]) || (i == 1 && s[i] >= '0' && s[i] <= '9'):
+			return ""
+		}
+	}
+	return ""
+}
+
+// isWordByte reports whether c can be part of a keyword, unquoted identifier or number;
+// bytes of multi-byte characters are letters
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
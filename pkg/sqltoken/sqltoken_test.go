@@ -0,0 +1,52 @@
+package sqltoken
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name  string
+		sql   string
+		kinds []Kind
+	}{
+		{"words and symbols", "SELECT a, 1", []Kind{Word, Space, Word, Symbol, Space, Word}},
+		{"line break", "a \n b", []Kind{Word, Newline, Word}},
+		{"string", "'it''s'", []Kind{Literal}},
+		{"escaped string", `E'a\'b'`, []Kind{Word, Literal}},
+		{"quoted identifier", `"a ""b"""`, []Kind{Literal}},
+		{"dollar quotes", "$body$ 'x' $$ $body$", []Kind{Literal}},
+		{"parameter", "$1", []Kind{Symbol, Word}},
+		{"comments", "-- a\n/* b */", []Kind{Comment, Newline, Comment}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.sql)
+			if err != nil {
+				t.Fatalf("Tokenize() error = %v", err)
+			}
+			var kinds []Kind
+			var text strings.Builder
+			for _, tok := range tokens {
+				kinds = append(kinds, tok.Kind)
+				text.WriteString(tok.Text)
+			}
+			if text.String() != tt.sql {
+				t.Errorf("tokens concatenate to %q, want %q", text.String(), tt.sql)
+			}
+			if !slices.Equal(kinds, tt.kinds) {
+				t.Errorf("Tokenize() kinds = %v, want %v", kinds, tt.kinds)
+			}
+		})
+	}
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	for _, sql := range []string{"'a", `"a`, "$x$ a", "/* a"} {
+		if _, err := Tokenize(sql); err == nil {
+			t.Errorf("Tokenize(%q) succeeded, want an error", sql)
+		}
+	}
+}